@@ -0,0 +1,352 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+const fileMode = "100644"
+
+// Archiver writes snapshots of a user's notes to a GitHub repository. Each archive is
+// a single commit made through the Git Data API, replacing the user's directory, so
+// notes deleted in axon are removed from the repository too. Files outside the
+// directory are left as they are.
+type Archiver struct {
+	client *github.Client
+	Owner  string
+	Repo   string
+	// Branch to commit to, the repository default branch when empty
+	Branch string
+}
+
+// Result describes an archive commit. Paths are relative to the repository root.
+type Result struct {
+	CommitSHA string   `json:"commit_sha"`
+	Unchanged bool     `json:"unchanged"`
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+}
+
+// New builds an archiver for a repository written as owner/repo, normally
+// Settings.CoreSettings.GithubArchiveRepo. Point the client BaseURL at a fake server
+// to test against it.
+func New(client *github.Client, repository string) (*Archiver, error) {
+	owner, repo, err := ParseRepo(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archiver{client: client, Owner: owner, Repo: repo}, nil
+}
+
+func ParseRepo(repository string) (string, string, error) {
+	owner, repo, found := strings.Cut(strings.TrimSpace(repository), "/")
+	if !found || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("archive repository %q must be owner/repo", repository)
+	}
+	return owner, repo, nil
+}
+
+// Archive collects every folder and note owned by the session user and commits them
+func (ar *Archiver) Archive(a *axon_types.AxonContext, session axon_types.Session) (*Result, error) {
+	folders, err := Collect(a, session)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := UserDir(session)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := Files(folders)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := 0
+	for _, folder := range folders {
+		notes += len(folder.Notes)
+	}
+
+	subject := fmt.Sprintf("Archive %d %s in %d %s", notes, plural(notes, "note", "notes"), len(folders), plural(len(folders), "folder", "folders"))
+
+	return ar.Commit(a.Context, dir, files, subject)
+}
+
+// Collect reads a snapshot of the folders and notes owned by the session user. Items
+// shared with the user belong to someone else's archive and are left out.
+func Collect(a *axon_types.AxonContext, session axon_types.Session) ([]FolderSnapshot, error) {
+	folder := axon_core.Folder{Session: session}
+	note := axon_core.Note{Session: session}
+
+	// Every page is read, a note left out would be removed from the repository
+	folders := []axon_types.Folder{}
+	err := folder.EachFolder(a, func(f axon_types.Folder) error {
+		folders = append(folders, f)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("could not archive folders - " + err.Error())
+	}
+
+	snapshot := make([]FolderSnapshot, 0, len(folders))
+	for _, f := range folders {
+		details := []axon_types.NoteDetail{}
+		err := note.EachNote(a, f.FolderID, func(n axon_types.Note) error {
+			detail, err := note.GetNoteDetail(a, f.FolderID, n.NoteID)
+			if err != nil {
+				return fmt.Errorf("could not archive note %s - %w", n.NoteID, err)
+			}
+			details = append(details, *detail)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not archive folder %s - %w", f.FolderID, err)
+		}
+
+		snapshot = append(snapshot, FolderSnapshot{Folder: f, Notes: details})
+	}
+
+	return snapshot, nil
+}
+
+// Commit replaces the contents of dir with files, given relative to dir, in one
+// commit. The rest of the branch tree is kept. The commit message is the subject
+// followed by the paths added, updated and removed. Nothing is committed when the
+// directory is unchanged.
+func (ar *Archiver) Commit(ctx context.Context, dir string, files []File, subject string) (*Result, error) {
+	return ar.commit(ctx, dir, files, subject, commitOptions{})
+}
+
+type commitOptions struct {
+	// When set, the commit fails with ErrConflict unless the branch is still at parent
+	checkParent bool
+	parent      string
+	// Existing files of the directory kept although they are not in files, by path
+	// relative to the directory
+	preserve func(path string) bool
+}
+
+func (ar *Archiver) commit(ctx context.Context, dir string, files []File, subject string, options commitOptions) (*Result, error) {
+	branch, err := ar.branch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, baseTree, all, err := ar.head(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+	existing := dirFiles(all, dir)
+
+	if options.checkParent && parent != options.parent {
+		return nil, fmt.Errorf("could not commit - branch %s has moved since it was read - %w", branch, axon_core.ErrConflict)
+	}
+
+	result := &Result{CommitSHA: parent}
+	entries := make([]*github.TreeEntry, 0, len(files))
+	paths := map[string]bool{}
+
+	for _, file := range files {
+		if paths[file.Path] {
+			return nil, fmt.Errorf("could not archive - %s is written twice", file.Path)
+		}
+		paths[file.Path] = true
+
+		full := path.Join(dir, file.Path)
+		sha, exists := existing[file.Path]
+		switch {
+		case exists && sha == BlobSHA(file.Content):
+			// Kept by the base tree
+			continue
+		case exists:
+			result.Updated = append(result.Updated, full)
+		default:
+			result.Added = append(result.Added, full)
+		}
+
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.String(full),
+			Mode:    github.String(fileMode),
+			Type:    github.String("blob"),
+			Content: github.String(file.Content),
+		})
+	}
+
+	for p := range existing {
+		if paths[p] || (options.preserve != nil && options.preserve(p)) {
+			continue
+		}
+		full := path.Join(dir, p)
+		result.Removed = append(result.Removed, full)
+		// An entry without SHA or content deletes the file from the base tree
+		entries = append(entries, &github.TreeEntry{
+			Path: github.String(full),
+			Mode: github.String(fileMode),
+			Type: github.String("blob"),
+		})
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Removed)
+
+	if parent != "" && len(result.Added)+len(result.Updated)+len(result.Removed) == 0 {
+		result.Unchanged = true
+		return result, nil
+	}
+
+	tree, _, err := ar.client.Git.CreateTree(ctx, ar.Owner, ar.Repo, baseTree, entries)
+	if err != nil {
+		return nil, fmt.Errorf("could not create archive tree - %w", err)
+	}
+
+	commit := &github.Commit{
+		Message: github.String(commitMessage(subject, result)),
+		Tree:    &github.Tree{SHA: tree.SHA},
+	}
+	if parent != "" {
+		commit.Parents = []*github.Commit{{SHA: github.String(parent)}}
+	}
+
+	created, _, err := ar.client.Git.CreateCommit(ctx, ar.Owner, ar.Repo, commit)
+	if err != nil {
+		return nil, fmt.Errorf("could not create archive commit - %w", err)
+	}
+
+	ref := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: created.SHA},
+	}
+
+	if parent == "" {
+		_, _, err = ar.client.Git.CreateRef(ctx, ar.Owner, ar.Repo, ref)
+	} else {
+		// Not forced, so a concurrent archive makes this one fail instead of being lost
+		_, _, err = ar.client.Git.UpdateRef(ctx, ar.Owner, ar.Repo, ref, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not update archive branch - %w", err)
+	}
+
+	result.CommitSHA = created.GetSHA()
+	return result, nil
+}
+
+func (ar *Archiver) branch(ctx context.Context) (string, error) {
+	if ar.Branch != "" {
+		return ar.Branch, nil
+	}
+
+	repository, _, err := ar.client.Repositories.Get(ctx, ar.Owner, ar.Repo)
+	if err != nil {
+		return "", fmt.Errorf("could not read archive repository - %w", err)
+	}
+
+	ar.Branch = repository.GetDefaultBranch()
+	if ar.Branch == "" {
+		ar.Branch = "main"
+	}
+	return ar.Branch, nil
+}
+
+// head returns the branch commit, its tree and the blob SHA of each file in the tree.
+// A branch that does not exist yet has no commit and no files.
+func (ar *Archiver) head(ctx context.Context, branch string) (string, string, map[string]string, error) {
+	files := map[string]string{}
+
+	ref, response, err := ar.client.Git.GetRef(ctx, ar.Owner, ar.Repo, "heads/"+branch)
+	if response != nil && response.StatusCode == http.StatusNotFound {
+		return "", "", files, nil
+	}
+	if err != nil {
+		return "", "", nil, fmt.Errorf("could not read archive branch - %w", err)
+	}
+
+	parent := ref.GetObject().GetSHA()
+
+	commit, _, err := ar.client.Git.GetCommit(ctx, ar.Owner, ar.Repo, parent)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("could not read archive commit - %w", err)
+	}
+
+	tree, _, err := ar.client.Git.GetTree(ctx, ar.Owner, ar.Repo, commit.GetTree().GetSHA(), true)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("could not read archive tree - %w", err)
+	}
+
+	if tree.GetTruncated() {
+		return "", "", nil, errors.New("could not read archive tree - tree is too large to list")
+	}
+
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			files[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+
+	return parent, commit.GetTree().GetSHA(), files, nil
+}
+
+// dirFiles keeps the files under dir, by path relative to it
+func dirFiles(files map[string]string, dir string) map[string]string {
+	inside := map[string]string{}
+	for p, sha := range files {
+		if relative, ok := strings.CutPrefix(p, dir+"/"); ok {
+			inside[relative] = sha
+		}
+	}
+	return inside
+}
+
+// BlobSHA is the git object ID of a file, used to skip files that have not changed
+func BlobSHA(content string) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", len(content))
+	hash.Write([]byte(content))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func commitMessage(subject string, result *Result) string {
+	var b strings.Builder
+	b.WriteString(subject + "\n")
+
+	for _, change := range []struct {
+		name  string
+		paths []string
+	}{
+		{"Added", result.Added},
+		{"Updated", result.Updated},
+		{"Removed", result.Removed},
+	} {
+		if len(change.paths) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", change.name)
+		for _, path := range change.paths {
+			fmt.Fprintf(&b, "  %s\n", path)
+		}
+	}
+
+	return b.String()
+}
+
+func plural(n int, singular string, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
@@ -0,0 +1,226 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// fakeRepo serves the Git Data API calls made by the archiver for one repository
+// whose main branch is at commit "commit1" with tree "tree1"
+type fakeRepo struct {
+	// Files of tree1 by path
+	files map[string]string
+	// Body of the last tree created
+	created struct {
+		BaseTree string                   `json:"base_tree"`
+		Tree     []map[string]interface{} `json:"tree"`
+	}
+}
+
+func newFakeRepo(t *testing.T, files map[string]string) (*fakeRepo, *Archiver) {
+	t.Helper()
+
+	repo := &fakeRepo{files: files}
+	server := httptest.NewServer(repo)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	base, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = base
+
+	archiver, err := New(client, "axon/archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiver.Branch = "main"
+
+	return repo, archiver
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/repos/axon/archive")
+
+	switch {
+	case route == "GET /git/ref/heads/main":
+		fmt.Fprint(w, `{"ref": "refs/heads/main", "object": {"sha": "commit1"}}`)
+	case route == "GET /commits/main":
+		fmt.Fprint(w, "commit1")
+	case route == "GET /git/commits/commit1":
+		fmt.Fprint(w, `{"sha": "commit1", "tree": {"sha": "tree1"}}`)
+	case route == "GET /git/trees/tree1":
+		tree := github.Tree{SHA: github.String("tree1"), Truncated: github.Bool(false)}
+		for p, content := range f.files {
+			tree.Entries = append(tree.Entries, &github.TreeEntry{Path: github.String(p), Type: github.String("blob"), SHA: github.String(BlobSHA(content))})
+		}
+		json.NewEncoder(w).Encode(tree)
+	case strings.HasPrefix(route, "GET /git/blobs/"):
+		for _, content := range f.files {
+			if BlobSHA(content) == lastSegment(route) {
+				fmt.Fprint(w, content)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	case route == "POST /git/trees":
+		if err := json.NewDecoder(r.Body).Decode(&f.created); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"sha": "tree2"}`)
+	case route == "POST /git/commits":
+		fmt.Fprint(w, `{"sha": "commit2"}`)
+	case route == "PATCH /git/refs/heads/main":
+		fmt.Fprint(w, `{"ref": "refs/heads/main", "object": {"sha": "commit2"}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// lastSegment is the last segment of a route
+func lastSegment(route string) string {
+	return route[strings.LastIndex(route, "/")+1:]
+}
+
+func TestCommitReplacesOnlyUserDir(t *testing.T) {
+	repo, archiver := newFakeRepo(t, map[string]string{
+		"notes.txt":              "written by hand\n",
+		"user-b/README.md":       "# Axon archive\n",
+		"user-b/f1/folder.json":  "{}\n",
+		"user-a/README.md":       "# Axon archive\n",
+		"user-a/f2/folder.json":  "{}\n",
+		"user-a/old/folder.json": "{}\n",
+	})
+
+	result, err := archiver.Commit(context.Background(), "user-a", []File{
+		{Path: "README.md", Content: "# Axon archive\n"},
+		{Path: "f2/folder.json", Content: "{\"folder_id\": \"f2\"}\n"},
+		{Path: "f3/folder.json", Content: "{}\n"},
+	}, "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.CommitSHA != "commit2" {
+		t.Errorf("commit = %q, want commit2", result.CommitSHA)
+	}
+	if !reflect.DeepEqual(result.Added, []string{"user-a/f3/folder.json"}) {
+		t.Errorf("added = %v", result.Added)
+	}
+	if !reflect.DeepEqual(result.Updated, []string{"user-a/f2/folder.json"}) {
+		t.Errorf("updated = %v", result.Updated)
+	}
+	if !reflect.DeepEqual(result.Removed, []string{"user-a/old/folder.json"}) {
+		t.Errorf("removed = %v", result.Removed)
+	}
+
+	// The tree is built on the current one, so files of other users and files
+	// outside any user directory are kept without being listed
+	if repo.created.BaseTree != "tree1" {
+		t.Errorf("base tree = %q, want tree1", repo.created.BaseTree)
+	}
+
+	entries := map[string]map[string]interface{}{}
+	paths := []string{}
+	for _, entry := range repo.created.Tree {
+		p, _ := entry["path"].(string)
+		entries[p] = entry
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	want := []string{"user-a/f2/folder.json", "user-a/f3/folder.json", "user-a/old/folder.json"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("tree entries = %v, want %v", paths, want)
+	}
+
+	removed := entries["user-a/old/folder.json"]
+	if sha, ok := removed["sha"]; !ok || sha != nil {
+		t.Errorf("removed entry = %v, want a null sha", removed)
+	}
+	if content := entries["user-a/f2/folder.json"]["content"]; content != "{\"folder_id\": \"f2\"}\n" {
+		t.Errorf("updated content = %v", content)
+	}
+}
+
+func TestCommitUnchangedDir(t *testing.T) {
+	repo, archiver := newFakeRepo(t, map[string]string{
+		"user-a/README.md": "# Axon archive\n",
+		"user-b/README.md": "# Other archive\n",
+	})
+
+	result, err := archiver.Commit(context.Background(), "user-a", []File{
+		{Path: "README.md", Content: "# Axon archive\n"},
+	}, "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Unchanged || result.CommitSHA != "commit1" {
+		t.Errorf("result = %+v, want unchanged at commit1", result)
+	}
+	if repo.created.Tree != nil {
+		t.Errorf("a tree was created for an unchanged directory")
+	}
+}
+
+func TestReadOnlyUserDir(t *testing.T) {
+	_, archiver := newFakeRepo(t, map[string]string{
+		"f0/folder.json":        `{"folder_name": "Outside"}`,
+		"user-a/f1/folder.json": `{"folder_name": "Mine"}`,
+		"user-a/f1/n1.json":     `{"note_name": "My note"}`,
+		"user-a/f1/n1.md":       "# My note\n",
+		"user-b/f2/folder.json": `{"folder_name": "Theirs"}`,
+		"user-b/f2/n2.json":     `{"note_name": "Their note"}`,
+	})
+
+	sha, folders, err := archiver.Read(context.Background(), "user-a", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sha != "commit1" {
+		t.Errorf("sha = %q, want commit1", sha)
+	}
+	if len(folders) != 1 {
+		t.Fatalf("read %d folders, want 1", len(folders))
+	}
+
+	folder := folders[0]
+	if folder.Folder.FolderID != "f1" || folder.Folder.FolderName != "Mine" {
+		t.Errorf("folder = %+v", folder.Folder)
+	}
+	if len(folder.Notes) != 1 || folder.Notes[0].NoteID != "n1" || folder.Notes[0].FolderID != "f1" {
+		t.Errorf("notes = %+v", folder.Notes)
+	}
+}
+
+func TestUserDir(t *testing.T) {
+	session := func(user_id string) axon_types.Session {
+		return axon_types.Session{SessionData: axon_types.UserCache{User: axon_types.User{UserId: user_id}}}
+	}
+
+	dir, err := UserDir(session("3f1c"))
+	if err != nil || dir != "3f1c" {
+		t.Errorf("UserDir = %q, %v", dir, err)
+	}
+
+	for _, user_id := range []string{"", "a/b", ".."} {
+		if _, err := UserDir(session(user_id)); err == nil {
+			t.Errorf("UserDir(%q) did not fail", user_id)
+		}
+	}
+}
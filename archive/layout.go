@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	axon_markdown "github.com/stephensanwo/axon-lib/markdown"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+const (
+	FOLDER_FILE string = "folder.json"
+	INDEX_FILE  string = "README.md"
+)
+
+// File is a text file in the archive repository, with a path relative to its root
+type File struct {
+	Path    string
+	Content string
+}
+
+// FolderSnapshot is a folder with the full detail of each of its notes
+type FolderSnapshot struct {
+	Folder axon_types.Folder
+	Notes  []axon_types.NoteDetail
+}
+
+// Files lays a snapshot out as files of the user's directory, see UserDir:
+//
+//	README.md                     index of folders and notes
+//	<folder_id>/folder.json       folder metadata
+//	<folder_id>/<note_id>.json    note with its nodes and edges
+//	<folder_id>/<note_id>.md      the note as Markdown, see markdown.Export
+//
+// Paths use IDs so renaming a folder or note does not move its files. Output is
+// deterministic, so unchanged notes produce unchanged files.
+func Files(folders []FolderSnapshot) ([]File, error) {
+	folders = sortSnapshot(folders)
+
+	files := []File{{Path: INDEX_FILE, Content: renderIndex(folders)}}
+
+	for _, folder := range folders {
+		content, err := marshal(folder.Folder)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: FolderPath(folder.Folder.FolderID), Content: content})
+
+		for _, note := range folder.Notes {
+			content, err := marshal(note)
+			if err != nil {
+				return nil, err
+			}
+			text, err := axon_markdown.Export(note)
+			if err != nil {
+				return nil, fmt.Errorf("could not render note %s - %w", note.NoteID, err)
+			}
+			files = append(files,
+				File{Path: NotePath(folder.Folder.FolderID, note.NoteID), Content: content},
+				File{Path: MarkdownPath(folder.Folder.FolderID, note.NoteID), Content: text},
+			)
+		}
+	}
+
+	return files, nil
+}
+
+// UserDir is the directory of the repository holding the session user's files. One
+// repository serves every user, so each user only reads and writes under their ID.
+func UserDir(session axon_types.Session) (string, error) {
+	user_id := session.SessionData.User.UserId
+	if user_id == "" || strings.ContainsAny(user_id, "/.") {
+		return "", fmt.Errorf("could not archive - user ID %q cannot name a directory", user_id)
+	}
+	return user_id, nil
+}
+
+func FolderPath(folder_id string) string {
+	return path.Join(folder_id, FOLDER_FILE)
+}
+
+func NotePath(folder_id string, note_id string) string {
+	return path.Join(folder_id, note_id+".json")
+}
+
+func MarkdownPath(folder_id string, note_id string) string {
+	return path.Join(folder_id, note_id+".md")
+}
+
+func marshal(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", errors.New("could not encode archive file - " + err.Error())
+	}
+	return string(data) + "\n", nil
+}
+
+// sortSnapshot orders folders, notes, nodes and edges by ID, without modifying the input
+func sortSnapshot(folders []FolderSnapshot) []FolderSnapshot {
+	sorted := make([]FolderSnapshot, len(folders))
+	for i, folder := range folders {
+		notes := make([]axon_types.NoteDetail, len(folder.Notes))
+		for j, note := range folder.Notes {
+			note.Nodes = append([]axon_types.Node{}, note.Nodes...)
+			note.Edges = append([]axon_types.Edge{}, note.Edges...)
+			sort.Slice(note.Nodes, func(a, b int) bool { return note.Nodes[a].NodeID < note.Nodes[b].NodeID })
+			sort.Slice(note.Edges, func(a, b int) bool { return note.Edges[a].EdgeID < note.Edges[b].EdgeID })
+			notes[j] = note
+		}
+		sort.Slice(notes, func(a, b int) bool { return notes[a].NoteID < notes[b].NoteID })
+		sorted[i] = FolderSnapshot{Folder: folder.Folder, Notes: notes}
+	}
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Folder.FolderID < sorted[b].Folder.FolderID })
+	return sorted
+}
+
+func renderIndex(folders []FolderSnapshot) string {
+	var b strings.Builder
+	b.WriteString("# Axon archive\n")
+
+	for _, folder := range folders {
+		fmt.Fprintf(&b, "\n## %s\n\n", escapeMarkdown(folder.Folder.FolderName))
+		if len(folder.Notes) == 0 {
+			b.WriteString("No notes\n")
+			continue
+		}
+		for _, note := range folder.Notes {
+			fmt.Fprintf(&b, "- [%s](%s)\n", escapeMarkdown(note.NoteName), MarkdownPath(folder.Folder.FolderID, note.NoteID))
+		}
+	}
+
+	return b.String()
+}
+
+// escapeMarkdown keeps names on one line and stops them being read as markup
+func escapeMarkdown(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	replacer := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`", "#", `\#`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,316 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/google/uuid"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Strategy decides what happens to an archived folder or note whose ID already exists
+type Strategy string
+
+const (
+	// Keep the existing item, restore only what is missing
+	StrategySkip Strategy = "skip"
+	// Replace the existing item with the archived one
+	StrategyOverwrite Strategy = "overwrite"
+	// Restore the archived item alongside the existing one, with new IDs
+	StrategyDuplicate Strategy = "duplicate"
+)
+
+const (
+	ActionCreate    string = "create"
+	ActionOverwrite string = "overwrite"
+	ActionSkip      string = "skip"
+	ActionDuplicate string = "duplicate"
+)
+
+// Appended to the names of duplicated items, and of created folders whose name is taken
+const restoredSuffix = " (restored)"
+
+type RestoreOptions struct {
+	// Commit SHA, tag or branch to restore from, the archive branch when empty
+	Ref      string   `json:"ref"`
+	Strategy Strategy `json:"strategy"`
+	// Report the actions without writing anything
+	DryRun bool `json:"dry_run"`
+}
+
+type RestoreReport struct {
+	CommitSHA string          `json:"commit_sha"`
+	Strategy  Strategy        `json:"strategy"`
+	DryRun    bool            `json:"dry_run"`
+	Actions   []RestoreAction `json:"actions"`
+}
+
+// RestoreAction is what happened, or would happen in a dry run, to one folder or note.
+// The restored IDs differ from the archived IDs when the item is duplicated.
+type RestoreAction struct {
+	Type             string `json:"type"`
+	Action           string `json:"action"`
+	Name             string `json:"name"`
+	FolderID         string `json:"folder_id"`
+	NoteID           string `json:"note_id,omitempty"`
+	RestoredFolderID string `json:"restored_folder_id"`
+	RestoredNoteID   string `json:"restored_note_id,omitempty"`
+	Nodes            int    `json:"nodes,omitempty"`
+	Edges            int    `json:"edges,omitempty"`
+}
+
+func ValidStrategy(strategy Strategy) bool {
+	switch strategy {
+	case StrategySkip, StrategyOverwrite, StrategyDuplicate:
+		return true
+	}
+	return false
+}
+
+// Read loads the folders and notes stored under dir in the archive at ref. The README
+// and Markdown renderings are ignored, the JSON files are the source of truth.
+func (ar *Archiver) Read(ctx context.Context, dir string, ref string) (string, []FolderSnapshot, error) {
+	sha, snapshot, _, err := ar.read(ctx, dir, ref)
+	return sha, snapshot, err
+}
+
+// read also returns the blob SHA of each note file, keyed by folder and note ID. A ref
+// that does not exist returns an error wrapping core.ErrNotFound.
+func (ar *Archiver) read(ctx context.Context, dir string, ref string) (string, []FolderSnapshot, map[string]string, error) {
+	if ref == "" {
+		branch, err := ar.branch(ctx)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		ref = branch
+	}
+
+	sha, response, err := ar.client.Repositories.GetCommitSHA1(ctx, ar.Owner, ar.Repo, ref, "")
+	if response != nil && (response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusUnprocessableEntity) {
+		return "", nil, nil, fmt.Errorf("could not resolve archive ref %s - %w", ref, axon_core.ErrNotFound)
+	}
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("could not resolve archive ref %s - %w", ref, err)
+	}
+
+	commit, _, err := ar.client.Git.GetCommit(ctx, ar.Owner, ar.Repo, sha)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("could not read archive commit - %w", err)
+	}
+
+	tree, _, err := ar.client.Git.GetTree(ctx, ar.Owner, ar.Repo, commit.GetTree().GetSHA(), true)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("could not read archive tree - %w", err)
+	}
+
+	if tree.GetTruncated() {
+		return "", nil, nil, errors.New("could not read archive tree - tree is too large to list")
+	}
+
+	folders := map[string]*FolderSnapshot{}
+	order := []string{}
+	notes := map[string][]*github.TreeEntry{}
+	blobs := map[string]string{}
+
+	for _, entry := range tree.Entries {
+		// Files of other users, and any added by hand outside the directory
+		relative, inside := strings.CutPrefix(entry.GetPath(), dir+"/")
+		folder_id, name := path.Split(relative)
+		folder_id = strings.TrimSuffix(folder_id, "/")
+		if !inside || entry.GetType() != "blob" || folder_id == "" || strings.Contains(folder_id, "/") {
+			continue
+		}
+
+		switch {
+		case name == FOLDER_FILE:
+			var folder axon_types.Folder
+			if err := ar.readJSON(ctx, entry.GetSHA(), &folder); err != nil {
+				return "", nil, nil, fmt.Errorf("could not read %s - %w", entry.GetPath(), err)
+			}
+			// The path is authoritative, the file may have been edited by hand
+			folder.FolderID = folder_id
+			folders[folder_id] = &FolderSnapshot{Folder: folder}
+			order = append(order, folder_id)
+		case strings.HasSuffix(name, ".json"):
+			notes[folder_id] = append(notes[folder_id], entry)
+		}
+	}
+
+	for folder_id, entries := range notes {
+		folder, ok := folders[folder_id]
+		if !ok {
+			return "", nil, nil, fmt.Errorf("could not read archive - %s has notes but no %s", folder_id, FOLDER_FILE)
+		}
+
+		for _, entry := range entries {
+			var note axon_types.NoteDetail
+			if err := ar.readJSON(ctx, entry.GetSHA(), &note); err != nil {
+				return "", nil, nil, fmt.Errorf("could not read %s - %w", entry.GetPath(), err)
+			}
+			note.FolderID = folder_id
+			note.NoteID = strings.TrimSuffix(path.Base(entry.GetPath()), ".json")
+			blobs[noteKey(folder_id, note.NoteID)] = entry.GetSHA()
+			folder.Notes = append(folder.Notes, note)
+		}
+	}
+
+	snapshot := make([]FolderSnapshot, 0, len(order))
+	for _, folder_id := range order {
+		snapshot = append(snapshot, *folders[folder_id])
+	}
+
+	return sha, sortSnapshot(snapshot), blobs, nil
+}
+
+func (ar *Archiver) readJSON(ctx context.Context, sha string, v interface{}) error {
+	data, _, err := ar.client.Git.GetBlobRaw(ctx, ar.Owner, ar.Repo, sha)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Restore recreates the session user's archived folders and notes at options.Ref in
+// their account
+func (ar *Archiver) Restore(a *axon_types.AxonContext, session axon_types.Session, options RestoreOptions) (*RestoreReport, error) {
+	if !ValidStrategy(options.Strategy) {
+		return nil, fmt.Errorf("could not restore - unknown strategy %q", options.Strategy)
+	}
+
+	dir, err := UserDir(session)
+	if err != nil {
+		return nil, err
+	}
+
+	sha, folders, err := ar.Read(a.Context, dir, options.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := RestoreSnapshot(a, session, folders, options)
+	if err != nil {
+		return nil, err
+	}
+
+	report.CommitSHA = sha
+	return report, nil
+}
+
+// RestoreSnapshot applies a snapshot to the session user's account. With DryRun the
+// existing data is read to plan the actions, and nothing is written.
+func RestoreSnapshot(a *axon_types.AxonContext, session axon_types.Session, folders []FolderSnapshot, options RestoreOptions) (*RestoreReport, error) {
+	folder := axon_core.Folder{Session: session}
+	note := axon_core.Note{Session: session}
+	importer := axon_core.Import{Session: session}
+
+	existing, err := folder.GetFolders(a)
+	if err != nil {
+		return nil, errors.New("could not restore - " + err.Error())
+	}
+
+	existingFolders := map[string]bool{}
+	for _, f := range *existing {
+		existingFolders[f.FolderID] = true
+	}
+
+	report := &RestoreReport{Strategy: options.Strategy, DryRun: options.DryRun, Actions: []RestoreAction{}}
+
+	for _, snapshot := range folders {
+		restored := snapshot.Folder
+		action := ActionCreate
+
+		if existingFolders[restored.FolderID] {
+			switch options.Strategy {
+			case StrategySkip:
+				action = ActionSkip
+			case StrategyOverwrite:
+				action = ActionOverwrite
+			case StrategyDuplicate:
+				action = ActionDuplicate
+				restored.FolderID = uuid.New().String()
+				restored.FolderName += restoredSuffix
+			}
+		}
+
+		report.Actions = append(report.Actions, RestoreAction{
+			Type:             axon_types.ShareResourceFolder,
+			Action:           action,
+			Name:             restored.FolderName,
+			FolderID:         snapshot.Folder.FolderID,
+			RestoredFolderID: restored.FolderID,
+		})
+
+		if !options.DryRun && action != ActionSkip {
+			if err := importer.PutFolder(a, restored); err != nil {
+				return nil, err
+			}
+		}
+
+		// Notes of a new folder cannot conflict
+		existingNotes := map[string]bool{}
+		if action == ActionSkip || action == ActionOverwrite {
+			notes, err := note.GetNotes(a, restored.FolderID)
+			if err != nil {
+				return nil, errors.New("could not restore - " + err.Error())
+			}
+			for _, n := range *notes {
+				existingNotes[n.NoteID] = true
+			}
+		}
+
+		for _, archived := range snapshot.Notes {
+			restoredNote := archived
+			restoredNote.FolderID = restored.FolderID
+			noteAction := ActionCreate
+
+			// A duplicated folder gets copies of its notes, so no ID is shared with the original
+			if restored.FolderID != snapshot.Folder.FolderID {
+				restoredNote = axon_core.RenumberNote(restoredNote, restored.FolderID)
+			}
+
+			if existingNotes[archived.NoteID] {
+				switch options.Strategy {
+				case StrategySkip:
+					noteAction = ActionSkip
+				case StrategyOverwrite:
+					noteAction = ActionOverwrite
+				case StrategyDuplicate:
+					noteAction = ActionDuplicate
+					restoredNote = axon_core.RenumberNote(restoredNote, restored.FolderID)
+					restoredNote.NoteName += restoredSuffix
+				}
+			}
+
+			report.Actions = append(report.Actions, RestoreAction{
+				Type:             axon_types.ShareResourceNote,
+				Action:           noteAction,
+				Name:             restoredNote.NoteName,
+				FolderID:         snapshot.Folder.FolderID,
+				NoteID:           archived.NoteID,
+				RestoredFolderID: restoredNote.FolderID,
+				RestoredNoteID:   restoredNote.NoteID,
+				Nodes:            len(restoredNote.Nodes),
+				Edges:            len(restoredNote.Edges),
+			})
+
+			if !options.DryRun && noteAction != ActionSkip {
+				if err := importer.PutNote(a, restoredNote); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func noteKey(folder_id string, note_id string) string {
+	return folder_id + "/" + note_id
+}
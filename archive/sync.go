@@ -0,0 +1,420 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Appended to the name of the copy made of the repository version in a conflict
+const conflictSuffix = " (conflict from GitHub)"
+
+// SyncReport lists the notes changed by a sync, as folder_id/note_id
+type SyncReport struct {
+	CommitSHA     string         `json:"commit_sha"`
+	Pulled        []string       `json:"pulled"`
+	Pushed        []string       `json:"pushed"`
+	DeletedLocal  []string       `json:"deleted_local"`
+	DeletedRemote []string       `json:"deleted_remote"`
+	Conflicts     []SyncConflict `json:"conflicts"`
+}
+
+// SyncConflict is a note changed on both sides since the last sync. The axon version
+// keeps its ID and is pushed, the repository version is kept as a copy.
+type SyncConflict struct {
+	FolderID   string `json:"folder_id"`
+	NoteID     string `json:"note_id"`
+	NoteName   string `json:"note_name"`
+	CopyNoteID string `json:"copy_note_id"`
+}
+
+// Sync reconciles the session user's notes with the repository in both directions.
+// Each side is compared with the state saved by the last sync: a note changed on one
+// side is copied to the other, a note deleted on one side and unchanged on the other
+// is deleted, and a note changed on both sides is kept in both versions. Folders are
+// created on either side as needed but only deleted from the repository. Only the
+// user's directory is read and written, and files in it outside the archive layout
+// are left untouched.
+func (ar *Archiver) Sync(a *axon_types.AxonContext, session axon_types.Session) (*SyncReport, error) {
+	syncer := axon_core.Sync{Session: session}
+	repository := ar.Owner + "/" + ar.Repo
+
+	dir, err := UserDir(session)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, err := ar.branch(a.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := syncer.GetSyncState(a, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteSHA, remote, blobs, err := ar.read(a.Context, dir, branch)
+	if err != nil && !errors.Is(err, axon_core.ErrNotFound) {
+		return nil, err
+	}
+	state = baseState(state, repository, branch, dir, remoteSHA)
+
+	local, err := Collect(a, session)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSyncer(a, session, state, sortSnapshot(local), remote, blobs)
+	if err := s.reconcile(); err != nil {
+		return nil, err
+	}
+
+	final, err := Collect(a, session)
+	if err != nil {
+		return nil, err
+	}
+	final = sortSnapshot(final)
+
+	files, err := Files(final)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("Sync from axon: %d pushed, %d pulled, %d %s",
+		len(s.report.Pushed), len(s.report.Pulled), len(s.report.Conflicts), plural(len(s.report.Conflicts), "conflict", "conflicts"))
+
+	result, err := ar.commit(a.Context, dir, files, subject, commitOptions{
+		checkParent: true,
+		parent:      remoteSHA,
+		preserve:    func(p string) bool { return !isLayoutPath(p) },
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.report.CommitSHA = result.CommitSHA
+
+	// Record the synced notes as the base of the next sync
+	next := axon_types.SyncState{
+		Repository:  repository,
+		Branch:      branch,
+		Directory:   dir,
+		CommitSHA:   result.CommitSHA,
+		Folders:     []string{},
+		Notes:       map[string]axon_types.NoteSyncState{},
+		LastSynced:  time.Now(),
+		DateCreated: state.DateCreated,
+	}
+
+	for _, folder := range final {
+		next.Folders = append(next.Folders, folder.Folder.FolderID)
+		for _, note := range folder.Notes {
+			content, err := marshal(note)
+			if err != nil {
+				return nil, err
+			}
+			next.Notes[noteKey(folder.Folder.FolderID, note.NoteID)] = axon_types.NoteSyncState{
+				FolderID:   folder.Folder.FolderID,
+				NoteID:     note.NoteID,
+				BlobSHA:    BlobSHA(content),
+				LastEdited: note.LastEdited,
+			}
+		}
+	}
+
+	if err := syncer.PutSyncState(a, next); err != nil {
+		return nil, err
+	}
+
+	return s.report, nil
+}
+
+type syncer struct {
+	a        *axon_types.AxonContext
+	importer axon_core.Import
+	note     axon_core.Note
+	state    *axon_types.SyncState
+	report   *SyncReport
+
+	localFolders  map[string]axon_types.Folder
+	remoteFolders map[string]axon_types.Folder
+	localNotes    map[string]axon_types.NoteDetail
+	remoteNotes   map[string]axon_types.NoteDetail
+	blobs         map[string]string
+}
+
+func newSyncer(a *axon_types.AxonContext, session axon_types.Session, state *axon_types.SyncState, local []FolderSnapshot, remote []FolderSnapshot, blobs map[string]string) *syncer {
+	s := &syncer{
+		a:             a,
+		importer:      axon_core.Import{Session: session},
+		note:          axon_core.Note{Session: session},
+		state:         state,
+		report:        &SyncReport{Pulled: []string{}, Pushed: []string{}, DeletedLocal: []string{}, DeletedRemote: []string{}, Conflicts: []SyncConflict{}},
+		localFolders:  map[string]axon_types.Folder{},
+		remoteFolders: map[string]axon_types.Folder{},
+		localNotes:    map[string]axon_types.NoteDetail{},
+		remoteNotes:   map[string]axon_types.NoteDetail{},
+		blobs:         blobs,
+	}
+
+	for _, folder := range local {
+		s.localFolders[folder.Folder.FolderID] = folder.Folder
+		for _, note := range folder.Notes {
+			s.localNotes[noteKey(folder.Folder.FolderID, note.NoteID)] = note
+		}
+	}
+
+	for _, folder := range remote {
+		s.remoteFolders[folder.Folder.FolderID] = folder.Folder
+		for _, note := range folder.Notes {
+			s.remoteNotes[noteKey(folder.Folder.FolderID, note.NoteID)] = note
+		}
+	}
+
+	return s
+}
+
+// baseState returns the state both sides are compared against. A state recorded
+// against another branch or directory is no common base, and neither is one whose
+// branch no longer exists: every synced note would look deleted from the repository.
+func baseState(state *axon_types.SyncState, repository string, branch string, dir string, remoteSHA string) *axon_types.SyncState {
+	if remoteSHA != "" && (state.Branch == "" || (state.Branch == branch && state.Directory == dir)) {
+		return state
+	}
+
+	return &axon_types.SyncState{Repository: repository, Notes: map[string]axon_types.NoteSyncState{}, DateCreated: state.DateCreated}
+}
+
+// syncAction is what a sync does with one note
+type syncAction int
+
+const (
+	// Already in sync, or changed the same way on both sides
+	syncNone syncAction = iota
+	syncPull
+	syncPush
+	syncConflict
+	syncDeleteLocal
+	syncDeleteRemote
+)
+
+// noteVersions holds the blob SHAs of a note in axon, in the repository and after the
+// last sync, each empty where the note is missing. Same is set when both sides hold
+// the same content, ignoring the fields that differ between accounts.
+type noteVersions struct {
+	Local  string
+	Remote string
+	Base   string
+	Same   bool
+}
+
+// decide returns the action that reconciles one note
+func decide(v noteVersions) syncAction {
+	hasLocal, hasRemote, hasBase := v.Local != "", v.Remote != "", v.Base != ""
+	localChanged := !hasBase || v.Local != v.Base
+	remoteChanged := !hasBase || v.Remote != v.Base
+
+	switch {
+	case hasLocal && hasRemote:
+		switch {
+		case v.Same:
+			return syncNone
+		case localChanged && remoteChanged:
+			return syncConflict
+		case remoteChanged:
+			return syncPull
+		default:
+			return syncPush
+		}
+	case hasLocal:
+		// Deleted from the repository and unchanged in axon
+		if hasBase && !localChanged {
+			return syncDeleteLocal
+		}
+		return syncPush
+	case hasRemote:
+		// Deleted in axon and unchanged in the repository
+		if hasBase && !remoteChanged {
+			return syncDeleteRemote
+		}
+		return syncPull
+	}
+
+	return syncNone
+}
+
+func (s *syncer) reconcile() error {
+	keys := map[string]bool{}
+	for key := range s.localNotes {
+		keys[key] = true
+	}
+	for key := range s.remoteNotes {
+		keys[key] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		local, hasLocal := s.localNotes[key]
+		remote, hasRemote := s.remoteNotes[key]
+
+		versions := noteVersions{Base: s.state.Notes[key].BlobSHA}
+		if hasLocal {
+			content, err := marshal(local)
+			if err != nil {
+				return err
+			}
+			versions.Local = BlobSHA(content)
+		}
+		if hasRemote {
+			versions.Remote = s.blobs[key]
+		}
+		if hasLocal && hasRemote {
+			versions.Same = sameContent(local, remote)
+		}
+
+		switch decide(versions) {
+		case syncConflict:
+			if err := s.conflict(key, local, remote); err != nil {
+				return err
+			}
+		case syncPull:
+			if err := s.pull(key, remote); err != nil {
+				return err
+			}
+		case syncPush:
+			s.report.Pushed = append(s.report.Pushed, key)
+		case syncDeleteLocal:
+			if _, err := s.note.DeleteNote(s.a, local.FolderID, local.NoteID); err != nil {
+				return err
+			}
+			s.report.DeletedLocal = append(s.report.DeletedLocal, key)
+		case syncDeleteRemote:
+			// Dropped from the commit
+			s.report.DeletedRemote = append(s.report.DeletedRemote, key)
+		}
+	}
+
+	// Folders added to the repository since the last sync, even if empty
+	known := map[string]bool{}
+	for _, folder_id := range s.state.Folders {
+		known[folder_id] = true
+	}
+	for folder_id := range s.remoteFolders {
+		if !known[folder_id] {
+			if err := s.ensureFolder(folder_id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *syncer) pull(key string, remote axon_types.NoteDetail) error {
+	if err := s.ensureFolder(remote.FolderID); err != nil {
+		return err
+	}
+
+	if err := s.importer.PutNote(s.a, remote); err != nil {
+		return err
+	}
+
+	s.report.Pulled = append(s.report.Pulled, key)
+	return nil
+}
+
+// conflict keeps the axon version of a note under its ID, and imports the repository
+// version next to it as a new note. Both are in the repository after the commit.
+func (s *syncer) conflict(key string, local axon_types.NoteDetail, remote axon_types.NoteDetail) error {
+	copied := axon_core.RenumberNote(remote, local.FolderID)
+	copied.NoteName += conflictSuffix
+
+	if err := s.importer.PutNote(s.a, copied); err != nil {
+		return err
+	}
+
+	s.report.Conflicts = append(s.report.Conflicts, SyncConflict{
+		FolderID:   local.FolderID,
+		NoteID:     local.NoteID,
+		NoteName:   local.NoteName,
+		CopyNoteID: copied.NoteID,
+	})
+	s.report.Pushed = append(s.report.Pushed, key)
+	return nil
+}
+
+// ensureFolder creates a folder that only exists in the repository
+func (s *syncer) ensureFolder(folder_id string) error {
+	if _, ok := s.localFolders[folder_id]; ok {
+		return nil
+	}
+
+	folder := s.remoteFolders[folder_id]
+	folder.FolderID = folder_id
+
+	if err := s.importer.PutFolder(s.a, folder); err != nil {
+		return err
+	}
+
+	s.localFolders[folder_id] = folder
+	return nil
+}
+
+// sameContent compares notes ignoring the fields that differ between accounts and
+// change on every write
+func sameContent(a axon_types.NoteDetail, b axon_types.NoteDetail) bool {
+	aContent, aErr := marshal(normalise(a))
+	bContent, bErr := marshal(normalise(b))
+	return aErr == nil && bErr == nil && aContent == bContent
+}
+
+func normalise(note axon_types.NoteDetail) axon_types.NoteDetail {
+	note.UserId = ""
+	note.DateCreated = time.Time{}
+	note.LastEdited = time.Time{}
+
+	nodes := make([]axon_types.Node, len(note.Nodes))
+	for i, node := range note.Nodes {
+		node.UserId, node.FolderID, node.NoteID, node.LastEdited = "", "", "", time.Time{}
+		nodes[i] = node
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+
+	edges := make([]axon_types.Edge, len(note.Edges))
+	for i, edge := range note.Edges {
+		edge.UserId, edge.FolderID, edge.NoteID, edge.LastEdited = "", "", "", time.Time{}
+		edges[i] = edge
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].EdgeID < edges[j].EdgeID })
+
+	note.Nodes, note.Edges = nodes, edges
+	return note
+}
+
+// isLayoutPath reports whether a path relative to the user's directory belongs to
+// the archive layout, as opposed to files added to it by hand
+func isLayoutPath(p string) bool {
+	if p == INDEX_FILE {
+		return true
+	}
+
+	dir, name := path.Split(p)
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" || strings.Contains(dir, "/") {
+		return false
+	}
+
+	return name == FOLDER_FILE || path.Ext(name) == ".json" || path.Ext(name) == ".md"
+}
@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions noteVersions
+		want     syncAction
+	}{
+		{"in sync", noteVersions{Local: "a", Remote: "a", Base: "a", Same: true}, syncNone},
+		{"changed the same way", noteVersions{Local: "b", Remote: "b2", Base: "a", Same: true}, syncNone},
+		{"changed in axon", noteVersions{Local: "b", Remote: "a", Base: "a"}, syncPush},
+		{"changed in the repository", noteVersions{Local: "a", Remote: "b", Base: "a"}, syncPull},
+		{"changed on both sides", noteVersions{Local: "b", Remote: "c", Base: "a"}, syncConflict},
+		{"on both sides without base", noteVersions{Local: "b", Remote: "c"}, syncConflict},
+		{"added in axon", noteVersions{Local: "b"}, syncPush},
+		{"added to the repository", noteVersions{Remote: "b"}, syncPull},
+		{"deleted from the repository", noteVersions{Local: "a", Base: "a"}, syncDeleteLocal},
+		{"deleted from the repository, changed in axon", noteVersions{Local: "b", Base: "a"}, syncPush},
+		{"deleted in axon", noteVersions{Remote: "a", Base: "a"}, syncDeleteRemote},
+		{"deleted in axon, changed in the repository", noteVersions{Remote: "b", Base: "a"}, syncPull},
+		{"deleted on both sides", noteVersions{Base: "a"}, syncNone},
+	}
+
+	for _, test := range tests {
+		if got := decide(test.versions); got != test.want {
+			t.Errorf("%s: decide(%+v) = %d, want %d", test.name, test.versions, got, test.want)
+		}
+	}
+}
+
+func TestBaseState(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	synced := func() *axon_types.SyncState {
+		return &axon_types.SyncState{
+			Repository:  "axon/archive",
+			Branch:      "main",
+			Directory:   "user-a",
+			CommitSHA:   "commit1",
+			Folders:     []string{"f1"},
+			Notes:       map[string]axon_types.NoteSyncState{"f1/n1": {FolderID: "f1", NoteID: "n1", BlobSHA: "a"}},
+			DateCreated: created,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		state     *axon_types.SyncState
+		branch    string
+		dir       string
+		remoteSHA string
+		keep      bool
+	}{
+		{"same branch and directory", synced(), "main", "user-a", "commit1", true},
+		{"never synced", &axon_types.SyncState{Repository: "axon/archive"}, "main", "user-a", "commit1", true},
+		{"missing branch", synced(), "main", "user-a", "", false},
+		{"other branch", synced(), "archive", "user-a", "commit1", false},
+		{"other directory", synced(), "main", "user-b", "commit1", false},
+	}
+
+	for _, test := range tests {
+		got := baseState(test.state, "axon/archive", test.branch, test.dir, test.remoteSHA)
+		if test.keep {
+			if got != test.state {
+				t.Errorf("%s: state was reset", test.name)
+			}
+			continue
+		}
+
+		if len(got.Notes) != 0 || len(got.Folders) != 0 || got.CommitSHA != "" {
+			t.Errorf("%s: state = %+v, want no base", test.name, got)
+		}
+		if got.Repository != "axon/archive" || !got.DateCreated.Equal(test.state.DateCreated) {
+			t.Errorf("%s: state = %+v, want the repository and creation date kept", test.name, got)
+		}
+	}
+}
+
+// A sync against a deleted branch reads nothing, and must not take the saved state as
+// a base, or every synced note would be deleted in axon
+func TestSyncMissingBranchHasNoBase(t *testing.T) {
+	_, archiver := newFakeRepo(t, map[string]string{})
+	archiver.Branch = "gone"
+
+	remoteSHA, remote, _, err := archiver.read(context.Background(), "user-a", archiver.Branch)
+	if !errors.Is(err, axon_core.ErrNotFound) {
+		t.Fatalf("read error = %v, want ErrNotFound", err)
+	}
+	if remoteSHA != "" || len(remote) != 0 {
+		t.Fatalf("read %q, %v from a missing branch", remoteSHA, remote)
+	}
+
+	state := &axon_types.SyncState{
+		Repository: "axon/archive",
+		Branch:     "gone",
+		Directory:  "user-a",
+		Notes:      map[string]axon_types.NoteSyncState{"f1/n1": {FolderID: "f1", NoteID: "n1", BlobSHA: "a"}},
+	}
+	base := baseState(state, "axon/archive", "gone", "user-a", remoteSHA)
+
+	action := decide(noteVersions{Local: "a", Base: base.Notes["f1/n1"].BlobSHA})
+	if action != syncPush {
+		t.Errorf("unchanged local note = %d, want pushed", action)
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Environment variables overriding settings start with this prefix, e.g.
+	// AXON_OAUTH_SETTINGS__CLIENT_SECRET sets oauth_settings.client_secret
+	ENV_PREFIX string = "AXON_"
+	// Separates the levels of a settings path in an environment variable name
+	ENV_SEPARATOR string = "__"
+	// A key with this suffix holds the path of a file containing the value, e.g.
+	// client_secret_file in YAML or AXON_OAUTH_SETTINGS__CLIENT_SECRET_FILE
+	FILE_SUFFIX string = "_file"
+)
+
+var settingsType = reflect.TypeOf(axon_types.Settings{})
+
+// Load reads the YAML settings file at path, applies the AXON_ environment
+// overrides of the process and validates the result
+func Load(path string) (*axon_types.Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("could not read settings - " + err.Error())
+	}
+
+	return Parse(data, os.Environ())
+}
+
+// Parse builds settings from YAML and environment variables in KEY=value form.
+// Environment variables take precedence over the YAML, and *_file keys are replaced
+// with the trimmed contents of the file they point to.
+func Parse(data []byte, environ []string) (*axon_types.Settings, error) {
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, errors.New("could not parse settings - " + err.Error())
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	if err := resolveFiles(values, nil); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnvironment(values, environ); err != nil {
+		return nil, err
+	}
+
+	settings, err := decode(values)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Validate(settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// decode re-encodes the merged values and decodes them into Settings, rejecting keys
+// that do not match a setting
+func decode(values map[string]interface{}) (*axon_types.Settings, error) {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, errors.New("could not parse settings - " + err.Error())
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var settings axon_types.Settings
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, errors.New("could not parse settings - " + err.Error())
+	}
+
+	if settings.Metadata.Environment == "" {
+		settings.Metadata.Environment = axon_types.DEVELOPMENT
+	}
+
+	return &settings, nil
+}
+
+// resolveFiles replaces every key ending in _file that names a string setting with
+// the contents of the file. Setting both the value and its file is an error.
+func resolveFiles(values map[string]interface{}, path []string) error {
+	for key, value := range values {
+		child := append(append([]string{}, path...), key)
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			if err := resolveFiles(nested, child); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := strings.TrimSuffix(key, FILE_SUFFIX)
+		if name == key || !isStringSetting(append(append([]string{}, path...), name)) {
+			continue
+		}
+
+		if _, exists := values[name]; exists {
+			return fmt.Errorf("could not parse settings - both %s and %s are set", strings.Join(append(path, name), "."), strings.Join(child, "."))
+		}
+
+		filename, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("could not parse settings - %s must be a file path", strings.Join(child, "."))
+		}
+
+		contents, err := readSecret(filename)
+		if err != nil {
+			return fmt.Errorf("could not read %s - %s", strings.Join(child, "."), err.Error())
+		}
+
+		delete(values, key)
+		values[name] = contents
+	}
+
+	return nil
+}
+
+func readSecret(filename string) (string, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+func isStringSetting(path []string) bool {
+	t, ok := settingType(path)
+	return ok && t.Kind() == reflect.String
+}
+
+// settingType follows yaml tags from Settings down a settings path. Map keys match any
+// name, so security.keys.<id> resolves to the map value type.
+func settingType(path []string) (reflect.Type, bool) {
+	t := settingsType
+	for _, name := range path {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByYamlName(t, name)
+			if !ok {
+				return nil, false
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	return t, true
+}
+
+func fieldByYamlName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// BuildOauthConfig builds the oauth2 client configuration from the OAuth settings
+func BuildOauthConfig(settings axon_types.OauthSettings) oauth2.Config {
+	return oauth2.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  settings.AuthorizeUrl,
+			TokenURL: settings.AccessTokenUrl,
+		},
+		RedirectURL: settings.RedirectUri,
+		Scopes:      settings.Scope,
+	}
+}
+
+// NewAxonContext returns the application context for loaded settings
+func NewAxonContext(ctx context.Context, settings *axon_types.Settings) *axon_types.AxonContext {
+	return &axon_types.AxonContext{
+		Context:  ctx,
+		Settings: *settings,
+		Oauth:    BuildOauthConfig(settings.OauthSettings),
+	}
+}
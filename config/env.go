@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// applyEnvironment overlays AXON_<SECTION>__<FIELD> variables onto the YAML values.
+// Names are matched case insensitively, lists are comma separated, and a _FILE
+// suffix reads the value from a file. Variables without the separator, such as
+// AXON_ENV, are left to the application.
+func applyEnvironment(values map[string]interface{}, environ []string) error {
+	// Apply in a fixed order so that errors are reproducible
+	sorted := append([]string{}, environ...)
+	sort.Strings(sorted)
+
+	for _, variable := range sorted {
+		name, raw, found := strings.Cut(variable, "=")
+		if !found || !strings.HasPrefix(name, ENV_PREFIX) || !strings.Contains(name, ENV_SEPARATOR) {
+			continue
+		}
+
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, ENV_PREFIX)), strings.ToLower(ENV_SEPARATOR))
+		last := path[len(path)-1]
+
+		if fileName := strings.TrimSuffix(last, FILE_SUFFIX); fileName != last && isStringSetting(append(path[:len(path)-1:len(path)-1], fileName)) {
+			contents, err := readSecret(raw)
+			if err != nil {
+				return fmt.Errorf("could not read %s - %s", name, err.Error())
+			}
+			path[len(path)-1] = fileName
+			raw = contents
+		}
+
+		t, ok := settingType(path)
+		if !ok {
+			return fmt.Errorf("could not parse settings - %s does not match a setting", name)
+		}
+
+		value, err := convert(raw, t)
+		if err != nil {
+			return fmt.Errorf("could not parse settings - %s %s", name, err.Error())
+		}
+
+		if err := setPath(values, path, value); err != nil {
+			return fmt.Errorf("could not parse settings - %s %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func convert(raw string, t reflect.Type) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return value, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return value, nil
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.String {
+			break
+		}
+		items := []interface{}{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("cannot be set from the environment")
+}
+
+func setPath(values map[string]interface{}, path []string, value interface{}) error {
+	current := values
+	for _, name := range path[:len(path)-1] {
+		next, exists := current[name]
+		if !exists || next == nil {
+			nested := map[string]interface{}{}
+			current[name] = nested
+			current = nested
+			continue
+		}
+
+		nested, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("conflicts with the value of %s", name)
+		}
+		current = nested
+	}
+
+	current[path[len(path)-1]] = value
+	return nil
+}
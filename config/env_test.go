@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+var testKey = base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+// A development configuration that validates
+var testYAML = `
+oauth_settings:
+  client_id: id
+  client_secret: yaml
+  authorize_url: https://github.com/login/oauth/authorize
+  access_token_url: https://github.com/login/oauth/access_token
+  redirect_uri: http://localhost:8100/callback
+security:
+  active_key_id: k1
+  keys:
+    k1: ` + testKey + `
+`
+
+// secretFile writes contents to a file in a temporary directory and returns its path
+func secretFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		got     func(s *axon_types.Settings) interface{}
+		want    interface{}
+	}{
+		{"string", []string{"AXON_OAUTH_SETTINGS__CLIENT_SECRET=env"},
+			func(s *axon_types.Settings) interface{} { return s.OauthSettings.ClientSecret }, "env"},
+		{"mixed case", []string{"AXON_OAUTH_SETTINGS__Client_Secret=env"},
+			func(s *axon_types.Settings) interface{} { return s.OauthSettings.ClientSecret }, "env"},
+		{"value with =", []string{"AXON_OAUTH_SETTINGS__STATE=a=b"},
+			func(s *axon_types.Settings) interface{} { return s.OauthSettings.State }, "a=b"},
+		{"list", []string{"AXON_HTTP__ALLOWED_ORIGINS=https://a.example.com, ,https://b.example.com"},
+			func(s *axon_types.Settings) interface{} { return s.HttpSettings.AllowedOrigins }, []string{"https://a.example.com", "https://b.example.com"}},
+		{"bool", []string{"AXON_HTTP__ALLOW_CREDENTIALS=true"},
+			func(s *axon_types.Settings) interface{} { return s.HttpSettings.AllowCredentials }, true},
+		{"int", []string{"AXON_HTTP__MAX_AGE=600"},
+			func(s *axon_types.Settings) interface{} { return s.HttpSettings.MaxAge }, 600},
+		{"section not in the YAML", []string{"AXON_CORE_SETTINGS__GITHUB_ARCHIVE_REPO=axon/archive"},
+			func(s *axon_types.Settings) interface{} { return s.CoreSettings.GithubArchiveRepo }, "axon/archive"},
+		{"map key", []string{"AXON_SECURITY__KEYS__K2=" + testKey},
+			func(s *axon_types.Settings) interface{} { return len(s.SecuritySettings.Keys) }, 2},
+		{"without separator", []string{"AXON_ENV=production", "OTHER__VALUE=1"},
+			func(s *axon_types.Settings) interface{} { return s.Metadata.Environment }, axon_types.DEVELOPMENT},
+	}
+
+	for _, test := range tests {
+		settings, err := Parse([]byte(testYAML), test.environ)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := test.got(settings); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: value = %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	secret := secretFile(t, "  from file\n")
+	missing := filepath.Join(t.TempDir(), "missing")
+	yamlFile := strings.Replace(testYAML, "client_secret: yaml", "client_secret_file: "+secret, 1)
+
+	tests := []struct {
+		name    string
+		yaml    string
+		environ []string
+		want    string
+		problem string
+	}{
+		{"environment", testYAML, []string{"AXON_OAUTH_SETTINGS__CLIENT_SECRET_FILE=" + secret}, "from file", ""},
+		{"upper case suffix", testYAML, []string{"AXON_OAUTH_SETTINGS__CLIENT_SECRET_File=" + secret}, "from file", ""},
+		{"YAML", yamlFile, nil, "from file", ""},
+		{"environment over YAML file", yamlFile, []string{"AXON_OAUTH_SETTINGS__CLIENT_SECRET=env"}, "env", ""},
+		{"value and file in YAML", strings.Replace(testYAML, "client_secret: yaml", "client_secret: yaml\n  client_secret_file: "+secret, 1), nil, "",
+			"both oauth_settings.client_secret and oauth_settings.client_secret_file are set"},
+		{"missing file", testYAML, []string{"AXON_OAUTH_SETTINGS__CLIENT_SECRET_FILE=" + missing}, "",
+			"could not read AXON_OAUTH_SETTINGS__CLIENT_SECRET_FILE"},
+		{"missing YAML file", strings.Replace(testYAML, "client_secret: yaml", "client_secret_file: "+missing, 1), nil, "",
+			"could not read oauth_settings.client_secret_file"},
+		{"not a string setting", testYAML, []string{"AXON_HTTP__MAX_AGE_FILE=" + secret}, "",
+			"AXON_HTTP__MAX_AGE_FILE does not match a setting"},
+	}
+
+	for _, test := range tests {
+		settings, err := Parse([]byte(test.yaml), test.environ)
+		if test.problem != "" {
+			if err == nil || !strings.Contains(err.Error(), test.problem) {
+				t.Errorf("%s: error = %v, want %q", test.name, err, test.problem)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if settings.OauthSettings.ClientSecret != test.want {
+			t.Errorf("%s: client_secret = %q, want %q", test.name, settings.OauthSettings.ClientSecret, test.want)
+		}
+	}
+}
+
+func TestParseEnvironmentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		environ []string
+		problem string
+	}{
+		{"unknown setting", testYAML, []string{"AXON_OAUTH_SETTINGS__NOPE=1"}, "AXON_OAUTH_SETTINGS__NOPE does not match a setting"},
+		{"below a string", testYAML, []string{"AXON_OAUTH_SETTINGS__CLIENT_ID__X=1"}, "AXON_OAUTH_SETTINGS__CLIENT_ID__X does not match a setting"},
+		{"bool", testYAML, []string{"AXON_HTTP__ALLOW_CREDENTIALS=yes please"}, "AXON_HTTP__ALLOW_CREDENTIALS must be true or false"},
+		{"int", testYAML, []string{"AXON_HTTP__MAX_AGE=soon"}, "AXON_HTTP__MAX_AGE must be an integer"},
+		{"struct", testYAML, []string{"AXON_OAUTH_SETTINGS__=1"}, "does not match a setting"},
+		{"YAML scalar", testYAML + "http: none\n", []string{"AXON_HTTP__MAX_AGE=1"}, "AXON_HTTP__MAX_AGE conflicts with the value of http"},
+		// Sorted, so the first failing variable is reported whatever the process order
+		{"first in order", testYAML, []string{"AXON_OAUTH_SETTINGS__ZZZ=1", "AXON_HTTP__MAX_AGE=soon"}, "AXON_HTTP__MAX_AGE must be an integer"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse([]byte(test.yaml), test.environ)
+		if err == nil || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("%s: error = %v, want %q", test.name, err, test.problem)
+		}
+	}
+}
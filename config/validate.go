@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	axon_cors "github.com/stephensanwo/axon-lib/cors"
+	axon_keyring "github.com/stephensanwo/axon-lib/keyring"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// ValidationError lists every problem found in the settings, so a deployment can be
+// fixed in one pass
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid settings:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Validate checks the settings required in every environment, plus the stricter
+// production rules: https URLs for OAuth and the client, and the client redirects.
+func Validate(settings *axon_types.Settings) error {
+	problems := []string{}
+	require := func(name string, value string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+
+	environment := settings.Metadata.Environment
+	if environment != axon_types.DEVELOPMENT && environment != axon_types.PRODUCTION {
+		problems = append(problems, fmt.Sprintf("metadata.environment must be %s or %s", axon_types.DEVELOPMENT, axon_types.PRODUCTION))
+	}
+
+	if settings.Metadata.LogLevel != "" {
+		if _, err := log.ParseLevel(settings.Metadata.LogLevel); err != nil {
+			problems = append(problems, "metadata.log_level: "+err.Error())
+		}
+	}
+
+	oauth := settings.OauthSettings
+	require("oauth_settings.client_id", oauth.ClientID)
+	require("oauth_settings.client_secret", oauth.ClientSecret)
+	require("oauth_settings.authorize_url", oauth.AuthorizeUrl)
+	require("oauth_settings.access_token_url", oauth.AccessTokenUrl)
+	require("oauth_settings.redirect_uri", oauth.RedirectUri)
+
+	// Sessions are always sealed, so the key ring is needed in every environment
+	if _, err := axon_keyring.New(settings.SecuritySettings); err != nil {
+		problems = append(problems, "security: "+err.Error())
+	}
+
+	if _, err := axon_cors.New(settings.HttpSettings); err != nil {
+		problems = append(problems, "http: "+err.Error())
+	}
+
+	if environment == axon_types.PRODUCTION {
+		require("axon_client.auth_redirect_url", settings.AxonClient.AuthRedirectUrl)
+		require("axon_client.error_url", settings.AxonClient.ErrorUrl)
+
+		urls := map[string]string{
+			"oauth_settings.authorize_url":    oauth.AuthorizeUrl,
+			"oauth_settings.access_token_url": oauth.AccessTokenUrl,
+			"oauth_settings.redirect_uri":     oauth.RedirectUri,
+			"axon_client.auth_redirect_url":   settings.AxonClient.AuthRedirectUrl,
+			"axon_client.error_url":           settings.AxonClient.ErrorUrl,
+		}
+		for _, name := range sortedKeys(urls) {
+			if urls[name] != "" && !isHttps(urls[name]) {
+				problems = append(problems, name+" must be an https URL in production")
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: problems}
+}
+
+func isHttps(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
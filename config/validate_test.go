@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+func validSettings() *axon_types.Settings {
+	settings := &axon_types.Settings{}
+	settings.Metadata.Environment = axon_types.PRODUCTION
+	settings.OauthSettings = axon_types.OauthSettings{
+		ClientID:       "id",
+		ClientSecret:   "secret",
+		AuthorizeUrl:   "https://github.com/login/oauth/authorize",
+		AccessTokenUrl: "https://github.com/login/oauth/access_token",
+		RedirectUri:    "https://api.example.com/callback",
+	}
+	settings.SecuritySettings = axon_types.SecuritySettings{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey}}
+	settings.AxonClient.AuthRedirectUrl = "https://app.example.com/"
+	settings.AxonClient.ErrorUrl = "https://app.example.com/error"
+	return settings
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(s *axon_types.Settings)
+		problems []string
+	}{
+		{"valid", func(s *axon_types.Settings) {}, nil},
+		{"development allows http", func(s *axon_types.Settings) {
+			s.Metadata.Environment = axon_types.DEVELOPMENT
+			s.OauthSettings.RedirectUri = "http://localhost:8100/callback"
+			s.AxonClient.ErrorUrl = ""
+		}, nil},
+		{"every problem", func(s *axon_types.Settings) {
+			s.Metadata.Environment = "staging"
+			s.Metadata.LogLevel = "loud"
+			s.OauthSettings = axon_types.OauthSettings{ClientID: " "}
+			s.SecuritySettings = axon_types.SecuritySettings{}
+			s.HttpSettings.MaxAge = -1
+		}, []string{
+			"metadata.environment must be development or production",
+			`metadata.log_level: not a valid logrus Level: "loud"`,
+			"oauth_settings.client_id is required",
+			"oauth_settings.client_secret is required",
+			"oauth_settings.authorize_url is required",
+			"oauth_settings.access_token_url is required",
+			"oauth_settings.redirect_uri is required",
+			"security: key ring is not configured - active_key_id is empty",
+			"http: cors: max_age cannot be negative",
+		}},
+		{"production", func(s *axon_types.Settings) {
+			s.OauthSettings.RedirectUri = "http://api.example.com/callback"
+			s.OauthSettings.AuthorizeUrl = "https://"
+			s.AxonClient.AuthRedirectUrl = ""
+			s.AxonClient.ErrorUrl = "app.example.com/error"
+		}, []string{
+			"axon_client.auth_redirect_url is required",
+			"axon_client.error_url must be an https URL in production",
+			"oauth_settings.authorize_url must be an https URL in production",
+			"oauth_settings.redirect_uri must be an https URL in production",
+		}},
+		{"key ring", func(s *axon_types.Settings) {
+			s.SecuritySettings.Keys["k2"] = "short"
+		}, []string{
+			"security: key ring key k2 is not valid base64 - illegal base64 data at input byte 4",
+		}},
+	}
+
+	for _, test := range tests {
+		settings := validSettings()
+		test.change(settings)
+
+		err := Validate(settings)
+		if test.problems == nil {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+
+		var validation *ValidationError
+		if !errors.As(err, &validation) {
+			t.Errorf("%s: error = %v, want a ValidationError", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(validation.Problems, test.problems) {
+			t.Errorf("%s: problems = %q\nwant %q", test.name, validation.Problems, test.problems)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Subscriber is called after a reload changed the settings under its path. Both
+// snapshots are read only. It may subscribe and read the settings, but not reload.
+type Subscriber func(previous *axon_types.Settings, current *axon_types.Settings)
+
+// Watcher keeps the current settings loaded from a file. A reload that fails to parse
+// or validate is rejected, and the previous settings stay in use.
+type Watcher struct {
+	path    string
+	environ func() []string
+
+	snapshot atomic.Pointer[snapshot]
+
+	// Serialises reloads and guards the fields below
+	mu          sync.Mutex
+	modTime     time.Time
+	size        int64
+	subscribers []subscription
+	// Held while subscribers are called, so they see changes in order
+	notifying sync.Mutex
+
+	// Optional, called with every rejected reload. Errors are logged otherwise.
+	OnError func(error)
+}
+
+type snapshot struct {
+	settings    *axon_types.Settings
+	axonContext *axon_types.AxonContext
+}
+
+type subscription struct {
+	path []string
+	fn   Subscriber
+}
+
+// change is a reload to tell subscribers about, with the subscribers at the time
+type change struct {
+	previous    *axon_types.Settings
+	current     *axon_types.Settings
+	subscribers []subscription
+}
+
+// NewWatcher loads the settings file, failing if the initial settings are invalid.
+// The log level is applied on load and on every change of metadata.log_level.
+func NewWatcher(ctx context.Context, path string) (*Watcher, error) {
+	w := &Watcher{path: path, environ: os.Environ}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.New("could not read settings - " + err.Error())
+	}
+
+	settings, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w.modTime, w.size = info.ModTime(), info.Size()
+	w.store(ctx, settings)
+	applyLogLevel(settings)
+
+	w.Subscribe("metadata.log_level", func(previous *axon_types.Settings, current *axon_types.Settings) {
+		applyLogLevel(current)
+	})
+
+	return w, nil
+}
+
+// Settings returns the current snapshot. Callers should read it once per request
+// rather than holding on to it.
+func (w *Watcher) Settings() *axon_types.Settings {
+	return w.snapshot.Load().settings
+}
+
+// AxonContext returns a context built from the current snapshot, for use as the base
+// context of each request
+func (w *Watcher) AxonContext() *axon_types.AxonContext {
+	return w.snapshot.Load().axonContext
+}
+
+// Subscribe registers fn for changes under a dotted settings path such as "http" or
+// "metadata.log_level". An empty path subscribes to every change.
+func (w *Watcher) Subscribe(path string, fn Subscriber) error {
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, ".")
+		if _, ok := settingType(parts); !ok {
+			return fmt.Errorf("could not subscribe - %s is not a setting", path)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, subscription{path: parts, fn: fn})
+	return nil
+}
+
+// Reload loads and validates the file, then swaps it in and notifies subscribers of
+// the paths that changed
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		w.mu.Unlock()
+		return errors.New("could not reload settings - " + err.Error())
+	}
+
+	c, err := w.reload(info)
+	w.unlockAndNotify(c)
+	return err
+}
+
+// reload swaps in the file, holding mu, and returns the change to notify
+func (w *Watcher) reload(info os.FileInfo) (*change, error) {
+	// Record the file version even when it is rejected, so it is not retried until
+	// it changes again
+	w.modTime, w.size = info.ModTime(), info.Size()
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, errors.New("could not reload settings - " + err.Error())
+	}
+
+	settings, err := Parse(data, w.environ())
+	if err != nil {
+		return nil, fmt.Errorf("rejected settings reload - %w", err)
+	}
+
+	previous := w.Settings()
+	w.store(w.AxonContext().Context, settings)
+
+	subscribers := append([]subscription{}, w.subscribers...)
+	return &change{previous: previous, current: settings, subscribers: subscribers}, nil
+}
+
+// unlockAndNotify releases mu, then calls the subscribers whose settings changed. The
+// next reload can start while they run, but its subscribers are called after these.
+func (w *Watcher) unlockAndNotify(c *change) {
+	if c == nil {
+		w.mu.Unlock()
+		return
+	}
+
+	w.notifying.Lock()
+	defer w.notifying.Unlock()
+	w.mu.Unlock()
+
+	for _, subscriber := range c.subscribers {
+		if !reflect.DeepEqual(valueAt(c.previous, subscriber.path), valueAt(c.current, subscriber.path)) {
+			subscriber.fn(c.previous, c.current)
+		}
+	}
+}
+
+// Watch polls the file for changes until ctx is done
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.reloadIfChanged()
+		}
+	}
+}
+
+// WatchSignal reloads on SIGHUP until ctx is done
+func (w *Watcher) WatchSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			w.report(w.Reload())
+		}
+	}
+}
+
+func (w *Watcher) reloadIfChanged() {
+	w.mu.Lock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		w.mu.Unlock()
+		w.report(errors.New("could not reload settings - " + err.Error()))
+		return
+	}
+
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		w.mu.Unlock()
+		return
+	}
+
+	c, err := w.reload(info)
+	w.unlockAndNotify(c)
+	w.report(err)
+}
+
+func (w *Watcher) report(err error) {
+	if err == nil {
+		return
+	}
+
+	if w.OnError != nil {
+		w.OnError(err)
+		return
+	}
+	log.Errorln(err.Error())
+}
+
+func (w *Watcher) store(ctx context.Context, settings *axon_types.Settings) {
+	w.snapshot.Store(&snapshot{
+		settings:    settings,
+		axonContext: NewAxonContext(ctx, settings),
+	})
+}
+
+// valueAt follows yaml tags from Settings down a settings path
+func valueAt(settings *axon_types.Settings, path []string) interface{} {
+	v := reflect.ValueOf(*settings)
+	for _, name := range path {
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByYamlName(v.Type(), name)
+			if !ok {
+				return nil
+			}
+			v = v.FieldByIndex(field.Index)
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(name))
+			if !v.IsValid() {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+func applyLogLevel(settings *axon_types.Settings) {
+	if settings.Metadata.LogLevel == "" {
+		return
+	}
+
+	// Validate has already checked the level
+	if level, err := log.ParseLevel(settings.Metadata.LogLevel); err == nil {
+		log.SetLevel(level)
+	}
+}
@@ -0,0 +1,428 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/google/uuid"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// The receipt is saved after each step, and after this many records within a step
+const receiptSaveInterval = 100
+
+// Steps of an account deletion, in the order they run. The user record goes last, so
+// an interrupted deletion can be resumed by signing in again.
+const (
+	DeletionStepNotes    = "notes"
+	DeletionStepSearch   = "search"
+	DeletionStepTags     = "tags"
+	DeletionStepShares   = "shares"
+	DeletionStepLinks    = "links"
+	DeletionStepTokens   = "tokens"
+	DeletionStepSync     = "sync"
+	DeletionStepSessions = "sessions"
+	DeletionStepProfile  = "profile"
+)
+
+// deletionSteps pairs each step with the function that removes its records. Every
+// function can run again after an interruption, removing what is left.
+var deletionSteps = []struct {
+	name   string
+	remove func(d *deletion) error
+}{
+	{DeletionStepNotes, (*deletion).removeNotes},
+	{DeletionStepSearch, (*deletion).removeSearch},
+	{DeletionStepTags, (*deletion).removeTags},
+	{DeletionStepShares, (*deletion).removeShares},
+	{DeletionStepLinks, (*deletion).removeLinks},
+	{DeletionStepTokens, (*deletion).removeTokens},
+	{DeletionStepSync, (*deletion).removeSync},
+	{DeletionStepSessions, (*deletion).removeSessions},
+	{DeletionStepProfile, (*deletion).removeProfile},
+}
+
+// DeleteAccount removes every record of the session user: folders, notes, nodes and
+// edges, the search index, tags, shares in both directions, public links, access
+// tokens, sync states, all sessions and the user record. A deletion that was
+// interrupted is resumed from the step it stopped in. The receipt is kept, and
+// returned once the account is gone.
+func (u *User) DeleteAccount(a *axon_types.AxonContext, session axon_types.Session) (*axon_types.DeletionReceipt, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not delete account - " + err.Error())
+	}
+
+	user := session.SessionData.User
+	if user.Email == "" {
+		return nil, fmt.Errorf("could not delete account - %w", ErrForbidden)
+	}
+
+	// Tokens with the write scope may change notes, not close the account
+	if !HasScope(session, axon_types.TokenScopeAdmin) {
+		return nil, fmt.Errorf("could not delete account - an admin token is required - %w", ErrForbidden)
+	}
+
+	emailHash := hashSecret(strings.ToLower(user.Email))
+
+	receipt, err := resumableDeletion(db, emailHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		receipt = &axon_types.DeletionReceipt{
+			DeletionId: uuid.New().String(),
+			UserId:     user.UserId,
+			EmailHash:  emailHash,
+			Status:     axon_types.DeletionInProgress,
+			StartedAt:  time.Now(),
+			Steps:      []string{},
+			Removed:    map[string]int{},
+		}
+	}
+	receipt.Runs++
+
+	d := &deletion{db: db, email: user.Email, session: session, receipt: receipt}
+	if err := d.save(); err != nil {
+		return nil, err
+	}
+
+	done := map[string]bool{}
+	for _, step := range receipt.Steps {
+		done[step] = true
+	}
+
+	for _, step := range deletionSteps {
+		if done[step.name] {
+			continue
+		}
+		if err := step.remove(d); err != nil {
+			// Keep the count of what was removed before the error
+			d.save()
+			return nil, fmt.Errorf("could not delete account in step %s - %w", step.name, err)
+		}
+		receipt.Steps = append(receipt.Steps, step.name)
+		if err := d.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	completed := time.Now()
+	receipt.Status = axon_types.DeletionCompleted
+	receipt.CompletedAt = &completed
+	if err := d.save(); err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// resumableDeletion returns the receipt of a deletion of the account that did not
+// finish, or nil
+func resumableDeletion(db *axon_coredb.DB, email_hash string) (*axon_types.DeletionReceipt, error) {
+	var found *axon_types.DeletionReceipt
+
+	err := eachRecord(db, axon_types.AXON_TABLE, deletionPartition(email_hash), func(item map[string]*dynamodb.AttributeValue) error {
+		var receipt axon_types.DeletionReceipt
+		if err := dynamodbattribute.UnmarshalMap(item, &receipt); err != nil {
+			return err
+		}
+		if receipt.Status == axon_types.DeletionInProgress && (found == nil || receipt.StartedAt.After(found.StartedAt)) {
+			found = &receipt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("could not delete account - " + err.Error())
+	}
+
+	if found != nil && found.Removed == nil {
+		found.Removed = map[string]int{}
+	}
+	return found, nil
+}
+
+func deletionPartition(email_hash string) string {
+	return fmt.Sprintf("DELETION#%s", email_hash)
+}
+
+// eachRecord calls fn with every record of a partition, reading it a page at a time.
+// Records can be deleted by fn, the next page starts after the last record read.
+func eachRecord(db *axon_coredb.DB, table_name string, partition_key string, fn func(item map[string]*dynamodb.AttributeValue) error) error {
+	var start map[string]*dynamodb.AttributeValue
+	for {
+		result, err := db.QueryDatabasePartitionPage(table_name, partition_key, start)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range result.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		start = result.LastEvaluatedKey
+	}
+}
+
+// partitionItems reads every record of a partition from the table itself. Nodes and
+// edges have no date_created, so they are not in the date_createdIndex that
+// QueryDatabasePartition reads.
+func partitionItems(db *axon_coredb.DB, table_name string, partition_key string) ([]map[string]*dynamodb.AttributeValue, error) {
+	items := []map[string]*dynamodb.AttributeValue{}
+	err := eachRecord(db, table_name, partition_key, func(item map[string]*dynamodb.AttributeValue) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// deletion removes the records of one account, counting them in the receipt
+type deletion struct {
+	db      *axon_coredb.DB
+	email   string
+	session axon_types.Session
+	receipt *axon_types.DeletionReceipt
+	// Records removed since the receipt was last saved
+	unsaved int
+}
+
+func (d *deletion) save() error {
+	d.unsaved = 0
+	err := d.db.MutateDatabase(axon_types.AXON_TABLE, deletionPartition(d.receipt.EmailHash), d.receipt.DeletionId, d.receipt)
+	if err != nil {
+		return errors.New("could not save deletion receipt - " + err.Error())
+	}
+	return nil
+}
+
+// remove deletes a record and counts it as kind
+func (d *deletion) remove(table_name string, partition_key string, sort_key string, kind string) error {
+	if err := d.db.DeleteRecord(table_name, partition_key, &sort_key); err != nil {
+		return err
+	}
+
+	d.receipt.Removed[kind]++
+	d.unsaved++
+	if d.unsaved >= receiptSaveInterval {
+		return d.save()
+	}
+	return nil
+}
+
+// removePartition deletes every record of a partition, counting them as kind
+func (d *deletion) removePartition(table_name string, partition_key string, kind string) error {
+	return eachRecord(d.db, table_name, partition_key, func(item map[string]*dynamodb.AttributeValue) error {
+		return d.remove(table_name, partition_key, sortKey(item), kind)
+	})
+}
+
+func sortKey(item map[string]*dynamodb.AttributeValue) string {
+	if value, ok := item["sort_key"]; ok && value.S != nil {
+		return *value.S
+	}
+	return ""
+}
+
+// removeNotes deletes each folder after its notes, and each note after its nodes and
+// edges, so a folder left by an interruption is found again. Then it deletes the
+// notes, nodes and edges no folder or note leads to.
+func (d *deletion) removeNotes() error {
+	folderPartition := fmt.Sprintf("FOLDER#%s", d.email)
+
+	err := eachRecord(d.db, axon_types.AXON_TABLE, folderPartition, func(item map[string]*dynamodb.AttributeValue) error {
+		folder_id := sortKey(item)
+		notePartition := fmt.Sprintf("NOTE#%s#%s", d.email, folder_id)
+
+		err := eachRecord(d.db, axon_types.AXON_TABLE, notePartition, func(item map[string]*dynamodb.AttributeValue) error {
+			note_id := sortKey(item)
+
+			if err := d.removePartition(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", d.email, folder_id, note_id), "nodes"); err != nil {
+				return err
+			}
+			if err := d.removePartition(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", d.email, folder_id, note_id), "edges"); err != nil {
+				return err
+			}
+			return d.remove(axon_types.AXON_TABLE, notePartition, note_id, "notes")
+		})
+		if err != nil {
+			return err
+		}
+
+		return d.remove(axon_types.AXON_TABLE, folderPartition, folder_id, "folders")
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.removeLeftovers()
+}
+
+// removeLeftovers deletes the notes, nodes and edges left behind when their folder or
+// note was deleted, before deletions took them along. Nothing points to them, so only
+// a scan of the table finds them.
+func (d *deletion) removeLeftovers() error {
+	kinds := map[string]string{"NOTE": "notes", "NODE": "nodes", "EDGE": "edges"}
+	prefixes := []string{}
+	for prefix := range kinds {
+		prefixes = append(prefixes, fmt.Sprintf("%s#%s#", prefix, d.email))
+	}
+
+	var start map[string]*dynamodb.AttributeValue
+	for {
+		result, err := d.db.ScanDatabasePrefixPage(axon_types.AXON_TABLE, prefixes, start)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range result.Items {
+			partition := ""
+			if value, ok := item["partition_key"]; ok && value.S != nil {
+				partition = *value.S
+			}
+			kind, _, _ := strings.Cut(partition, "#")
+			if err := d.remove(axon_types.AXON_TABLE, partition, sortKey(item), kinds[kind]); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		start = result.LastEvaluatedKey
+	}
+}
+
+func (d *deletion) removeSearch() error {
+	if err := d.removePartition(axon_types.AXON_TABLE, searchPartition(d.email), "search_documents"); err != nil {
+		return err
+	}
+	return d.removePartition(axon_types.AXON_TABLE, searchTermPartition(d.email), "search_postings")
+}
+
+// removeTags deletes the index of each tag before its catalogue entry, so a tag left
+// by an interruption is found again
+func (d *deletion) removeTags() error {
+	catalogue := tagPartition(d.email)
+	return eachRecord(d.db, axon_types.AXON_TABLE, catalogue, func(item map[string]*dynamodb.AttributeValue) error {
+		name := sortKey(item)
+		if err := d.removePartition(axon_types.AXON_TABLE, taggedPartition(d.email, name), "tagged_items"); err != nil {
+			return err
+		}
+		return d.remove(axon_types.AXON_TABLE, catalogue, name, "tags")
+	})
+}
+
+// removeShares deletes the shares the user made with the recipients' copies, the
+// shares made with the user with the owners' copies, and the share audit
+func (d *deletion) removeShares() error {
+	sharePartition := fmt.Sprintf("SHARE#%s", d.email)
+	err := eachRecord(d.db, axon_types.AXON_TABLE, sharePartition, func(item map[string]*dynamodb.AttributeValue) error {
+		var share axon_types.Share
+		if err := dynamodbattribute.UnmarshalMap(item, &share); err != nil {
+			return err
+		}
+
+		resourceKey := shareResourceKey(share.OwnerEmail, share.FolderID, share.NoteID)
+		if err := d.db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("SHARED_WITH#%s", share.RecipientEmail), &resourceKey); err != nil {
+			return err
+		}
+		return d.remove(axon_types.AXON_TABLE, sharePartition, sortKey(item), "shares")
+	})
+	if err != nil {
+		return err
+	}
+
+	sharedWithPartition := fmt.Sprintf("SHARED_WITH#%s", d.email)
+	err = eachRecord(d.db, axon_types.AXON_TABLE, sharedWithPartition, func(item map[string]*dynamodb.AttributeValue) error {
+		var share axon_types.Share
+		if err := dynamodbattribute.UnmarshalMap(item, &share); err != nil {
+			return err
+		}
+
+		if err := d.db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("SHARE#%s", share.OwnerEmail), &share.ShareId); err != nil {
+			return err
+		}
+		return d.remove(axon_types.AXON_TABLE, sharedWithPartition, sortKey(item), "shares_received")
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.removePartition(axon_types.AXON_TABLE, fmt.Sprintf("SHARE_AUDIT#%s", d.email), "share_audits")
+}
+
+func (d *deletion) removeLinks() error {
+	linkPartition := fmt.Sprintf("LINK#%s", d.email)
+	return eachRecord(d.db, axon_types.AXON_TABLE, linkPartition, func(item map[string]*dynamodb.AttributeValue) error {
+		var link axon_types.PublicLink
+		if err := dynamodbattribute.UnmarshalMap(item, &link); err != nil {
+			return err
+		}
+
+		if err := d.db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("LINKTOKEN#%s", link.TokenHash), &link.TokenHash); err != nil {
+			return err
+		}
+		return d.remove(axon_types.AXON_TABLE, linkPartition, sortKey(item), "links")
+	})
+}
+
+func (d *deletion) removeTokens() error {
+	tokenPartition := fmt.Sprintf("TOKEN#%s", d.email)
+	return eachRecord(d.db, axon_types.AXON_TABLE, tokenPartition, func(item map[string]*dynamodb.AttributeValue) error {
+		var token axon_types.PersonalAccessToken
+		if err := dynamodbattribute.UnmarshalMap(item, &token); err != nil {
+			return err
+		}
+
+		if err := d.db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("TOKENHASH#%s", token.TokenHash), &token.TokenHash); err != nil {
+			return err
+		}
+		return d.remove(axon_types.AXON_TABLE, tokenPartition, sortKey(item), "tokens")
+	})
+}
+
+func (d *deletion) removeSync() error {
+	return d.removePartition(axon_types.AXON_TABLE, fmt.Sprintf("SYNC#%s", d.email), "sync_states")
+}
+
+// removeSessions revokes every session listed under the user, and the session making
+// the request, which predates the index when it was created before it existed
+func (d *deletion) removeSessions() error {
+	indexPartition := fmt.Sprintf("USER_SESSION#%s", d.email)
+	err := eachRecord(d.db, axon_types.AXON_USER_SESSION_TABLE, indexPartition, func(item map[string]*dynamodb.AttributeValue) error {
+		session_id := sortKey(item)
+		if err := d.remove(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", session_id), session_id, "sessions"); err != nil {
+			return err
+		}
+		return d.db.DeleteRecord(axon_types.AXON_USER_SESSION_TABLE, indexPartition, &session_id)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Personal access token sessions are not stored, they ended with their tokens
+	session_id := d.session.SessionId
+	if session_id == "" || strings.HasPrefix(session_id, "TOKEN#") {
+		return nil
+	}
+	return d.db.DeleteRecord(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", session_id), &session_id)
+}
+
+func (d *deletion) removeProfile() error {
+	return d.remove(axon_types.AXON_TABLE, fmt.Sprintf("USER#%s", d.email), d.email, "users")
+}
@@ -15,6 +15,8 @@ import (
 
 type Edge struct {
 	Session axon_types.Session
+	// Email of the owner when acting on a note shared with the session user
+	OwnerEmail string
 }
 
 func (e *Edge) GetEdges(a *axon_types.AxonContext, folder_id string, note_id string) (*[]axon_types.Edge, error) {
@@ -25,16 +27,22 @@ func (e *Edge) GetEdges(a *axon_types.AxonContext, folder_id string, note_id str
 		return nil, errors.New("could not fetch edges - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, e.Session, e.OwnerEmail, folder_id, note_id, axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch edges - %w", err)
+	}
+
 	var edges []axon_types.Edge
 
-	nodeResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", e.Session.SessionData.User.Email, folder_id, note_id), nil)
+	edgeItems, err := partitionItems(db, axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id))
 
 	if err != nil {
 		return nil, errors.New("could not fetch edges - " + err.Error())
 	}
 
-	// Unmarshal the DynamoDB item into a Note | Node | Edges structs
-	if err := dynamodbattribute.UnmarshalMap(nodeResult.Item, &edges); err != nil {
+	// Unmarshal the DynamoDB items into a Node | Edges structs
+	if err := dynamodbattribute.UnmarshalListOfMaps(edgeItems, &edges); err != nil {
 		return nil, err
 	}
 	
@@ -50,15 +58,25 @@ func (e *Edge) CreateEdge(a *axon_types.AxonContext, source_id string, target_id
 		return nil, errors.New("could not create edge - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, e.Session, e.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not create edge - %w", err)
+	}
+
 	// Confirm that note exists
 	var note axon_types.Note
 
-	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", e.Session.SessionData.User.Email, folder_id), &note_id)
+	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
 
-	if noteResult.Item == nil || err != nil {
+	if err != nil {
 		return nil, errors.New("could not fetch note data - " + err.Error())
 	}
 
+	if len(noteResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch note data - note %w", ErrNotFound)
+	}
+
 	// Unmarshal the DynamoDB item into a Note struct
 	if err := dynamodbattribute.UnmarshalMap(noteResult.Item, &note); err != nil {
 		return nil, err
@@ -81,7 +99,7 @@ func (e *Edge) CreateEdge(a *axon_types.AxonContext, source_id string, target_id
 	}
 
 	// Add edge to Database
-	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", e.Session.SessionData.User.Email, folder_id, note.NoteID), edge.EdgeID, edge)
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note.NoteID), edge.EdgeID, edge)
 
 	if err != nil {
 		return nil, errors.New("could not create edge - " + err.Error())
@@ -99,8 +117,22 @@ func (e *Edge) FindEdge(a *axon_types.AxonContext, folder_id string, note_id str
 		return nil, errors.New("could not fetch edge - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, e.Session, e.OwnerEmail, folder_id, note_id, axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch edge - %w", err)
+	}
+
 	// Fetch the Edge
-	edgeResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", e.Session.SessionData.User.Email, folder_id, note_id), &edge_id)
+	edgeResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id), &edge_id)
+
+	if err != nil {
+		return nil, errors.New("could not fetch edge - " + err.Error())
+	}
+
+	if len(edgeResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch edge - edge %w", ErrNotFound)
+	}
 
 	var edge axon_types.Edge
 
@@ -120,7 +152,13 @@ func (e *Edge) DeleteEdge(a *axon_types.AxonContext, folder_id string, note_id s
 		return nil, errors.New("could not delete edge - " + err.Error())
 	}
 
-	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", e.Session.SessionData.User.Email, folder_id, note_id), &edge_id)
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, e.Session, e.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not delete edge - %w", err)
+	}
+
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id), &edge_id)
 
 	if err != nil {
 		return nil, errors.New("could not delete edge or edge does not exist - " + err.Error())
@@ -139,16 +177,22 @@ func (e *Edge) UpdateEdge(a *axon_types.AxonContext, source_id string, target_id
 		return nil, errors.New("could not fetch edge detail - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, e.Session, e.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not update edge - %w", err)
+	}
+
 	// Create a map to store the updated attributes
 	updatedAttributes := make(map[string]*dynamodb.AttributeValue)
 
 	// Update the sorce and target fields if provided
 	if source_id != "" {
-		updatedAttributes["source_id"] = &dynamodb.AttributeValue{S: &source_id}
+		updatedAttributes["source"] = &dynamodb.AttributeValue{S: &source_id}
 	}
 
 	if target_id != "" {
-		updatedAttributes["target_id"] = &dynamodb.AttributeValue{S: &target_id}
+		updatedAttributes["target"] = &dynamodb.AttributeValue{S: &target_id}
 	}
 	
 	if animated {
@@ -168,7 +212,7 @@ func (e *Edge) UpdateEdge(a *axon_types.AxonContext, source_id string, target_id
 		S: jsii.String(time.Now().Format(time.RFC3339)),
 	}
 
-	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", e.Session.SessionData.User.Email, folder_id, note_id), edge_id, updatedAttributes)
+	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id), edge_id, updatedAttributes)
 
 	return &edge_id, err
 
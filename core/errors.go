@@ -0,0 +1,10 @@
+package core
+
+import "errors"
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrForbidden        = errors.New("permission denied")
+	ErrConflict         = errors.New("already exists")
+	ErrPasswordRequired = errors.New("a valid password is required")
+)
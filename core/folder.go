@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/google/uuid"
 	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
@@ -14,6 +15,8 @@ import (
 
 type Folder struct {
 	Session axon_types.Session
+	// Email of the owner when acting on a folder shared with the session user
+	OwnerEmail string
 }
 
 
@@ -65,6 +68,13 @@ func (f *Folder) GetFolderList(a *axon_types.AxonContext) (*[]axon_types.FolderL
 	}
 	wg.Wait()
 
+	// Append the folders and notes other users have shared with the session user
+	shared, err := f.getSharedFolderList(db)
+	if err != nil {
+		return nil, errors.New("could not fetch shared folders - " + err.Error())
+	}
+	res = append(res, shared...)
+
 	return &res, err
 
 }
@@ -80,15 +90,34 @@ func (f *Folder) GetFolders(a *axon_types.AxonContext) (*[]axon_types.Folder, er
 	var folder []axon_types.Folder
 
 	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", f.Session.SessionData.User.Email))
+	if err != nil {
+		return nil, errors.New("could not fetch folder - " + err.Error())
+	}
 
 	// Unmarshal the DynamoDB item into a Note struct
 	dynamodbattribute.UnmarshalListOfMaps(result.Items, &folder)
 
+	return &folder, err
+
+}
+
+// EachFolder calls fn with every folder of the session user, reading them a page at a
+// time. GetFolders reads a single page, so exports and archives use this instead.
+func (f *Folder) EachFolder(a *axon_types.AxonContext, fn func(folder axon_types.Folder) error) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
 	if err != nil {
-		return nil, errors.New("could not fetch folder - " + err.Error())
+		return errors.New("could not fetch folders - " + err.Error())
 	}
-	return &folder, err
 
+	return eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", f.Session.SessionData.User.Email), func(item map[string]*dynamodb.AttributeValue) error {
+		var folder axon_types.Folder
+		if err := dynamodbattribute.UnmarshalMap(item, &folder); err != nil {
+			return errors.New("could not fetch folders - " + err.Error())
+		}
+		return fn(folder)
+	})
 }
 
 func (f *Folder) CreateFolder(a *axon_types.AxonContext, folder_name string) (*string, error) {
@@ -98,6 +127,13 @@ func (f *Folder) CreateFolder(a *axon_types.AxonContext, folder_name string) (*s
 	if err != nil {
 		return nil, errors.New("could not create folder - " + err.Error())
 	}
+
+	// Folders can only be created in the session user's own account
+	email, err := authorize(db, f.Session, f.OwnerEmail, "", "", axon_types.PermissionOwner)
+	if err != nil {
+		return nil, fmt.Errorf("could not create folder - %w", err)
+	}
+
 	
 	//  Create folder object
 	folder := axon_types.Folder{
@@ -109,12 +145,16 @@ func (f *Folder) CreateFolder(a *axon_types.AxonContext, folder_name string) (*s
 	}
 
 	// Add folder to database
-	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", f.Session.SessionData.User.Email), folder.FolderID, folder)
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), folder.FolderID, folder)
 
 	if err != nil {
 		return nil, errors.New("could not create folder - " + err.Error())
 	}
 
+	if err := indexFolder(db, email, folder); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
+
 	return &folder.FolderID, err
 
 }
@@ -127,12 +167,22 @@ func (f *Folder) FindFolder(a *axon_types.AxonContext, folder_id string) (*axon_
 		return nil, errors.New("could not find folder - " + err.Error())
 	}
 
-	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", f.Session.SessionData.User.Email), &folder_id)
+	// Resolve the owner of the folder and check access
+	email, err := authorize(db, f.Session, f.OwnerEmail, folder_id, "", axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not find folder - %w", err)
+	}
+
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), &folder_id)
 
 	if err != nil {
 		return nil, errors.New("could not find folder - " + err.Error())
 	}
 
+	if len(result.Item) == 0 {
+		return nil, fmt.Errorf("could not find folder - folder %w", ErrNotFound)
+	}
+
 	var folder axon_types.Folder
 
 	// Unmarshal the DynamoDB item into a Folder struct
@@ -149,12 +199,52 @@ func (f *Folder) DeleteFolder(a *axon_types.AxonContext, folder_id string) (*str
 		return nil, errors.New("could not delete folder - " + err.Error())
 	}
 
-	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", f.Session.SessionData.User.Email), &folder_id)
+	// Resolve the owner of the folder and check access
+	email, err := authorize(db, f.Session, f.OwnerEmail, folder_id, "", axon_types.PermissionOwner)
+	if err != nil {
+		return nil, fmt.Errorf("could not delete folder - %w", err)
+	}
+
+	// Notes go first with their nodes and edges, so a folder left by a failure still
+	// leads to them
+	notePartition := fmt.Sprintf("NOTE#%s#%s", email, folder_id)
+	err = eachRecord(db, axon_types.AXON_TABLE, notePartition, func(item map[string]*dynamodb.AttributeValue) error {
+		note_id := sortKey(item)
+		if err := deleteNoteContent(db, email, folder_id, note_id); err != nil {
+			return err
+		}
+		return db.DeleteRecord(axon_types.AXON_TABLE, notePartition, &note_id)
+	})
+	if err != nil {
+		return nil, errors.New("could not delete folder notes - " + err.Error())
+	}
+
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), &folder_id)
 
 	if err != nil {
 		return nil, errors.New("could not delete folder or folder does not exist - " + err.Error())
 	}
 
+	// Remove any shares of the deleted folder and its notes
+	share := Share{Session: f.Session}
+	if err := share.revokeResourceShares(db, folder_id, ""); err != nil {
+		return nil, errors.New("could not revoke folder shares - " + err.Error())
+	}
+
+	// Public links to the notes of the folder stop working with it
+	if err := revokeResourceLinks(db, email, folder_id, ""); err != nil {
+		return nil, errors.New("could not revoke folder links - " + err.Error())
+	}
+
+	// Remove the folder and its notes and nodes from search
+	if err := unindex(db, email, searchKey(folder_id)); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
+
+	if err := untagFolder(db, email, folder_id); err != nil {
+		return nil, errors.New("could not update tags - " + err.Error())
+	}
+
 	return &folder_id, err
 
 }
@@ -171,16 +261,119 @@ func (f *Folder) UpdateFolder(a *axon_types.AxonContext, folder_name string, fol
 		return nil, errors.New("could not update folder - " + err.Error())
 	}
 
+	// Resolve the owner of the folder and check access
+	email, err := authorize(db, f.Session, f.OwnerEmail, folder_id, "", axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not update folder - %w", err)
+	}
+
 	attributes := FolderAttributes{
 		FolderName: folder_name,
 	}
 
-	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", f.Session.SessionData.User.Email), folder_id, attributes)
+	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), folder_id, attributes)
 
 	if err != nil {
 		return nil, errors.New("could not update folder or folder does not exist - " + err.Error())
 	}
 
+	if err := reindexFolder(db, email, folder_id); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
+
 	return &folder_id, err
 
 }
+
+// Groups the shares of the session user by folder. A shared folder lists all of its
+// notes, a folder holding only individually shared notes lists just those notes.
+func (f *Folder) getSharedFolderList(db *axon_coredb.DB) ([]axon_types.FolderList, error) {
+	shares, err := sharedWith(db, f.Session.SessionData.User.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	folderShares := map[string]axon_types.Share{}
+	for _, share := range shares {
+		if share.ResourceType == axon_types.ShareResourceFolder {
+			folderShares[shareResourceKey(share.OwnerEmail, share.FolderID, "")] = share
+		}
+	}
+
+	res := []axon_types.FolderList{}
+	index := map[string]int{}
+
+	for _, share := range shares {
+		key := shareResourceKey(share.OwnerEmail, share.FolderID, "")
+
+		// Notes in a shared folder are already listed with the folder
+		if _, ok := folderShares[key]; ok && share.ResourceType == axon_types.ShareResourceNote {
+			continue
+		}
+
+		i, ok := index[key]
+		if !ok {
+			folderResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", share.OwnerEmail), &share.FolderID)
+			if err != nil {
+				return nil, err
+			}
+
+			// The folder was deleted after it was shared
+			if len(folderResult.Item) == 0 {
+				continue
+			}
+
+			var folder axon_types.Folder
+			if err := dynamodbattribute.UnmarshalMap(folderResult.Item, &folder); err != nil {
+				return nil, err
+			}
+
+			res = append(res, axon_types.FolderList{
+				UserId:      folder.UserId,
+				FolderID:    folder.FolderID,
+				FolderName:  folder.FolderName,
+				DateCreated: folder.DateCreated,
+				LastEdited:  folder.LastEdited,
+				Notes:       []axon_types.Note{},
+				OwnerEmail:  share.OwnerEmail,
+			})
+			i = len(res) - 1
+			index[key] = i
+		}
+
+		if permissionRank[share.Permission] > permissionRank[res[i].Permission] {
+			res[i].Permission = share.Permission
+		}
+
+		if share.ResourceType == axon_types.ShareResourceFolder {
+			notesResult, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", share.OwnerEmail, share.FolderID))
+			if err != nil {
+				return nil, err
+			}
+
+			var notes []axon_types.Note
+			if err := dynamodbattribute.UnmarshalListOfMaps(notesResult.Items, &notes); err != nil {
+				return nil, err
+			}
+			res[i].Notes = append(res[i].Notes, notes...)
+			continue
+		}
+
+		noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", share.OwnerEmail, share.FolderID), &share.NoteID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(noteResult.Item) == 0 {
+			continue
+		}
+
+		var note axon_types.Note
+		if err := dynamodbattribute.UnmarshalMap(noteResult.Item, &note); err != nil {
+			return nil, err
+		}
+		res[i].Notes = append(res[i].Notes, note)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,326 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/google/uuid"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Import writes complete folders and notes with the IDs they already have, for
+// restoring backups and importing data from other tools. Folders and notes are
+// written to the session user's own account, AddGraph can also add to shared notes.
+type Import struct {
+	Session axon_types.Session
+	// Email of the owner when adding to a note shared with the session user
+	OwnerEmail string
+}
+
+// Puts a folder, replacing any folder with the same ID
+func (i *Import) PutFolder(a *axon_types.AxonContext, folder axon_types.Folder) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return errors.New("could not import folder - " + err.Error())
+	}
+
+	email, err := authorize(db, i.Session, "", "", "", axon_types.PermissionOwner)
+	if err != nil {
+		return fmt.Errorf("could not import folder - %w", err)
+	}
+
+	folder.UserId = i.Session.SessionData.User.UserId
+	if folder.DateCreated.IsZero() {
+		folder.DateCreated = time.Now()
+	}
+	folder.LastEdited = time.Now()
+
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), folder.FolderID, folder)
+	if err != nil {
+		return errors.New("could not import folder - " + err.Error())
+	}
+
+	if err := indexFolder(db, email, folder); err != nil {
+		return errors.New("could not update search index - " + err.Error())
+	}
+
+	return nil
+}
+
+// Puts a note with its nodes and edges. An existing note with the same ID is replaced,
+// and its nodes and edges that are not in the import are removed.
+func (i *Import) PutNote(a *axon_types.AxonContext, note axon_types.NoteDetail) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return errors.New("could not import note - " + err.Error())
+	}
+
+	email, err := authorize(db, i.Session, "", note.FolderID, "", axon_types.PermissionOwner)
+	if err != nil {
+		return fmt.Errorf("could not import note - %w", err)
+	}
+
+	folderResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), &note.FolderID)
+	if err != nil {
+		return errors.New("could not import note - " + err.Error())
+	}
+
+	if len(folderResult.Item) == 0 {
+		return fmt.Errorf("could not import note - folder %w", ErrNotFound)
+	}
+
+	userId := i.Session.SessionData.User.UserId
+	now := time.Now()
+
+	nodePartition := fmt.Sprintf("NODE#%s#%s#%s", email, note.FolderID, note.NoteID)
+	edgePartition := fmt.Sprintf("EDGE#%s#%s#%s", email, note.FolderID, note.NoteID)
+
+	// Tags of the note and its nodes before the import, to move their indexes over
+	previousTags, err := noteTags(db, email, note.FolderID, note.NoteID)
+	if err != nil {
+		return errors.New("could not import note - " + err.Error())
+	}
+
+	if err := i.removeStale(db, nodePartition, "node_id", nodeIds(note.Nodes)); err != nil {
+		return err
+	}
+	if err := i.removeStale(db, edgePartition, "edge_id", edgeIds(note.Edges)); err != nil {
+		return err
+	}
+
+	record := axon_types.Note{
+		UserId:      userId,
+		FolderID:    note.FolderID,
+		NoteID:      note.NoteID,
+		NoteName:    note.NoteName,
+		Description: note.Description,
+		Tags:        validTags(note.Tags),
+		DateCreated: note.DateCreated,
+		LastEdited:  now,
+	}
+	if record.DateCreated.IsZero() {
+		record.DateCreated = now
+	}
+
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, note.FolderID), note.NoteID, record)
+	if err != nil {
+		return errors.New("could not import note - " + err.Error())
+	}
+
+	nodes := make([]axon_types.Node, 0, len(note.Nodes))
+	for _, node := range note.Nodes {
+		node.UserId, node.FolderID, node.NoteID, node.LastEdited = userId, note.FolderID, note.NoteID, now
+		node.Tags = validTags(node.Tags)
+
+		err = db.MutateDatabase(axon_types.AXON_TABLE, nodePartition, node.NodeID, node)
+		if err != nil {
+			return errors.New("could not import node - " + err.Error())
+		}
+		nodes = append(nodes, node)
+	}
+
+	for _, edge := range note.Edges {
+		edge.UserId, edge.FolderID, edge.NoteID, edge.LastEdited = userId, note.FolderID, note.NoteID, now
+
+		err = db.MutateDatabase(axon_types.AXON_TABLE, edgePartition, edge.EdgeID, edge)
+		if err != nil {
+			return errors.New("could not import edge - " + err.Error())
+		}
+	}
+
+	// Replace the documents of the note, dropping those of removed nodes
+	if err := unindex(db, email, searchKey(note.FolderID, note.NoteID)); err != nil {
+		return errors.New("could not update search index - " + err.Error())
+	}
+	if err := indexNote(db, email, record); err != nil {
+		return errors.New("could not update search index - " + err.Error())
+	}
+	for _, node := range nodes {
+		if err := indexNode(db, email, node); err != nil {
+			return errors.New("could not update search index - " + err.Error())
+		}
+	}
+
+	if err := retagNote(db, email, previousTags, record, nodes); err != nil {
+		return errors.New("could not update tags - " + err.Error())
+	}
+
+	return nil
+}
+
+// Adds nodes and edges to an existing note. Nodes and edges without an ID are given
+// one, so callers that connect new nodes should set the node IDs themselves. Edges
+// must connect nodes of the note or of the import.
+func (i *Import) AddGraph(a *axon_types.AxonContext, folder_id string, note_id string, nodes []axon_types.Node, edges []axon_types.Edge) ([]axon_types.Node, []axon_types.Edge, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, nil, errors.New("could not import graph - " + err.Error())
+	}
+
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, i.Session, i.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not import graph - %w", err)
+	}
+
+	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
+	if err != nil {
+		return nil, nil, errors.New("could not import graph - " + err.Error())
+	}
+
+	if len(noteResult.Item) == 0 {
+		return nil, nil, fmt.Errorf("could not import graph - note %w", ErrNotFound)
+	}
+
+	// Nodes already in the note can be connected to
+	nodeItems, err := partitionItems(db, axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id))
+	if err != nil {
+		return nil, nil, errors.New("could not import graph - " + err.Error())
+	}
+
+	var existing []axon_types.Node
+	if err := dynamodbattribute.UnmarshalListOfMaps(nodeItems, &existing); err != nil {
+		return nil, nil, err
+	}
+
+	known := nodeIds(existing)
+	existingTags := map[string][]string{}
+	for _, node := range existing {
+		existingTags[node.NodeID] = node.Tags
+	}
+	userId := i.Session.SessionData.User.UserId
+	now := time.Now()
+
+	added := make([]axon_types.Node, len(nodes))
+	for j, node := range nodes {
+		if node.NodeID == "" {
+			node.NodeID = uuid.New().String()
+		}
+		node.UserId, node.FolderID, node.NoteID, node.LastEdited = userId, folder_id, note_id, now
+		node.Tags = validTags(node.Tags)
+		known[node.NodeID] = true
+		added[j] = node
+	}
+
+	connected := make([]axon_types.Edge, len(edges))
+	for j, edge := range edges {
+		if !known[edge.SourceID] || !known[edge.TargetID] {
+			return nil, nil, fmt.Errorf("could not import graph - edge %s to %s connects an unknown node", edge.SourceID, edge.TargetID)
+		}
+		if edge.EdgeID == "" {
+			edge.EdgeID = uuid.New().String()
+		}
+		edge.UserId, edge.FolderID, edge.NoteID, edge.LastEdited = userId, folder_id, note_id, now
+		connected[j] = edge
+	}
+
+	// Validate everything before writing, so a bad edge does not leave a partial import
+	for _, node := range added {
+		err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), node.NodeID, node)
+		if err != nil {
+			return nil, nil, errors.New("could not import node - " + err.Error())
+		}
+		if err := indexNode(db, email, node); err != nil {
+			return nil, nil, errors.New("could not update search index - " + err.Error())
+		}
+		item := axon_types.TaggedItem{Kind: axon_types.TaggedKindNode, FolderID: folder_id, NoteID: note_id, NodeID: node.NodeID}
+		if err := retag(db, email, item, existingTags[node.NodeID], node.Tags); err != nil {
+			return nil, nil, errors.New("could not update tags - " + err.Error())
+		}
+	}
+
+	for _, edge := range connected {
+		err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id), edge.EdgeID, edge)
+		if err != nil {
+			return nil, nil, errors.New("could not import edge - " + err.Error())
+		}
+	}
+
+	return added, connected, nil
+}
+
+// removeStale deletes the records of a partition whose id attribute is not in keep
+func (i *Import) removeStale(db *axon_coredb.DB, partition_key string, id_attribute string, keep map[string]bool) error {
+	items, err := partitionItems(db, axon_types.AXON_TABLE, partition_key)
+	if err != nil {
+		return errors.New("could not import note - " + err.Error())
+	}
+
+	var records []map[string]interface{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		id, _ := record[id_attribute].(string)
+		if id == "" || keep[id] {
+			continue
+		}
+		if err := db.DeleteRecord(axon_types.AXON_TABLE, partition_key, &id); err != nil {
+			return errors.New("could not import note - " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// RenumberNote returns a copy of a note in folder_id with new note, node and edge
+// IDs. Edges and group members are remapped to the new node IDs.
+func RenumberNote(note axon_types.NoteDetail, folder_id string) axon_types.NoteDetail {
+	copied := note
+	copied.FolderID = folder_id
+	copied.NoteID = uuid.New().String()
+
+	nodeMap := make(map[string]string, len(note.Nodes))
+	for _, node := range note.Nodes {
+		nodeMap[node.NodeID] = uuid.New().String()
+	}
+
+	copied.Nodes = make([]axon_types.Node, len(note.Nodes))
+	for j, node := range note.Nodes {
+		node.NodeID = nodeMap[node.NodeID]
+		if id, ok := nodeMap[node.ParentID]; ok {
+			node.ParentID = id
+		}
+		copied.Nodes[j] = node
+	}
+
+	copied.Edges = make([]axon_types.Edge, len(note.Edges))
+	for j, edge := range note.Edges {
+		edge.EdgeID = uuid.New().String()
+		if id, ok := nodeMap[edge.SourceID]; ok {
+			edge.SourceID = id
+		}
+		if id, ok := nodeMap[edge.TargetID]; ok {
+			edge.TargetID = id
+		}
+		copied.Edges[j] = edge
+	}
+
+	return copied
+}
+
+func nodeIds(nodes []axon_types.Node) map[string]bool {
+	ids := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		ids[node.NodeID] = true
+	}
+	return ids
+}
+
+func edgeIds(edges []axon_types.Edge) map[string]bool {
+	ids := make(map[string]bool, len(edges))
+	for _, edge := range edges {
+		ids[edge.EdgeID] = true
+	}
+	return ids
+}
@@ -0,0 +1,274 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/google/uuid"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Link manages public read-only links to the session user's notes
+type Link struct {
+	Session axon_types.Session
+}
+
+type linkLookup struct {
+	Email  string `json:"email"`
+	LinkId string `json:"link_id"`
+}
+
+// Creates a public link to a note. The plaintext link token is only returned here.
+// expires_at and password are optional.
+func (l *Link) CreateLink(a *axon_types.AxonContext, folder_id string, note_id string, expires_at *time.Time, password string) (*string, *axon_types.PublicLinkList, error) {
+
+	if expires_at != nil && expires_at.Before(time.Now()) {
+		return nil, nil, errors.New("could not create link - expiry is in the past")
+	}
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, nil, errors.New("could not create link - " + err.Error())
+	}
+
+	email := l.Session.SessionData.User.Email
+
+	// Confirm that note exists
+	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
+	if err != nil {
+		return nil, nil, errors.New("could not create link - " + err.Error())
+	}
+
+	if len(noteResult.Item) == 0 {
+		return nil, nil, fmt.Errorf("could not create link - note %w", ErrNotFound)
+	}
+
+	secret, err := newSecret(axon_types.LINK_PREFIX)
+	if err != nil {
+		return nil, nil, errors.New("could not create link - " + err.Error())
+	}
+
+	// Create link object
+	link := axon_types.PublicLink{
+		LinkId:      uuid.New().String(),
+		UserId:      l.Session.SessionData.User.UserId,
+		Email:       email,
+		FolderID:    folder_id,
+		NoteID:      note_id,
+		TokenHash:   hashSecret(secret),
+		DateCreated: time.Now(),
+		ExpiresAt:   expires_at,
+	}
+
+	if password != "" {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, errors.New("could not create link - " + err.Error())
+		}
+		link.PasswordHash = string(passwordHash)
+	}
+
+	// Add link to database, with a lookup record keyed by the hash for public reads
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("LINK#%s", email), link.LinkId, link)
+	if err != nil {
+		return nil, nil, errors.New("could not create link - " + err.Error())
+	}
+
+	lookup := linkLookup{Email: email, LinkId: link.LinkId}
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("LINKTOKEN#%s", link.TokenHash), link.TokenHash, lookup)
+	if err != nil {
+		return nil, nil, errors.New("could not create link - " + err.Error())
+	}
+
+	linkList := toLinkList(link)
+
+	return &secret, &linkList, err
+}
+
+func (l *Link) GetLinks(a *axon_types.AxonContext) (*[]axon_types.PublicLinkList, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch links - " + err.Error())
+	}
+
+	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("LINK#%s", l.Session.SessionData.User.Email))
+	if err != nil {
+		return nil, errors.New("could not fetch links - " + err.Error())
+	}
+
+	var links []axon_types.PublicLink
+
+	// Unmarshal the DynamoDB items into PublicLink structs
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &links); err != nil {
+		return nil, err
+	}
+
+	linkList := make([]axon_types.PublicLinkList, len(links))
+	for i, link := range links {
+		linkList[i] = toLinkList(link)
+	}
+
+	return &linkList, err
+}
+
+func (l *Link) RevokeLink(a *axon_types.AxonContext, link_id string) (*string, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not revoke link - " + err.Error())
+	}
+
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("LINK#%s", l.Session.SessionData.User.Email), &link_id)
+	if err != nil {
+		return nil, errors.New("could not revoke link - " + err.Error())
+	}
+
+	if len(result.Item) == 0 {
+		return nil, fmt.Errorf("could not revoke link - link %w", ErrNotFound)
+	}
+
+	var link axon_types.PublicLink
+
+	// Unmarshal the DynamoDB item into a PublicLink struct
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &link); err != nil {
+		return nil, err
+	}
+
+	// The link record is the source of truth, so remove it first
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("LINK#%s", link.Email), &link.LinkId)
+	if err != nil {
+		return nil, errors.New("could not revoke link - " + err.Error())
+	}
+
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("LINKTOKEN#%s", link.TokenHash), &link.TokenHash)
+	if err != nil {
+		return nil, errors.New("could not revoke link - " + err.Error())
+	}
+
+	return &link_id, err
+}
+
+// revokeResourceLinks removes every public link to a note that is being deleted, or to
+// the notes of a folder that is being deleted when note_id is empty. The token lookup
+// goes first, so a link left by a failure is still listed and can be revoked.
+func revokeResourceLinks(db *axon_coredb.DB, email string, folder_id string, note_id string) error {
+	linkPartition := fmt.Sprintf("LINK#%s", email)
+	return eachRecord(db, axon_types.AXON_TABLE, linkPartition, func(item map[string]*dynamodb.AttributeValue) error {
+		var link axon_types.PublicLink
+		if err := dynamodbattribute.UnmarshalMap(item, &link); err != nil {
+			return err
+		}
+
+		if link.FolderID != folder_id || (note_id != "" && link.NoteID != note_id) {
+			return nil
+		}
+
+		if err := db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("LINKTOKEN#%s", link.TokenHash), &link.TokenHash); err != nil {
+			return err
+		}
+		return db.DeleteRecord(axon_types.AXON_TABLE, linkPartition, &link.LinkId)
+	})
+}
+
+// GetPublicNote is the unauthenticated read path for public links. It returns the
+// note detail with all user identifiers removed. Unknown, revoked and expired links
+// all return ErrNotFound, a missing or wrong password returns ErrPasswordRequired.
+func GetPublicNote(a *axon_types.AxonContext, secret string, password string) (*axon_types.NoteDetail, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch note - " + err.Error())
+	}
+
+	hash := hashSecret(secret)
+
+	lookupResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("LINKTOKEN#%s", hash), &hash)
+	if err != nil {
+		return nil, errors.New("could not fetch note - " + err.Error())
+	}
+
+	if len(lookupResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch note - link %w", ErrNotFound)
+	}
+
+	var lookup linkLookup
+	if err := dynamodbattribute.UnmarshalMap(lookupResult.Item, &lookup); err != nil {
+		return nil, err
+	}
+
+	linkResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("LINK#%s", lookup.Email), &lookup.LinkId)
+	if err != nil {
+		return nil, errors.New("could not fetch note - " + err.Error())
+	}
+
+	if len(linkResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch note - link %w", ErrNotFound)
+	}
+
+	var link axon_types.PublicLink
+	if err := dynamodbattribute.UnmarshalMap(linkResult.Item, &link); err != nil {
+		return nil, err
+	}
+
+	if link.TokenHash != hash || (link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt)) {
+		return nil, fmt.Errorf("could not fetch note - link %w", ErrNotFound)
+	}
+
+	if link.PasswordHash != "" {
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			return nil, fmt.Errorf("could not fetch note - %w", ErrPasswordRequired)
+		}
+	}
+
+	// Read the note as its owner
+	note := Note{
+		Session: axon_types.Session{
+			SessionData: axon_types.UserCache{
+				User: axon_types.User{UserId: link.UserId, Email: link.Email},
+			},
+		},
+	}
+
+	noteDetail, err := note.GetNoteDetail(a, link.FolderID, link.NoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sanitizeNoteDetail(noteDetail), nil
+}
+
+// sanitizeNoteDetail removes user identifiers from a note before it is served publicly
+func sanitizeNoteDetail(noteDetail *axon_types.NoteDetail) *axon_types.NoteDetail {
+	noteDetail.UserId = ""
+
+	for i := range noteDetail.Nodes {
+		noteDetail.Nodes[i].UserId = ""
+	}
+
+	for i := range noteDetail.Edges {
+		noteDetail.Edges[i].UserId = ""
+	}
+
+	return noteDetail
+}
+
+func toLinkList(link axon_types.PublicLink) axon_types.PublicLinkList {
+	return axon_types.PublicLinkList{
+		LinkId:      link.LinkId,
+		FolderID:    link.FolderID,
+		NoteID:      link.NoteID,
+		HasPassword: link.PasswordHash != "",
+		DateCreated: link.DateCreated,
+		ExpiresAt:   link.ExpiresAt,
+	}
+}
@@ -16,6 +16,8 @@ import (
 
 type Node struct {
 	Session axon_types.Session
+	// Email of the owner when acting on a note shared with the session user
+	OwnerEmail string
 }
 
 func (no *Node) GetNodes(a *axon_types.AxonContext, folder_id string, note_id string) (*[]axon_types.Node, error) {
@@ -26,16 +28,22 @@ func (no *Node) GetNodes(a *axon_types.AxonContext, folder_id string, note_id st
 		return nil, errors.New("could not fetch nodes - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, no.Session, no.OwnerEmail, folder_id, note_id, axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch nodes - %w", err)
+	}
+
 	var nodes []axon_types.Node
 
-	nodeResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", no.Session.SessionData.User.Email, folder_id, note_id), nil)
+	nodeItems, err := partitionItems(db, axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id))
 
 	if err != nil {
 		return nil, errors.New("could not fetch nodes - " + err.Error())
 	}
 
-	// Unmarshal the DynamoDB item into a Note | Node | Edges structs
-	if err := dynamodbattribute.UnmarshalMap(nodeResult.Item, &nodes); err != nil {
+	// Unmarshal the DynamoDB items into a Node | Edges structs
+	if err := dynamodbattribute.UnmarshalListOfMaps(nodeItems, &nodes); err != nil {
 		return nil, err
 	}
 	
@@ -51,15 +59,25 @@ func (no *Node) CreateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 		return nil, errors.New("could not create node - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, no.Session, no.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not create node - %w", err)
+	}
+
 	// Confirm that note exists
 	var note axon_types.Note 
 
-	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", no.Session.SessionData.User.Email, folder_id), &note_id)
+	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
 
-	if noteResult.Item == nil || err != nil {
+	if err != nil {
 		return nil, errors.New("could not fetch note data - " + err.Error())
 	}
 
+	if len(noteResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch note data - note %w", ErrNotFound)
+	}
+
 	// Unmarshal the DynamoDB item into a Note struct
 	if err := dynamodbattribute.UnmarshalMap(noteResult.Item, &note); err != nil {
 		return nil, err
@@ -85,11 +103,15 @@ func (no *Node) CreateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 	}
 
 	// Add node to Database
-	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", no.Session.SessionData.User.Email, folder_id, note.NoteID), node.NodeID, node)
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note.NoteID), node.NodeID, node)
 
 	if err != nil {
 		return nil, errors.New("could not create node - " + err.Error())
 	}
+
+	if err := indexNode(db, email, node); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
 	
 	return &node, err
 
@@ -102,9 +124,23 @@ func (no *Node) FindNode(a *axon_types.AxonContext, folder_id string, note_id st
 	if err != nil {
 		return nil, errors.New("could not fetch node - " + err.Error())
 	}
+
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, no.Session, no.OwnerEmail, folder_id, note_id, axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch node - %w", err)
+	}
 	
 	// Fetch the Node
-	nodeResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", no.Session.SessionData.User.Email, folder_id, note_id), &node_id)
+	nodeResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), &node_id)
+
+	if err != nil {
+		return nil, errors.New("could not fetch node - " + err.Error())
+	}
+
+	if len(nodeResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch node - node %w", ErrNotFound)
+	}
 
 	var node axon_types.Node
 
@@ -124,12 +160,33 @@ func (no *Node) DeleteNode(a *axon_types.AxonContext, folder_id string, note_id
 		return nil, errors.New("could not delete node - " + err.Error())
 	}
 
-	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", no.Session.SessionData.User.Email, folder_id, note_id), &node_id)
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, no.Session, no.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not delete node - %w", err)
+	}
+
+	// Read the tags before the node is gone, to remove it from their indexes
+	item := axon_types.TaggedItem{Kind: axon_types.TaggedKindNode, FolderID: folder_id, NoteID: note_id, NodeID: node_id}
+	tags, _, err := itemTags(db, email, item)
+	if err != nil {
+		return nil, errors.New("could not delete node - " + err.Error())
+	}
+
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), &node_id)
 
 	if err != nil {
 		return nil, errors.New("could not delete node or node does not exist - " + err.Error())
 	}
 
+	if err := unindex(db, email, searchKey(folder_id, note_id, node_id)); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
+
+	if err := retag(db, email, item, tags, nil); err != nil {
+		return nil, errors.New("could not update tags - " + err.Error())
+	}
+
 	return &node_id, err
 
 }
@@ -142,37 +199,43 @@ func (no *Node) UpdateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 		return nil, errors.New("could not fetch note detail - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, no.Session, no.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not update node - %w", err)
+	}
+
 	// Create a map to store the updated attributes
 	updatedAttributes := make(map[string]*dynamodb.AttributeValue)
 
 	// Update the userNodeData fields if provided
 	if userNodeData.Label != "" {
-		updatedAttributes["userNodeData.label"] = &dynamodb.AttributeValue{S: &userNodeData.Label}
+		updatedAttributes["data.label"] = &dynamodb.AttributeValue{S: &userNodeData.Label}
 	}
 
-	if userNodeData.Label != "" {
-		updatedAttributes["userNodeData.label"] = &dynamodb.AttributeValue{S: &userNodeData.Label}
+	if userNodeData.Title != "" {
+		updatedAttributes["data.title"] = &dynamodb.AttributeValue{S: &userNodeData.Title}
 	}
 
 	if userNodeData.Description != "" {
-		updatedAttributes["userNodeData.description"] = &dynamodb.AttributeValue{S: &userNodeData.Description}
+		updatedAttributes["data.description"] = &dynamodb.AttributeValue{S: &userNodeData.Description}
 	}
 
 	if userNodeData.NodeCategory != "" {
-		updatedAttributes["userNodeData.nodeCategory"] = &dynamodb.AttributeValue{S: &userNodeData.NodeCategory}
+		updatedAttributes["data.node_category"] = &dynamodb.AttributeValue{S: &userNodeData.NodeCategory}
 	}
 
 	// Update the clientRefPosition fields if provided
 	if clientRefPosition.X != 0 {
-		updatedAttributes["clientRefPosition.x"] = &dynamodb.AttributeValue{N: jsii.String(strconv.Itoa(clientRefPosition.X))}
+		updatedAttributes["position.x"] = &dynamodb.AttributeValue{N: jsii.String(strconv.Itoa(clientRefPosition.X))}
 	}
 	if clientRefPosition.Y != 0 {
-		updatedAttributes["clientRefPosition.y"] = &dynamodb.AttributeValue{N: jsii.String(strconv.Itoa(clientRefPosition.Y))}
+		updatedAttributes["position.y"] = &dynamodb.AttributeValue{N: jsii.String(strconv.Itoa(clientRefPosition.Y))}
 	}
 
 	// Update the userContent fields if provided
 	if userContent.MarkDown != "" {
-		updatedAttributes["userContent.markdown"] = &dynamodb.AttributeValue{S: &userContent.MarkDown}
+		updatedAttributes["node_content.markdown"] = &dynamodb.AttributeValue{S: &userContent.MarkDown}
 	}
 
 	// Update the userStyles fields if provided
@@ -182,7 +245,7 @@ func (no *Node) UpdateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 		if err != nil {
 			return nil, err
 		}
-		updatedAttributes["userStyles.background_styles"] = &dynamodb.AttributeValue{
+		updatedAttributes["node_styles.background_styles"] = &dynamodb.AttributeValue{
 			M: backgroundStylesAV,
 		}
 	}
@@ -192,7 +255,7 @@ func (no *Node) UpdateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 		if err != nil {
 			return nil, err
 		}
-		updatedAttributes["userStyles.label_styles"] = &dynamodb.AttributeValue{
+		updatedAttributes["node_styles.label_styles"] = &dynamodb.AttributeValue{
 			M: labelStylesAV,
 		}
 	}
@@ -202,7 +265,7 @@ func (no *Node) UpdateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 		if err != nil {
 			return nil, err
 		}
-		updatedAttributes["userStyles.description_styles"] = &dynamodb.AttributeValue{
+		updatedAttributes["node_styles.description_styles"] = &dynamodb.AttributeValue{
 			M: descriptionStylesAV,
 		}
 	}
@@ -211,7 +274,15 @@ func (no *Node) UpdateNode(a *axon_types.AxonContext, userNodeData axon_types.No
 		S: jsii.String(time.Now().Format(time.RFC3339)),
 	}
 
-	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", no.Session.SessionData.User.Email, folder_id, note_id), node_id, updatedAttributes)
+	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), node_id, updatedAttributes)
+
+	if err != nil {
+		return nil, errors.New("could not update node - " + err.Error())
+	}
+
+	if err := reindexNode(db, email, folder_id, note_id, node_id); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
 
 	return &node_id, err
 
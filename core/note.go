@@ -15,6 +15,8 @@ import (
 
 type Note struct {
 	Session axon_types.Session
+	// Email of the owner when acting on a note shared with the session user
+	OwnerEmail string
 }
 
 // Gets the note data by ID and all the nodes and edges associated with it
@@ -26,22 +28,32 @@ func (n *Note) GetNoteDetail(a *axon_types.AxonContext, folder_id string, note_i
 		return nil, errors.New("could not fetch note detail - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, n.Session, n.OwnerEmail, folder_id, note_id, axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch note detail - %w", err)
+	}
+
 	// Fetch the Note
-	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id), &note_id)
+	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
 
 	if err != nil {
 		return nil, errors.New("could not fetch note - " + err.Error())
 	}
 
+	if len(noteResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch note - note %w", ErrNotFound)
+	}
+
 	// Fetch Nodes and Edges
-	nodeResult, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", n.Session.SessionData.User.Email, folder_id, note_id))
+	nodeItems, err := partitionItems(db, axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id))
 
 	if err != nil {
 		return nil, errors.New("could not fetch node details - " + err.Error())
 	}
 
 
-	edgeResult, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", n.Session.SessionData.User.Email, folder_id, note_id))
+	edgeItems, err := partitionItems(db, axon_types.AXON_TABLE, fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id))
 
 	if err != nil {
 		return nil, errors.New("could not fetch edge details - " + err.Error())
@@ -56,10 +68,10 @@ func (n *Note) GetNoteDetail(a *axon_types.AxonContext, folder_id string, note_i
 	if err := dynamodbattribute.UnmarshalMap(noteResult.Item, &note); err != nil {
 		return nil, err
 	}
-	if err := dynamodbattribute.UnmarshalListOfMaps(nodeResult.Items, &nodes); err != nil {
+	if err := dynamodbattribute.UnmarshalListOfMaps(nodeItems, &nodes); err != nil {
 		return nil, err
 	}
-	if err := dynamodbattribute.UnmarshalListOfMaps(edgeResult.Items, &edges); err != nil {
+	if err := dynamodbattribute.UnmarshalListOfMaps(edgeItems, &edges); err != nil {
 		return nil, err
 	}
 
@@ -68,6 +80,7 @@ func (n *Note) GetNoteDetail(a *axon_types.AxonContext, folder_id string, note_i
 	noteData.NoteID = note.NoteID
 	noteData.NoteName = note.NoteName
 	noteData.Description = note.Description
+	noteData.Tags = note.Tags
 	noteData.DateCreated = note.DateCreated
 	noteData.LastEdited = note.LastEdited
 	noteData.Nodes = nodes
@@ -84,8 +97,18 @@ func (n *Note) GetNotes(a *axon_types.AxonContext, folder_id string) (*[]axon_ty
 		return nil, errors.New("could not fetch note detail - " + err.Error())
 	}
 
+	// Resolve the owner of the folder and check access
+	email, err := authorize(db, n.Session, n.OwnerEmail, folder_id, "", axon_types.PermissionViewer)
+	if errors.Is(err, ErrForbidden) {
+		// Without access to the folder, only the notes shared individually are listed
+		return n.getSharedNotes(db, folder_id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch notes - %w", err)
+	}
+
 	// Fetch the Note
-	notesResult, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id))
+	notesResult, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id))
 
 	if err != nil {
 		return nil, errors.New("could not fetch notes - " + err.Error())
@@ -102,6 +125,25 @@ func (n *Note) GetNotes(a *axon_types.AxonContext, folder_id string) (*[]axon_ty
 
 }
 
+// EachNote calls fn with every note in one of the session user's folders, reading them
+// a page at a time
+func (n *Note) EachNote(a *axon_types.AxonContext, folder_id string, fn func(note axon_types.Note) error) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return errors.New("could not fetch notes - " + err.Error())
+	}
+
+	return eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id), func(item map[string]*dynamodb.AttributeValue) error {
+		var note axon_types.Note
+		if err := dynamodbattribute.UnmarshalMap(item, &note); err != nil {
+			return errors.New("could not fetch notes - " + err.Error())
+		}
+		return fn(note)
+	})
+}
+
 func (n *Note) CreateNote(a *axon_types.AxonContext, note_name string, description string, folder_id string) (*string, error) {
 
 	// Create the DynamoDB client
@@ -110,6 +152,12 @@ func (n *Note) CreateNote(a *axon_types.AxonContext, note_name string, descripti
 		return nil, errors.New("could not fetch note detail - " + err.Error())
 	}
 
+	// Resolve the owner of the folder and check access
+	email, err := authorize(db, n.Session, n.OwnerEmail, folder_id, "", axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not create note - %w", err)
+	}
+
 	//  Create note object
 	note := axon_types.Note{
 		UserId:      n.Session.SessionData.User.UserId,
@@ -122,12 +170,16 @@ func (n *Note) CreateNote(a *axon_types.AxonContext, note_name string, descripti
 	}
 
 	// Add note to Database
-	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id), note.NoteID, note)
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), note.NoteID, note)
 
 	if err != nil {
 		return nil, errors.New("could not create note - " + err.Error())
 	}
 
+	if err := indexNote(db, email, note); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
+
 	return &note.NoteID, err
 }
 
@@ -139,8 +191,22 @@ func (n *Note) FindNote(a *axon_types.AxonContext, folder_id string, note_id str
 		return nil, errors.New("could not fetch note - " + err.Error())
 	}
 
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, n.Session, n.OwnerEmail, folder_id, note_id, axon_types.PermissionViewer)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch note - %w", err)
+	}
+
 	// Fetch the Note
-	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id), &note_id)
+	noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
+
+	if err != nil {
+		return nil, errors.New("could not fetch note - " + err.Error())
+	}
+
+	if len(noteResult.Item) == 0 {
+		return nil, fmt.Errorf("could not fetch note - note %w", ErrNotFound)
+	}
 
 	var note axon_types.Note
 
@@ -159,17 +225,72 @@ func (n *Note) DeleteNote(a *axon_types.AxonContext, folder_id string, note_id s
 	if err != nil {
 		return nil, errors.New("could not delete note - " + err.Error())
 	}
+
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, n.Session, n.OwnerEmail, folder_id, note_id, axon_types.PermissionOwner)
+	if err != nil {
+		return nil, fmt.Errorf("could not delete note - %w", err)
+	}
+
+	// Read the tags before the note is gone, to remove it from their indexes
+	tags, _, err := itemTags(db, email, axon_types.TaggedItem{Kind: axon_types.TaggedKindNote, FolderID: folder_id, NoteID: note_id})
+	if err != nil {
+		return nil, errors.New("could not delete note - " + err.Error())
+	}
+
+	// Nodes and edges go first, so a note left by a failure still leads to them
+	if err := deleteNoteContent(db, email, folder_id, note_id); err != nil {
+		return nil, errors.New("could not delete note nodes and edges - " + err.Error())
+	}
 	
-	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id), &note_id)
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
 
 	if err != nil {
 		return nil, errors.New("could not delete note or note does not exist - " + err.Error())
 	}
 
+	// Remove any shares of the deleted note
+	share := Share{Session: n.Session}
+	if err := share.revokeResourceShares(db, folder_id, note_id); err != nil {
+		return nil, errors.New("could not revoke note shares - " + err.Error())
+	}
+
+	// Public links to the note stop working with it
+	if err := revokeResourceLinks(db, email, folder_id, note_id); err != nil {
+		return nil, errors.New("could not revoke note links - " + err.Error())
+	}
+
+	// Remove the note and its nodes from search
+	if err := unindex(db, email, searchKey(folder_id, note_id)); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
+
+	if err := untagNote(db, email, folder_id, note_id, tags); err != nil {
+		return nil, errors.New("could not update tags - " + err.Error())
+	}
+
 	return &note_id, err
 
 }
 
+// deleteNoteContent deletes the nodes and edges of a note, which are kept in
+// partitions of their own
+func deleteNoteContent(db *axon_coredb.DB, email string, folder_id string, note_id string) error {
+	for _, partition := range []string{
+		fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id),
+		fmt.Sprintf("EDGE#%s#%s#%s", email, folder_id, note_id),
+	} {
+		err := eachRecord(db, axon_types.AXON_TABLE, partition, func(item map[string]*dynamodb.AttributeValue) error {
+			sort_key := sortKey(item)
+			return db.DeleteRecord(axon_types.AXON_TABLE, partition, &sort_key)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (n *Note) UpdateNote(a *axon_types.AxonContext, name *string, description *string, folder_id string, note_id string) (*string, error) {
 
 	// Create the DynamoDB client
@@ -177,13 +298,19 @@ func (n *Note) UpdateNote(a *axon_types.AxonContext, name *string, description *
 	if err != nil {
 		return nil, errors.New("could not fetch note detail - " + err.Error())
 	}
+
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, n.Session, n.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not update note - %w", err)
+	}
 	
 	// Create a map to store the updated attributes
 	updatedAttributes := make(map[string]*dynamodb.AttributeValue)
 
 	// Check if the name field is provided and update it
 	if name != nil {
-		updatedAttributes["name"] = &dynamodb.AttributeValue{S: name}
+		updatedAttributes["note_name"] = &dynamodb.AttributeValue{S: name}
 	}
  
 	// Check if the description field is provided and update it
@@ -196,9 +323,52 @@ func (n *Note) UpdateNote(a *axon_types.AxonContext, name *string, description *
 		S: jsii.String(time.Now().Format(time.RFC3339)),
 	}
 
-	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", n.Session.SessionData.User.Email, folder_id), note_id, updatedAttributes)
+	err = db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), note_id, updatedAttributes)
 
+	if err != nil {
+		return nil, errors.New("could not update note - " + err.Error())
+	}
+
+	if err := reindexNote(db, email, folder_id, note_id); err != nil {
+		return nil, errors.New("could not update search index - " + err.Error())
+	}
 
 	return &note_id, err
 
 }
+
+func (n *Note) getSharedNotes(db *axon_coredb.DB, folder_id string) (*[]axon_types.Note, error) {
+	shares, err := sharedWith(db, n.Session.SessionData.User.Email)
+	if err != nil {
+		return nil, errors.New("could not fetch notes - " + err.Error())
+	}
+
+	notes := []axon_types.Note{}
+
+	for _, share := range shares {
+		if share.OwnerEmail != n.OwnerEmail || share.FolderID != folder_id || share.ResourceType != axon_types.ShareResourceNote {
+			continue
+		}
+
+		noteResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", share.OwnerEmail, share.FolderID), &share.NoteID)
+		if err != nil {
+			return nil, errors.New("could not fetch notes - " + err.Error())
+		}
+
+		if len(noteResult.Item) == 0 {
+			continue
+		}
+
+		var note axon_types.Note
+		if err := dynamodbattribute.UnmarshalMap(noteResult.Item, &note); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("could not fetch notes - %w", ErrForbidden)
+	}
+
+	return &notes, nil
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+var permissionRank = map[string]int{
+	axon_types.PermissionViewer:    1,
+	axon_types.PermissionCommenter: 2,
+	axon_types.PermissionEditor:    3,
+	axon_types.PermissionOwner:     4,
+}
+
+// ValidPermission reports whether a permission can be granted to another user
+func ValidPermission(permission string) bool {
+	switch permission {
+	case axon_types.PermissionViewer, axon_types.PermissionCommenter, axon_types.PermissionEditor:
+		return true
+	}
+	return false
+}
+
+// authorize resolves the email whose partitions hold an item, and checks that the
+// session user has at least the required permission on it. An empty owner_email
+// means the session user's own items. A note_id of "" checks the folder only.
+func authorize(db *axon_coredb.DB, session axon_types.Session, owner_email string, folder_id string, note_id string, required string) (string, error) {
+	email := session.SessionData.User.Email
+
+	if owner_email == "" || owner_email == email {
+		return email, nil
+	}
+
+	permission, err := sharedPermission(db, email, owner_email, folder_id, note_id)
+	if err != nil {
+		return "", err
+	}
+
+	if permissionRank[permission] < permissionRank[required] {
+		return "", fmt.Errorf("%w - %s access is required", ErrForbidden, required)
+	}
+
+	return owner_email, nil
+}
+
+// sharedPermission returns the highest permission granted to a recipient on a note,
+// either directly or through its folder
+func sharedPermission(db *axon_coredb.DB, recipient_email string, owner_email string, folder_id string, note_id string) (string, error) {
+	permission := ""
+
+	keys := []string{shareResourceKey(owner_email, folder_id, "")}
+	if note_id != "" {
+		keys = append(keys, shareResourceKey(owner_email, folder_id, note_id))
+	}
+
+	for _, key := range keys {
+		sortKey := key
+		result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SHARED_WITH#%s", recipient_email), &sortKey)
+		if err != nil {
+			return "", err
+		}
+
+		if len(result.Item) == 0 {
+			continue
+		}
+
+		var share axon_types.Share
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &share); err != nil {
+			return "", err
+		}
+
+		if permissionRank[share.Permission] > permissionRank[permission] {
+			permission = share.Permission
+		}
+	}
+
+	return permission, nil
+}
+
+// The recipient side of a share is keyed by the resource, so a recipient holds at
+// most one share per folder or note
+func shareResourceKey(owner_email string, folder_id string, note_id string) string {
+	if note_id == "" {
+		return fmt.Sprintf("%s#%s", owner_email, folder_id)
+	}
+	return fmt.Sprintf("%s#%s#%s", owner_email, folder_id, note_id)
+}
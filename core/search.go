@@ -0,0 +1,493 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_search "github.com/stephensanwo/axon-lib/search"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Search finds the session user's own folders, notes and nodes by their text. The
+// index is kept up to date by every create, update and delete in core.
+type Search struct {
+	Session axon_types.Session
+}
+
+// Most postings read for one word of a query, and most documents read to score a
+// query. Past these, results come from what was read and are marked truncated.
+const (
+	maxSearchPostings  = 5000
+	maxSearchDocuments = 1000
+)
+
+// Searches the index of the session user and returns up to limit results, best first.
+// Only the documents with the words of the query are read, fuzzy words are looked up
+// among the indexed words close to them. Documents indexed before words were are
+// found after a reindex.
+func (s *Search) Search(a *axon_types.AxonContext, q string, limit int) (*axon_types.SearchResults, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not search - " + err.Error())
+	}
+
+	query, err := axon_search.Parse(q)
+	if err != nil {
+		return nil, fmt.Errorf("could not search - %w", err)
+	}
+
+	documents, truncated, err := searchCandidates(db, s.Session.SessionData.User.Email, query)
+	if err != nil {
+		return nil, errors.New("could not search - " + err.Error())
+	}
+
+	results := []axon_types.SearchResult{}
+	for _, document := range documents {
+		if result, ok := axon_search.Match(document, query); ok {
+			results = append(results, result)
+		}
+	}
+
+	axon_search.Rank(results)
+
+	total := len(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &axon_types.SearchResults{Query: q, Results: results, Total: total, Truncated: truncated}, nil
+}
+
+// searchCandidates reads the documents that can match a query. A match contains a
+// word of every clause, so the postings of each clause are intersected and only the
+// documents in all of them are read. It reports whether candidates were left out
+// because a limit was reached.
+func searchCandidates(db *axon_coredb.DB, email string, query axon_search.Query) ([]axon_types.SearchDocument, bool, error) {
+	var keys map[string]bool
+	truncated := false
+	for _, clause := range query.Clauses {
+		found, cut, err := clausePostings(db, email, clause)
+		if err != nil {
+			return nil, false, err
+		}
+		truncated = truncated || cut
+
+		if keys == nil {
+			keys = found
+		}
+		for key := range keys {
+			if !found[key] {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, truncated, nil
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	if len(sorted) > maxSearchDocuments {
+		sorted = sorted[:maxSearchDocuments]
+		truncated = true
+	}
+
+	documents := make([]axon_types.SearchDocument, 0, len(sorted))
+	for _, key := range sorted {
+		result, err := db.QueryDatabase(axon_types.AXON_TABLE, searchPartition(email), &key)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(result.Item) == 0 {
+			continue
+		}
+
+		var document axon_types.SearchDocument
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &document); err != nil {
+			return nil, false, err
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, truncated, nil
+}
+
+// clausePostings returns the keys of the documents with a word of the clause, and
+// whether some were left out
+func clausePostings(db *axon_coredb.DB, email string, clause axon_search.Clause) (map[string]bool, bool, error) {
+	if term, prefix, ok := clause.IndexTerm(); ok {
+		begins := term + "#"
+		if prefix {
+			begins = term
+		}
+		return postings(db, email, begins, nil)
+	}
+
+	// A fuzzy word is expanded to the indexed words within its edits
+	keys := map[string]bool{}
+	truncated := false
+	for _, begins := range clause.FuzzyPrefixes() {
+		found, cut, err := postings(db, email, begins, clause.MatchesWord)
+		if err != nil {
+			return nil, false, err
+		}
+		for key := range found {
+			keys[key] = true
+		}
+		truncated = truncated || cut
+	}
+	return keys, truncated, nil
+}
+
+// postings returns the keys of the documents indexed under a word starting with
+// begins, and kept by keep when it is set, and whether more postings were left unread
+func postings(db *axon_coredb.DB, email string, begins string, keep func(term string) bool) (map[string]bool, bool, error) {
+	keys := map[string]bool{}
+	kept := map[string]bool{}
+	read := 0
+	var start map[string]*dynamodb.AttributeValue
+	for {
+		result, err := db.QueryDatabasePrefixPage(axon_types.AXON_TABLE, searchTermPartition(email), begins, start)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, item := range result.Items {
+			var posting axon_types.SearchPosting
+			if err := dynamodbattribute.UnmarshalMap(item, &posting); err != nil {
+				return nil, false, err
+			}
+			read++
+
+			if keep != nil {
+				match, seen := kept[posting.Term]
+				if !seen {
+					match = keep(posting.Term)
+					kept[posting.Term] = match
+				}
+				if !match {
+					continue
+				}
+			}
+			keys[posting.Document] = true
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return keys, false, nil
+		}
+		if read >= maxSearchPostings {
+			return keys, true, nil
+		}
+		start = result.LastEvaluatedKey
+	}
+}
+
+// Rebuilds the index of the session user from their folders, notes and nodes, for
+// data written before the index existed
+func (s *Search) Reindex(a *axon_types.AxonContext) (*axon_types.SearchIndexReport, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not rebuild search index - " + err.Error())
+	}
+
+	email := s.Session.SessionData.User.Email
+	if err := unindex(db, email, ""); err != nil {
+		return nil, errors.New("could not rebuild search index - " + err.Error())
+	}
+
+	report := axon_types.SearchIndexReport{}
+	err = eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), func(item map[string]*dynamodb.AttributeValue) error {
+		var folder axon_types.Folder
+		if err := dynamodbattribute.UnmarshalMap(item, &folder); err != nil {
+			return err
+		}
+		if err := indexFolder(db, email, folder); err != nil {
+			return err
+		}
+		report.Folders++
+
+		return eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder.FolderID), func(item map[string]*dynamodb.AttributeValue) error {
+			var note axon_types.Note
+			if err := dynamodbattribute.UnmarshalMap(item, &note); err != nil {
+				return err
+			}
+			if err := indexNote(db, email, note); err != nil {
+				return err
+			}
+			report.Notes++
+
+			return eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, note.FolderID, note.NoteID), func(item map[string]*dynamodb.AttributeValue) error {
+				var node axon_types.Node
+				if err := dynamodbattribute.UnmarshalMap(item, &node); err != nil {
+					return err
+				}
+				report.Nodes++
+				return indexNode(db, email, node)
+			})
+		})
+	})
+	if err != nil {
+		return nil, errors.New("could not rebuild search index - " + err.Error())
+	}
+
+	return &report, nil
+}
+
+func searchPartition(email string) string {
+	return fmt.Sprintf("SEARCH#%s", email)
+}
+
+func searchTermPartition(email string) string {
+	return fmt.Sprintf("SEARCHTERM#%s", email)
+}
+
+// searchKey is the sort key of the document of a folder, note or node
+func searchKey(ids ...string) string {
+	return strings.Join(ids, "#")
+}
+
+func indexFolder(db *axon_coredb.DB, email string, folder axon_types.Folder) error {
+	return putSearchDocument(db, email, axon_types.SearchDocument{
+		Kind:       axon_types.SearchKindFolder,
+		FolderID:   folder.FolderID,
+		Fields:     map[string]string{axon_types.SearchFieldName: folder.FolderName},
+		LastEdited: folder.LastEdited,
+	})
+}
+
+func indexNote(db *axon_coredb.DB, email string, note axon_types.Note) error {
+	return putSearchDocument(db, email, axon_types.SearchDocument{
+		Kind:     axon_types.SearchKindNote,
+		FolderID: note.FolderID,
+		NoteID:   note.NoteID,
+		Fields: map[string]string{
+			axon_types.SearchFieldName:        note.NoteName,
+			axon_types.SearchFieldDescription: note.Description,
+		},
+		LastEdited: note.LastEdited,
+	})
+}
+
+func indexNode(db *axon_coredb.DB, email string, node axon_types.Node) error {
+	return putSearchDocument(db, email, axon_types.SearchDocument{
+		Kind:     axon_types.SearchKindNode,
+		FolderID: node.FolderID,
+		NoteID:   node.NoteID,
+		NodeID:   node.NodeID,
+		Fields: map[string]string{
+			axon_types.SearchFieldLabel:       node.Data.Label,
+			axon_types.SearchFieldTitle:       node.Data.Title,
+			axon_types.SearchFieldDescription: node.Data.Description,
+			axon_types.SearchFieldMarkdown:    node.Content.MarkDown,
+		},
+		LastEdited: node.LastEdited,
+	})
+}
+
+// putSearchDocument writes a document without its empty fields, and indexes it under
+// its words. A document with no text is removed instead, as nothing can match it.
+func putSearchDocument(db *axon_coredb.DB, email string, document axon_types.SearchDocument) error {
+	key := searchKey(document.FolderID)
+	switch document.Kind {
+	case axon_types.SearchKindNote:
+		key = searchKey(document.FolderID, document.NoteID)
+	case axon_types.SearchKindNode:
+		key = searchKey(document.FolderID, document.NoteID, document.NodeID)
+	}
+
+	for field, text := range document.Fields {
+		if strings.TrimSpace(text) == "" {
+			delete(document.Fields, field)
+		}
+	}
+
+	// Words the document was indexed under before
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, searchPartition(email), &key)
+	if err != nil {
+		return err
+	}
+	var previous axon_types.SearchDocument
+	if len(result.Item) > 0 {
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &previous); err != nil {
+			return err
+		}
+	}
+
+	if len(document.Fields) == 0 {
+		if err := db.DeleteRecord(axon_types.AXON_TABLE, searchPartition(email), &key); err != nil {
+			return err
+		}
+		return post(db, email, key, previous.Terms, nil)
+	}
+
+	document.Terms = axon_search.DocumentTerms(document)
+	if document.LastEdited.IsZero() {
+		document.LastEdited = time.Now()
+	}
+	if err := db.MutateDatabase(axon_types.AXON_TABLE, searchPartition(email), key, document); err != nil {
+		return err
+	}
+	return post(db, email, key, previous.Terms, document.Terms)
+}
+
+// post moves the postings of a document from the words it was indexed under to its
+// current words
+func post(db *axon_coredb.DB, email string, key string, previous []string, current []string) error {
+	partition := searchTermPartition(email)
+
+	kept := map[string]bool{}
+	for _, term := range current {
+		kept[term] = true
+	}
+	for _, term := range previous {
+		if !kept[term] {
+			posting := term + "#" + key
+			if err := db.DeleteRecord(axon_types.AXON_TABLE, partition, &posting); err != nil {
+				return err
+			}
+		}
+	}
+
+	indexed := map[string]bool{}
+	for _, term := range previous {
+		indexed[term] = true
+	}
+	for _, term := range current {
+		if !indexed[term] {
+			posting := axon_types.SearchPosting{Term: term, Document: key}
+			if err := db.MutateDatabase(axon_types.AXON_TABLE, partition, term+"#"+key, posting); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// reindexFolder indexes a folder as it is stored, after a partial update
+func reindexFolder(db *axon_coredb.DB, email string, folder_id string) error {
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("FOLDER#%s", email), &folder_id)
+	if err != nil {
+		return err
+	}
+	if len(result.Item) == 0 {
+		return unindex(db, email, searchKey(folder_id))
+	}
+
+	var folder axon_types.Folder
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &folder); err != nil {
+		return err
+	}
+	folder.FolderID = folder_id
+	return indexFolder(db, email, folder)
+}
+
+// reindexNote indexes a note as it is stored, after a partial update
+func reindexNote(db *axon_coredb.DB, email string, folder_id string, note_id string) error {
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), &note_id)
+	if err != nil {
+		return err
+	}
+	if len(result.Item) == 0 {
+		return unindex(db, email, searchKey(folder_id, note_id))
+	}
+
+	var note axon_types.Note
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &note); err != nil {
+		return err
+	}
+	note.FolderID, note.NoteID = folder_id, note_id
+	return indexNote(db, email, note)
+}
+
+// reindexNode indexes a node as it is stored, after a partial update
+func reindexNode(db *axon_coredb.DB, email string, folder_id string, note_id string, node_id string) error {
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), &node_id)
+	if err != nil {
+		return err
+	}
+	if len(result.Item) == 0 {
+		return unindex(db, email, searchKey(folder_id, note_id, node_id))
+	}
+
+	var node axon_types.Node
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &node); err != nil {
+		return err
+	}
+	node.FolderID, node.NoteID, node.NodeID = folder_id, note_id, node_id
+	return indexNode(db, email, node)
+}
+
+// unindex removes the document with the key and the documents under it, those of the
+// notes and nodes of a folder or the nodes of a note, with their postings. An empty key
+// removes them all.
+func unindex(db *axon_coredb.DB, email string, key string) error {
+	partition := searchPartition(email)
+
+	remove := func(item map[string]*dynamodb.AttributeValue) error {
+		var document axon_types.SearchDocument
+		if err := dynamodbattribute.UnmarshalMap(item, &document); err != nil {
+			return err
+		}
+		sort_key := sortKey(item)
+		if err := db.DeleteRecord(axon_types.AXON_TABLE, partition, &sort_key); err != nil {
+			return err
+		}
+		return post(db, email, sort_key, document.Terms, nil)
+	}
+
+	if key == "" {
+		if err := eachRecord(db, axon_types.AXON_TABLE, partition, remove); err != nil {
+			return err
+		}
+		// And any postings left without a document
+		terms := searchTermPartition(email)
+		return eachRecord(db, axon_types.AXON_TABLE, terms, func(item map[string]*dynamodb.AttributeValue) error {
+			sort_key := sortKey(item)
+			return db.DeleteRecord(axon_types.AXON_TABLE, terms, &sort_key)
+		})
+	}
+
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, partition, &key)
+	if err != nil {
+		return err
+	}
+	if len(result.Item) > 0 {
+		if err := remove(result.Item); err != nil {
+			return err
+		}
+	}
+	prefix := key + "#"
+
+	var start map[string]*dynamodb.AttributeValue
+	for {
+		result, err := db.QueryDatabasePrefixPage(axon_types.AXON_TABLE, partition, prefix, start)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range result.Items {
+			if err := remove(item); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		start = result.LastEvaluatedKey
+	}
+}
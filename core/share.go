@@ -0,0 +1,335 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/google/uuid"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Share manages the folders and notes the session user shares with other users.
+// Each share is stored twice, under the owner for listing and revocation, and
+// under the recipient keyed by resource for permission checks.
+type Share struct {
+	Session axon_types.Session
+}
+
+func (s *Share) ShareFolder(a *axon_types.AxonContext, folder_id string, recipient_email string, permission string) (*axon_types.Share, error) {
+	return s.share(a, axon_types.ShareResourceFolder, folder_id, "", recipient_email, permission)
+}
+
+func (s *Share) ShareNote(a *axon_types.AxonContext, folder_id string, note_id string, recipient_email string, permission string) (*axon_types.Share, error) {
+	return s.share(a, axon_types.ShareResourceNote, folder_id, note_id, recipient_email, permission)
+}
+
+func (s *Share) share(a *axon_types.AxonContext, resource_type string, folder_id string, note_id string, recipient_email string, permission string) (*axon_types.Share, error) {
+
+	owner := s.Session.SessionData.User
+
+	if !ValidPermission(permission) {
+		return nil, errors.New("could not share - invalid permission " + permission)
+	}
+
+	if recipient_email == owner.Email {
+		return nil, errors.New("could not share - cannot share with yourself")
+	}
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not share - " + err.Error())
+	}
+
+	// Confirm that the shared item exists
+	partitionKey, sortKey := fmt.Sprintf("FOLDER#%s", owner.Email), folder_id
+	if resource_type == axon_types.ShareResourceNote {
+		partitionKey, sortKey = fmt.Sprintf("NOTE#%s#%s", owner.Email, folder_id), note_id
+	}
+
+	itemResult, err := db.QueryDatabase(axon_types.AXON_TABLE, partitionKey, &sortKey)
+	if err != nil {
+		return nil, errors.New("could not share - " + err.Error())
+	}
+
+	if len(itemResult.Item) == 0 {
+		return nil, fmt.Errorf("could not share - %s does not exist", resource_type)
+	}
+
+	// Confirm that the recipient is an axon user
+	recipientResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("USER#%s", recipient_email), &recipient_email)
+	if err != nil {
+		return nil, errors.New("could not share - " + err.Error())
+	}
+
+	if len(recipientResult.Item) == 0 {
+		return nil, errors.New("could not share - recipient is not an axon user")
+	}
+
+	// Sharing an item again changes the permission of the existing share
+	resourceKey := shareResourceKey(owner.Email, folder_id, note_id)
+	existingResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SHARED_WITH#%s", recipient_email), &resourceKey)
+	if err != nil {
+		return nil, errors.New("could not share - " + err.Error())
+	}
+
+	if len(existingResult.Item) > 0 {
+		var existing axon_types.Share
+		if err := dynamodbattribute.UnmarshalMap(existingResult.Item, &existing); err != nil {
+			return nil, err
+		}
+		return s.changePermission(db, existing, permission)
+	}
+
+	share := axon_types.Share{
+		ShareId:        uuid.New().String(),
+		OwnerId:        owner.UserId,
+		OwnerEmail:     owner.Email,
+		RecipientEmail: recipient_email,
+		ResourceType:   resource_type,
+		FolderID:       folder_id,
+		NoteID:         note_id,
+		Permission:     permission,
+		DateCreated:    time.Now(),
+		LastEdited:     time.Now(),
+	}
+
+	if err := putShare(db, share); err != nil {
+		return nil, errors.New("could not share - " + err.Error())
+	}
+
+	if err := s.audit(db, share, axon_types.ShareActionGranted, ""); err != nil {
+		return nil, errors.New("could not record share audit - " + err.Error())
+	}
+
+	return &share, nil
+}
+
+func (s *Share) UpdateShare(a *axon_types.AxonContext, share_id string, permission string) (*axon_types.Share, error) {
+
+	if !ValidPermission(permission) {
+		return nil, errors.New("could not update share - invalid permission " + permission)
+	}
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not update share - " + err.Error())
+	}
+
+	share, err := s.findShare(db, share_id)
+	if err != nil {
+		return nil, errors.New("could not update share - " + err.Error())
+	}
+
+	return s.changePermission(db, *share, permission)
+}
+
+func (s *Share) RevokeShare(a *axon_types.AxonContext, share_id string) (*string, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not revoke share - " + err.Error())
+	}
+
+	share, err := s.findShare(db, share_id)
+	if err != nil {
+		return nil, errors.New("could not revoke share - " + err.Error())
+	}
+
+	if err := s.revoke(db, *share); err != nil {
+		return nil, errors.New("could not revoke share - " + err.Error())
+	}
+
+	return &share_id, nil
+}
+
+// Shares the session user has granted to other users
+func (s *Share) GetShares(a *axon_types.AxonContext) (*[]axon_types.Share, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch shares - " + err.Error())
+	}
+
+	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("SHARE#%s", s.Session.SessionData.User.Email))
+	if err != nil {
+		return nil, errors.New("could not fetch shares - " + err.Error())
+	}
+
+	var shares []axon_types.Share
+
+	// Unmarshal the DynamoDB items into Share structs
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &shares); err != nil {
+		return nil, err
+	}
+
+	return &shares, err
+}
+
+// Shares other users have granted to the session user
+func (s *Share) GetSharedWithMe(a *axon_types.AxonContext) (*[]axon_types.Share, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch shares - " + err.Error())
+	}
+
+	shares, err := sharedWith(db, s.Session.SessionData.User.Email)
+	if err != nil {
+		return nil, errors.New("could not fetch shares - " + err.Error())
+	}
+
+	return &shares, err
+}
+
+// Audit trail of shares on the session user's items, most recent first
+func (s *Share) GetShareAudit(a *axon_types.AxonContext) (*[]axon_types.ShareAudit, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch share audit - " + err.Error())
+	}
+
+	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("SHARE_AUDIT#%s", s.Session.SessionData.User.Email))
+	if err != nil {
+		return nil, errors.New("could not fetch share audit - " + err.Error())
+	}
+
+	var audit []axon_types.ShareAudit
+
+	// Unmarshal the DynamoDB items into ShareAudit structs
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &audit); err != nil {
+		return nil, err
+	}
+
+	return &audit, err
+}
+
+func (s *Share) findShare(db *axon_coredb.DB, share_id string) (*axon_types.Share, error) {
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SHARE#%s", s.Session.SessionData.User.Email), &share_id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Item) == 0 {
+		return nil, errors.New("share does not exist")
+	}
+
+	var share axon_types.Share
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &share); err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+func (s *Share) changePermission(db *axon_coredb.DB, share axon_types.Share, permission string) (*axon_types.Share, error) {
+	previous := share.Permission
+	if previous == permission {
+		return &share, nil
+	}
+
+	share.Permission = permission
+	share.LastEdited = time.Now()
+
+	if err := putShare(db, share); err != nil {
+		return nil, errors.New("could not update share - " + err.Error())
+	}
+
+	if err := s.audit(db, share, axon_types.ShareActionChanged, previous); err != nil {
+		return nil, errors.New("could not record share audit - " + err.Error())
+	}
+
+	return &share, nil
+}
+
+func (s *Share) revoke(db *axon_coredb.DB, share axon_types.Share) error {
+	resourceKey := shareResourceKey(share.OwnerEmail, share.FolderID, share.NoteID)
+
+	// Remove the recipient side first, which is what grants access
+	if err := db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("SHARED_WITH#%s", share.RecipientEmail), &resourceKey); err != nil {
+		return err
+	}
+
+	if err := db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("SHARE#%s", share.OwnerEmail), &share.ShareId); err != nil {
+		return err
+	}
+
+	return s.audit(db, share, axon_types.ShareActionRevoked, share.Permission)
+}
+
+// revokeResourceShares removes every share on a folder or note that is being deleted.
+// Shares on notes in a deleted folder are removed with it.
+func (s *Share) revokeResourceShares(db *axon_coredb.DB, folder_id string, note_id string) error {
+	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("SHARE#%s", s.Session.SessionData.User.Email))
+	if err != nil {
+		return err
+	}
+
+	var shares []axon_types.Share
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &shares); err != nil {
+		return err
+	}
+
+	for _, share := range shares {
+		if share.FolderID != folder_id || (note_id != "" && share.NoteID != note_id) {
+			continue
+		}
+		if err := s.revoke(db, share); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Share) audit(db *axon_coredb.DB, share axon_types.Share, action string, previous_permission string) error {
+	audit := axon_types.ShareAudit{
+		AuditId:            uuid.New().String(),
+		ShareId:            share.ShareId,
+		ActorEmail:         s.Session.SessionData.User.Email,
+		Action:             action,
+		RecipientEmail:     share.RecipientEmail,
+		ResourceType:       share.ResourceType,
+		FolderID:           share.FolderID,
+		NoteID:             share.NoteID,
+		PreviousPermission: previous_permission,
+		DateCreated:        time.Now(),
+	}
+
+	if action != axon_types.ShareActionRevoked {
+		audit.Permission = share.Permission
+	}
+
+	return db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SHARE_AUDIT#%s", share.OwnerEmail), audit.AuditId, audit)
+}
+
+func putShare(db *axon_coredb.DB, share axon_types.Share) error {
+	if err := db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SHARE#%s", share.OwnerEmail), share.ShareId, share); err != nil {
+		return err
+	}
+
+	return db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SHARED_WITH#%s", share.RecipientEmail), shareResourceKey(share.OwnerEmail, share.FolderID, share.NoteID), share)
+}
+
+func sharedWith(db *axon_coredb.DB, recipient_email string) ([]axon_types.Share, error) {
+	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("SHARED_WITH#%s", recipient_email))
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []axon_types.Share
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &shares); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
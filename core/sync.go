@@ -0,0 +1,68 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Sync stores the sync state of the session user, one record per repository
+type Sync struct {
+	Session axon_types.Session
+}
+
+// Gets the state of the last sync with a repository. A repository that has never been
+// synced returns an empty state.
+func (s *Sync) GetSyncState(a *axon_types.AxonContext, repository string) (*axon_types.SyncState, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch sync state - " + err.Error())
+	}
+
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SYNC#%s", s.Session.SessionData.User.Email), &repository)
+	if err != nil {
+		return nil, errors.New("could not fetch sync state - " + err.Error())
+	}
+
+	state := axon_types.SyncState{Repository: repository, Notes: map[string]axon_types.NoteSyncState{}}
+	if len(result.Item) == 0 {
+		return &state, nil
+	}
+
+	// Unmarshal the DynamoDB item into a SyncState struct
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &state); err != nil {
+		return nil, err
+	}
+
+	if state.Notes == nil {
+		state.Notes = map[string]axon_types.NoteSyncState{}
+	}
+
+	return &state, nil
+}
+
+func (s *Sync) PutSyncState(a *axon_types.AxonContext, state axon_types.SyncState) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return errors.New("could not save sync state - " + err.Error())
+	}
+
+	if state.DateCreated.IsZero() {
+		state.DateCreated = time.Now()
+	}
+
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("SYNC#%s", s.Session.SessionData.User.Email), state.Repository, state)
+	if err != nil {
+		return errors.New("could not save sync state - " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,765 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/jsii-runtime-go"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// ErrInvalidTag is returned for tags that are empty, too long or hold characters
+// other than letters, digits, - _ and .
+var ErrInvalidTag = errors.New("invalid tag")
+
+// Most tags a note or node may have, and the longest tag in characters
+const (
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+// Tag manages the tags of notes and nodes, and the tag catalogue of the session user.
+// Tags set on a note shared with the user go to the catalogue of its owner.
+type Tag struct {
+	Session axon_types.Session
+	// Email of the owner when tagging a note shared with the session user
+	OwnerEmail string
+}
+
+// Lists the tag catalogue of the session user by name
+func (t *Tag) GetTags(a *axon_types.AxonContext) (*[]axon_types.Tag, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch tags - " + err.Error())
+	}
+
+	tags := []axon_types.Tag{}
+	err = eachRecord(db, axon_types.AXON_TABLE, tagPartition(t.Session.SessionData.User.Email), func(item map[string]*dynamodb.AttributeValue) error {
+		var tag axon_types.Tag
+		if err := dynamodbattribute.UnmarshalMap(item, &tag); err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("could not fetch tags - " + err.Error())
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return &tags, nil
+}
+
+// Replaces the tags of a note and returns them as stored: lower case, spaces as
+// dashes, without repeats and sorted
+func (t *Tag) SetNoteTags(a *axon_types.AxonContext, folder_id string, note_id string, tags []string) (*[]string, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not tag note - " + err.Error())
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("could not tag note - %w", err)
+	}
+
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, t.Session, t.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not tag note - %w", err)
+	}
+
+	item := axon_types.TaggedItem{Kind: axon_types.TaggedKindNote, FolderID: folder_id, NoteID: note_id}
+	if err := setItemTags(db, email, item, normalized); err != nil {
+		return nil, fmt.Errorf("could not tag note - %w", err)
+	}
+
+	return &normalized, nil
+}
+
+// Replaces the tags of a node and returns them as stored, like SetNoteTags
+func (t *Tag) SetNodeTags(a *axon_types.AxonContext, folder_id string, note_id string, node_id string, tags []string) (*[]string, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not tag node - " + err.Error())
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("could not tag node - %w", err)
+	}
+
+	// Resolve the owner of the note and check access
+	email, err := authorize(db, t.Session, t.OwnerEmail, folder_id, note_id, axon_types.PermissionEditor)
+	if err != nil {
+		return nil, fmt.Errorf("could not tag node - %w", err)
+	}
+
+	item := axon_types.TaggedItem{Kind: axon_types.TaggedKindNode, FolderID: folder_id, NoteID: note_id, NodeID: node_id}
+	if err := setItemTags(db, email, item, normalized); err != nil {
+		return nil, fmt.Errorf("could not tag node - %w", err)
+	}
+
+	return &normalized, nil
+}
+
+// Lists the session user's notes with a tag, from the tag's index
+func (t *Tag) TaggedNotes(a *axon_types.AxonContext, tag string) (*[]axon_types.Note, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch tagged notes - " + err.Error())
+	}
+
+	name, err := normalizeTag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch tagged notes - %w", err)
+	}
+
+	email := t.Session.SessionData.User.Email
+	notes := []axon_types.Note{}
+	err = eachTaggedItem(db, email, name, axon_types.TaggedKindNote, func(item axon_types.TaggedItem) error {
+		result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, item.FolderID), &item.NoteID)
+		if err != nil || len(result.Item) == 0 {
+			return err
+		}
+
+		var note axon_types.Note
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &note); err != nil {
+			return err
+		}
+		notes = append(notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("could not fetch tagged notes - " + err.Error())
+	}
+
+	return &notes, nil
+}
+
+// Lists the session user's nodes with a tag across all notes, from the tag's index
+func (t *Tag) TaggedNodes(a *axon_types.AxonContext, tag string) (*[]axon_types.Node, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch tagged nodes - " + err.Error())
+	}
+
+	name, err := normalizeTag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch tagged nodes - %w", err)
+	}
+
+	email := t.Session.SessionData.User.Email
+	nodes := []axon_types.Node{}
+	err = eachTaggedItem(db, email, name, axon_types.TaggedKindNode, func(item axon_types.TaggedItem) error {
+		result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, item.FolderID, item.NoteID), &item.NodeID)
+		if err != nil || len(result.Item) == 0 {
+			return err
+		}
+
+		var node axon_types.Node
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &node); err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("could not fetch tagged nodes - " + err.Error())
+	}
+
+	return &nodes, nil
+}
+
+// Renames a tag on every note and node that has it. Renaming to a tag that is in use
+// is a conflict, MergeTags joins two tags.
+func (t *Tag) RenameTag(a *axon_types.AxonContext, tag string, name string) (*axon_types.Tag, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not rename tag - " + err.Error())
+	}
+
+	from, err := normalizeTag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("could not rename tag - %w", err)
+	}
+	to, err := normalizeTag(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not rename tag - %w", err)
+	}
+
+	email := t.Session.SessionData.User.Email
+
+	existing, err := findTag(db, email, from)
+	if err != nil {
+		return nil, errors.New("could not rename tag - " + err.Error())
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("could not rename tag - tag %w", ErrNotFound)
+	}
+	if from == to {
+		return existing, nil
+	}
+
+	target, err := findTag(db, email, to)
+	if err != nil {
+		return nil, errors.New("could not rename tag - " + err.Error())
+	}
+	if target != nil {
+		return nil, fmt.Errorf("could not rename tag - tag %s %w, merge the tags instead", to, ErrConflict)
+	}
+
+	if err := moveTag(db, email, from, to); err != nil {
+		return nil, errors.New("could not rename tag - " + err.Error())
+	}
+
+	renamed, err := recountTag(db, email, to, existing.DateCreated)
+	if err != nil {
+		return nil, errors.New("could not rename tag - " + err.Error())
+	}
+	return renamed, nil
+}
+
+// Merges tags into another, which is created when it is not in use. Notes and nodes
+// with several of the tags end up with the target once.
+func (t *Tag) MergeTags(a *axon_types.AxonContext, tags []string, into string) (*axon_types.Tag, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not merge tags - " + err.Error())
+	}
+
+	sources, err := normalizeTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("could not merge tags - %w", err)
+	}
+	target, err := normalizeTag(into)
+	if err != nil {
+		return nil, fmt.Errorf("could not merge tags - %w", err)
+	}
+
+	email := t.Session.SessionData.User.Email
+
+	created := time.Now()
+	existing, err := findTag(db, email, target)
+	if err != nil {
+		return nil, errors.New("could not merge tags - " + err.Error())
+	}
+	if existing != nil {
+		created = existing.DateCreated
+	}
+
+	for _, source := range sources {
+		existing, err := findTag(db, email, source)
+		if err != nil {
+			return nil, errors.New("could not merge tags - " + err.Error())
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("could not merge tags - tag %s %w", source, ErrNotFound)
+		}
+		if existing.DateCreated.Before(created) {
+			created = existing.DateCreated
+		}
+	}
+
+	for _, source := range sources {
+		if source == target {
+			continue
+		}
+		if err := moveTag(db, email, source, target); err != nil {
+			return nil, errors.New("could not merge tags - " + err.Error())
+		}
+	}
+
+	merged, err := recountTag(db, email, target, created)
+	if err != nil {
+		return nil, errors.New("could not merge tags - " + err.Error())
+	}
+	return merged, nil
+}
+
+func tagPartition(email string) string {
+	return fmt.Sprintf("TAG#%s", email)
+}
+
+func taggedPartition(email string, tag string) string {
+	return fmt.Sprintf("TAGGED#%s#%s", email, tag)
+}
+
+// taggedKey is the sort key of an item in the index of a tag
+func taggedKey(item axon_types.TaggedItem) string {
+	if item.Kind == axon_types.TaggedKindNode {
+		return strings.Join([]string{item.Kind, item.FolderID, item.NoteID, item.NodeID}, "#")
+	}
+	return strings.Join([]string{item.Kind, item.FolderID, item.NoteID}, "#")
+}
+
+// normalizeTag returns a tag in lower case with runs of spaces as a single dash
+func normalizeTag(tag string) (string, error) {
+	name := strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+	if name == "" {
+		return "", fmt.Errorf("%w - tags cannot be empty", ErrInvalidTag)
+	}
+	if utf8.RuneCountInString(name) > maxTagLength {
+		return "", fmt.Errorf("%w - %s is longer than %d characters", ErrInvalidTag, name, maxTagLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' {
+			return "", fmt.Errorf("%w - %s may only hold letters, digits, - _ and .", ErrInvalidTag, name)
+		}
+	}
+	return name, nil
+}
+
+// normalizeTags normalizes each tag, and returns them sorted without repeats
+func normalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		name, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("%w - at most %d tags are allowed", ErrInvalidTag, maxTags)
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// validTags normalizes the tags of imported notes and nodes, leaving out the ones
+// that are not valid and any after the first maxTags
+func validTags(tags []string) []string {
+	seen := map[string]bool{}
+	valid := []string{}
+	for _, tag := range tags {
+		name, err := normalizeTag(tag)
+		if err != nil || seen[name] || len(valid) == maxTags {
+			continue
+		}
+		seen[name] = true
+		valid = append(valid, name)
+	}
+
+	if len(valid) == 0 {
+		return nil
+	}
+	sort.Strings(valid)
+	return valid
+}
+
+// itemRecord returns the partition and sort key of the note or node of an item
+func itemRecord(email string, item axon_types.TaggedItem) (string, string) {
+	if item.Kind == axon_types.TaggedKindNode {
+		return fmt.Sprintf("NODE#%s#%s#%s", email, item.FolderID, item.NoteID), item.NodeID
+	}
+	return fmt.Sprintf("NOTE#%s#%s", email, item.FolderID), item.NoteID
+}
+
+// itemTags reads the tags of the note or node of an item. It returns false when the
+// note or node does not exist.
+func itemTags(db *axon_coredb.DB, email string, item axon_types.TaggedItem) ([]string, bool, error) {
+	partition_key, sort_key := itemRecord(email, item)
+
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, partition_key, &sort_key)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(result.Item) == 0 {
+		return nil, false, nil
+	}
+
+	var record struct {
+		Tags []string `json:"tags"`
+	}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, false, err
+	}
+	return record.Tags, true, nil
+}
+
+// writeItemTags stores the tags of an existing note or node
+func writeItemTags(db *axon_coredb.DB, email string, item axon_types.TaggedItem, tags []string) error {
+	partition_key, sort_key := itemRecord(email, item)
+
+	list, err := dynamodbattribute.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	return db.UpdateRecord(axon_types.AXON_TABLE, partition_key, sort_key, map[string]*dynamodb.AttributeValue{
+		"tags":        list,
+		"last_edited": {S: jsii.String(time.Now().Format(time.RFC3339))},
+	})
+}
+
+// setItemTags replaces the tags of a note or node, and updates the indexes and counts
+// of the tags added and removed
+func setItemTags(db *axon_coredb.DB, email string, item axon_types.TaggedItem, tags []string) error {
+	previous, found, err := itemTags(db, email, item)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s %w", item.Kind, ErrNotFound)
+	}
+
+	if err := writeItemTags(db, email, item, tags); err != nil {
+		return err
+	}
+	return retag(db, email, item, previous, tags)
+}
+
+// retag indexes an item under the tags it gained and removes it from the tags it lost.
+// The item record itself is not changed. Counts only change for index records that
+// are written or removed, so running it again changes nothing.
+func retag(db *axon_coredb.DB, email string, item axon_types.TaggedItem, previous []string, tags []string) error {
+	had := map[string]bool{}
+	for _, tag := range previous {
+		had[tag] = true
+	}
+	has := map[string]bool{}
+	for _, tag := range tags {
+		has[tag] = true
+	}
+
+	key := taggedKey(item)
+	for _, tag := range previous {
+		if has[tag] {
+			continue
+		}
+		exists, err := isTagged(db, email, tag, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := db.DeleteRecord(axon_types.AXON_TABLE, taggedPartition(email, tag), &key); err != nil {
+			return err
+		}
+		if err := countTag(db, email, tag, item.Kind, -1); err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range tags {
+		if had[tag] {
+			continue
+		}
+		exists, err := isTagged(db, email, tag, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		indexed := item
+		indexed.Tag = tag
+		indexed.DateCreated = time.Now()
+		if err := db.MutateDatabase(axon_types.AXON_TABLE, taggedPartition(email, tag), key, indexed); err != nil {
+			return err
+		}
+		if err := countTag(db, email, tag, item.Kind, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isTagged reports whether the index of a tag holds the item with the key
+func isTagged(db *axon_coredb.DB, email string, tag string, key string) (bool, error) {
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, taggedPartition(email, tag), &key)
+	if err != nil {
+		return false, err
+	}
+	return len(result.Item) > 0, nil
+}
+
+func findTag(db *axon_coredb.DB, email string, name string) (*axon_types.Tag, error) {
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, tagPartition(email), &name)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Item) == 0 {
+		return nil, nil
+	}
+
+	var tag axon_types.Tag
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// countTag changes the count of notes or nodes of a catalogue entry with an atomic
+// add, creating it for its first item. It is removed when it has none left, unless an
+// item was tagged in the meantime.
+func countTag(db *axon_coredb.DB, email string, name string, kind string, delta int) error {
+	deltas := map[string]int{"notes": delta, "nodes": 0}
+	if kind == axon_types.TaggedKindNode {
+		deltas = map[string]int{"notes": 0, "nodes": delta}
+	}
+
+	now := time.Now()
+	item, err := db.IncrementRecord(axon_types.AXON_TABLE, tagPartition(email), name, deltas,
+		map[string]interface{}{"name": name, "last_edited": now},
+		map[string]interface{}{"date_created": now},
+	)
+	if err != nil {
+		return err
+	}
+
+	var tag axon_types.Tag
+	if err := dynamodbattribute.UnmarshalMap(item, &tag); err != nil {
+		return err
+	}
+	if tag.Notes > 0 || tag.Nodes > 0 {
+		return nil
+	}
+
+	_, err = db.DeleteRecordIf(axon_types.AXON_TABLE, tagPartition(email), name, "#notes <= :zero AND #nodes <= :zero",
+		map[string]string{"#notes": "notes", "#nodes": "nodes"},
+		map[string]interface{}{":zero": 0},
+	)
+	return err
+}
+
+// recountTag counts the items in the index of a tag and stores the catalogue entry
+func recountTag(db *axon_coredb.DB, email string, name string, created time.Time) (*axon_types.Tag, error) {
+	tag := axon_types.Tag{Name: name, DateCreated: created}
+
+	err := eachRecord(db, axon_types.AXON_TABLE, taggedPartition(email, name), func(item map[string]*dynamodb.AttributeValue) error {
+		if strings.HasPrefix(sortKey(item), axon_types.TaggedKindNode+"#") {
+			tag.Nodes++
+		} else {
+			tag.Notes++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putTag(db, email, tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func putTag(db *axon_coredb.DB, email string, tag axon_types.Tag) error {
+	if tag.Notes <= 0 && tag.Nodes <= 0 {
+		return db.DeleteRecord(axon_types.AXON_TABLE, tagPartition(email), &tag.Name)
+	}
+	tag.LastEdited = time.Now()
+	return db.MutateDatabase(axon_types.AXON_TABLE, tagPartition(email), tag.Name, tag)
+}
+
+// eachTaggedItem calls fn with the items of one kind in the index of a tag
+func eachTaggedItem(db *axon_coredb.DB, email string, tag string, kind string, fn func(item axon_types.TaggedItem) error) error {
+	var start map[string]*dynamodb.AttributeValue
+	for {
+		result, err := db.QueryDatabasePrefixPage(axon_types.AXON_TABLE, taggedPartition(email, tag), kind+"#", start)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range result.Items {
+			var item axon_types.TaggedItem
+			if err := dynamodbattribute.UnmarshalMap(record, &item); err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		start = result.LastEvaluatedKey
+	}
+}
+
+// moveTag replaces a tag with another on every item in its index, and moves the items
+// to the index of the other tag. The catalogue entry of the old tag is removed, the
+// caller counts the new one.
+func moveTag(db *axon_coredb.DB, email string, from string, to string) error {
+	partition := taggedPartition(email, from)
+
+	err := eachRecord(db, axon_types.AXON_TABLE, partition, func(record map[string]*dynamodb.AttributeValue) error {
+		var item axon_types.TaggedItem
+		if err := dynamodbattribute.UnmarshalMap(record, &item); err != nil {
+			return err
+		}
+
+		tags, found, err := itemTags(db, email, item)
+		if err != nil {
+			return err
+		}
+		if found {
+			replaced := []string{to}
+			for _, tag := range tags {
+				if tag != from && tag != to {
+					replaced = append(replaced, tag)
+				}
+			}
+			sort.Strings(replaced)
+			if err := writeItemTags(db, email, item, replaced); err != nil {
+				return err
+			}
+
+			item.Tag = to
+			if err := db.MutateDatabase(axon_types.AXON_TABLE, taggedPartition(email, to), taggedKey(item), item); err != nil {
+				return err
+			}
+		}
+
+		sort_key := sortKey(record)
+		return db.DeleteRecord(axon_types.AXON_TABLE, partition, &sort_key)
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.DeleteRecord(axon_types.AXON_TABLE, tagPartition(email), &from)
+}
+
+// itemTagSet is an item with the tags it has
+type itemTagSet struct {
+	item axon_types.TaggedItem
+	tags []string
+}
+
+// noteTags returns the tagged note and nodes of a note, by their keys in tag indexes
+func noteTags(db *axon_coredb.DB, email string, folder_id string, note_id string) (map[string]itemTagSet, error) {
+	tagged := map[string]itemTagSet{}
+
+	note := axon_types.TaggedItem{Kind: axon_types.TaggedKindNote, FolderID: folder_id, NoteID: note_id}
+	tags, _, err := itemTags(db, email, note)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		tagged[taggedKey(note)] = itemTagSet{item: note, tags: tags}
+	}
+
+	err = eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), func(record map[string]*dynamodb.AttributeValue) error {
+		var node axon_types.Node
+		if err := dynamodbattribute.UnmarshalMap(record, &node); err != nil {
+			return err
+		}
+		if len(node.Tags) == 0 {
+			return nil
+		}
+		item := axon_types.TaggedItem{Kind: axon_types.TaggedKindNode, FolderID: folder_id, NoteID: note_id, NodeID: sortKey(record)}
+		tagged[taggedKey(item)] = itemTagSet{item: item, tags: node.Tags}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tagged, nil
+}
+
+// retagNote moves the tag indexes of a note and its nodes from the tags in previous,
+// as returned by noteTags, to the tags they have now. Items of previous that are no
+// longer in the note are removed from the indexes.
+func retagNote(db *axon_coredb.DB, email string, previous map[string]itemTagSet, note axon_types.Note, nodes []axon_types.Node) error {
+	current := []itemTagSet{{
+		item: axon_types.TaggedItem{Kind: axon_types.TaggedKindNote, FolderID: note.FolderID, NoteID: note.NoteID},
+		tags: note.Tags,
+	}}
+	for _, node := range nodes {
+		current = append(current, itemTagSet{
+			item: axon_types.TaggedItem{Kind: axon_types.TaggedKindNode, FolderID: note.FolderID, NoteID: note.NoteID, NodeID: node.NodeID},
+			tags: node.Tags,
+		})
+	}
+
+	left := make(map[string]itemTagSet, len(previous))
+	for key, set := range previous {
+		left[key] = set
+	}
+
+	for _, set := range current {
+		key := taggedKey(set.item)
+		if err := retag(db, email, set.item, left[key].tags, set.tags); err != nil {
+			return err
+		}
+		delete(left, key)
+	}
+
+	for _, set := range left {
+		if err := retag(db, email, set.item, set.tags, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// untagNote removes a deleted note and the nodes in it from the indexes of their tags
+func untagNote(db *axon_coredb.DB, email string, folder_id string, note_id string, tags []string) error {
+	note := axon_types.TaggedItem{Kind: axon_types.TaggedKindNote, FolderID: folder_id, NoteID: note_id}
+	if err := retag(db, email, note, tags, nil); err != nil {
+		return err
+	}
+
+	return eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("NODE#%s#%s#%s", email, folder_id, note_id), func(record map[string]*dynamodb.AttributeValue) error {
+		var node axon_types.Node
+		if err := dynamodbattribute.UnmarshalMap(record, &node); err != nil {
+			return err
+		}
+		if len(node.Tags) == 0 {
+			return nil
+		}
+		item := axon_types.TaggedItem{Kind: axon_types.TaggedKindNode, FolderID: folder_id, NoteID: note_id, NodeID: sortKey(record)}
+		return retag(db, email, item, node.Tags, nil)
+	})
+}
+
+// untagFolder removes the notes and nodes of a deleted folder from the indexes of
+// their tags
+func untagFolder(db *axon_coredb.DB, email string, folder_id string) error {
+	return eachRecord(db, axon_types.AXON_TABLE, fmt.Sprintf("NOTE#%s#%s", email, folder_id), func(record map[string]*dynamodb.AttributeValue) error {
+		var note axon_types.Note
+		if err := dynamodbattribute.UnmarshalMap(record, &note); err != nil {
+			return err
+		}
+		return untagNote(db, email, folder_id, sortKey(record), note.Tags)
+	})
+}
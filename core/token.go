@@ -0,0 +1,285 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/google/uuid"
+	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// How often last_used is written back for a token that is in active use
+const tokenLastUsedInterval = time.Minute
+
+type Token struct {
+	Session axon_types.Session
+}
+
+type tokenLookup struct {
+	Email   string `json:"email"`
+	TokenId string `json:"token_id"`
+}
+
+type tokenLastUsed struct {
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Creates a new personal access token. The plaintext token is only returned here,
+// the database only holds its hash.
+func (t *Token) CreateToken(a *axon_types.AxonContext, token_name string, scopes []string, expires_at *time.Time) (*string, *axon_types.TokenList, error) {
+
+	if strings.TrimSpace(token_name) == "" {
+		return nil, nil, errors.New("could not create token - token name is required")
+	}
+
+	if len(scopes) == 0 {
+		return nil, nil, errors.New("could not create token - at least one scope is required")
+	}
+
+	for _, scope := range scopes {
+		if !ValidTokenScope(scope) {
+			return nil, nil, errors.New("could not create token - invalid scope " + scope)
+		}
+	}
+
+	// A token could otherwise mint one with more access than it has
+	if !HasScope(t.Session, axon_types.TokenScopeAdmin) {
+		return nil, nil, fmt.Errorf("could not create token - an admin token is required - %w", ErrForbidden)
+	}
+
+	if expires_at != nil && expires_at.Before(time.Now()) {
+		return nil, nil, errors.New("could not create token - expiry is in the past")
+	}
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, nil, errors.New("could not create token - " + err.Error())
+	}
+
+	secret, err := newSecret(axon_types.TOKEN_PREFIX)
+	if err != nil {
+		return nil, nil, errors.New("could not create token - " + err.Error())
+	}
+
+	// Create token object
+	token := axon_types.PersonalAccessToken{
+		TokenId:     uuid.New().String(),
+		UserId:      t.Session.SessionData.User.UserId,
+		Email:       t.Session.SessionData.User.Email,
+		TokenName:   token_name,
+		TokenHash:   hashSecret(secret),
+		Scopes:      scopes,
+		DateCreated: time.Now(),
+		ExpiresAt:   expires_at,
+	}
+
+	// Add token to database, with a lookup record keyed by the hash for authentication
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("TOKEN#%s", token.Email), token.TokenId, token)
+	if err != nil {
+		return nil, nil, errors.New("could not create token - " + err.Error())
+	}
+
+	lookup := tokenLookup{Email: token.Email, TokenId: token.TokenId}
+	err = db.MutateDatabase(axon_types.AXON_TABLE, fmt.Sprintf("TOKENHASH#%s", token.TokenHash), token.TokenHash, lookup)
+	if err != nil {
+		return nil, nil, errors.New("could not create token - " + err.Error())
+	}
+
+	tokenList := toTokenList(token)
+
+	return &secret, &tokenList, err
+}
+
+func (t *Token) GetTokens(a *axon_types.AxonContext) (*[]axon_types.TokenList, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not fetch tokens - " + err.Error())
+	}
+
+	result, err := db.QueryDatabasePartition(axon_types.AXON_TABLE, fmt.Sprintf("TOKEN#%s", t.Session.SessionData.User.Email))
+	if err != nil {
+		return nil, errors.New("could not fetch tokens - " + err.Error())
+	}
+
+	var tokens []axon_types.PersonalAccessToken
+
+	// Unmarshal the DynamoDB items into PersonalAccessToken structs
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &tokens); err != nil {
+		return nil, err
+	}
+
+	tokenList := make([]axon_types.TokenList, len(tokens))
+	for i, token := range tokens {
+		tokenList[i] = toTokenList(token)
+	}
+
+	return &tokenList, err
+}
+
+func (t *Token) RevokeToken(a *axon_types.AxonContext, token_id string) (*string, error) {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not revoke token - " + err.Error())
+	}
+
+	result, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("TOKEN#%s", t.Session.SessionData.User.Email), &token_id)
+	if err != nil {
+		return nil, errors.New("could not revoke token - " + err.Error())
+	}
+
+	if len(result.Item) == 0 {
+		return nil, errors.New("could not revoke token - token does not exist")
+	}
+
+	var token axon_types.PersonalAccessToken
+
+	// Unmarshal the DynamoDB item into a PersonalAccessToken struct
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &token); err != nil {
+		return nil, err
+	}
+
+	// The token record is the source of truth, so remove it first
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("TOKEN#%s", token.Email), &token.TokenId)
+	if err != nil {
+		return nil, errors.New("could not revoke token - " + err.Error())
+	}
+
+	err = db.DeleteRecord(axon_types.AXON_TABLE, fmt.Sprintf("TOKENHASH#%s", token.TokenHash), &token.TokenHash)
+	if err != nil {
+		return nil, errors.New("could not revoke token - " + err.Error())
+	}
+
+	return &token_id, err
+}
+
+// AuthenticateToken resolves a bearer token to a session for its owner. Expired or
+// revoked tokens are rejected, and last_used is refreshed at most once a minute.
+func AuthenticateToken(a *axon_types.AxonContext, secret string) (*axon_types.Session, error) {
+
+	if !strings.HasPrefix(secret, axon_types.TOKEN_PREFIX) {
+		return nil, errors.New("invalid token")
+	}
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return nil, errors.New("could not authenticate token - " + err.Error())
+	}
+
+	hash := hashSecret(secret)
+
+	lookupResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("TOKENHASH#%s", hash), &hash)
+	if err != nil {
+		return nil, errors.New("could not authenticate token - " + err.Error())
+	}
+
+	if len(lookupResult.Item) == 0 {
+		return nil, errors.New("invalid token")
+	}
+
+	var lookup tokenLookup
+	if err := dynamodbattribute.UnmarshalMap(lookupResult.Item, &lookup); err != nil {
+		return nil, err
+	}
+
+	tokenResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("TOKEN#%s", lookup.Email), &lookup.TokenId)
+	if err != nil {
+		return nil, errors.New("could not authenticate token - " + err.Error())
+	}
+
+	if len(tokenResult.Item) == 0 {
+		return nil, errors.New("invalid token")
+	}
+
+	var token axon_types.PersonalAccessToken
+	if err := dynamodbattribute.UnmarshalMap(tokenResult.Item, &token); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if token.TokenHash != hash {
+		return nil, errors.New("invalid token")
+	}
+
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, errors.New("token has expired")
+	}
+
+	userResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("USER#%s", token.Email), &token.Email)
+	if err != nil {
+		return nil, errors.New("could not authenticate token - " + err.Error())
+	}
+
+	if len(userResult.Item) == 0 {
+		return nil, errors.New("invalid token")
+	}
+
+	var user axon_types.User
+	if err := dynamodbattribute.UnmarshalMap(userResult.Item, &user); err != nil {
+		return nil, err
+	}
+
+	if token.LastUsed == nil || now.Sub(*token.LastUsed) > tokenLastUsedInterval {
+		// Failing to record usage should not fail the request
+		db.UpdateRecord(axon_types.AXON_TABLE, fmt.Sprintf("TOKEN#%s", token.Email), token.TokenId, tokenLastUsed{LastUsed: now})
+	}
+
+	session := axon_types.Session{
+		SessionId: fmt.Sprintf("TOKEN#%s", token.TokenId),
+		SessionData: axon_types.UserCache{
+			User: user,
+		},
+		Scopes: token.Scopes,
+	}
+
+	return &session, nil
+}
+
+func ValidTokenScope(scope string) bool {
+	switch scope {
+	case axon_types.TokenScopeRead, axon_types.TokenScopeWrite, axon_types.TokenScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// HasScope reports whether a session may act with the given scope. Cookie sessions carry
+// no scopes and have full access, admin implies write and write implies read.
+func HasScope(session axon_types.Session, scope string) bool {
+	if session.Scopes == nil {
+		return true
+	}
+
+	for _, s := range session.Scopes {
+		switch {
+		case s == scope:
+			return true
+		case s == axon_types.TokenScopeAdmin:
+			return true
+		case s == axon_types.TokenScopeWrite && scope == axon_types.TokenScopeRead:
+			return true
+		}
+	}
+	return false
+}
+
+func toTokenList(token axon_types.PersonalAccessToken) axon_types.TokenList {
+	return axon_types.TokenList{
+		TokenId:     token.TokenId,
+		TokenName:   token.TokenName,
+		Scopes:      token.Scopes,
+		DateCreated: token.DateCreated,
+		ExpiresAt:   token.ExpiresAt,
+		LastUsed:    token.LastUsed,
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 
 	"github.com/google/uuid"
 	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_keyring "github.com/stephensanwo/axon-lib/keyring"
 	github "github.com/stephensanwo/axon-lib/github"
 	axon_types "github.com/stephensanwo/axon-lib/types"
 	"golang.org/x/oauth2"
@@ -89,6 +92,105 @@ func (u *User) GetAuthenticatedUserData(a *axon_types.AxonContext) (axon_types.S
 	// Unmarshal the DynamoDB item into a Session struct
 	dynamodbattribute.UnmarshalMap(result.Item, &userSession)
 
-	return userSession, err
+	if len(result.Item) == 0 {
+		return userSession, err
+	}
+
+	// A session outlives a deleted account when it was not listed under its user, it
+	// ends with the account
+	email := userSession.SessionData.User.Email
+	userResult, err := db.QueryDatabase(axon_types.AXON_TABLE, fmt.Sprintf("USER#%s", email), &email)
+	if err != nil {
+		return axon_types.Session{}, errors.New("Error fetching user session" + err.Error())
+	}
+
+	if len(userResult.Item) == 0 {
+		err = db.DeleteRecord(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", a.SessionId), &a.SessionId)
+		return axon_types.Session{}, err
+	}
+
+	// Decrypt the sealed OAuth token
+	keyRing, err := axon_keyring.New(a.Settings.SecuritySettings)
+	if err != nil {
+		return axon_types.Session{}, errors.New("Error fetching user session" + err.Error())
+	}
+
+	reseal, err := keyRing.OpenSession(&userSession)
+	if err != nil {
+		return axon_types.Session{}, errors.New("Error fetching user session" + err.Error())
+	}
+
+	// The session was sealed with a retired key, or holds a plaintext token, store it
+	// again sealed with the active key
+	if reseal {
+		sealedSession, err := keyRing.SealSession(userSession)
+		if err != nil {
+			return axon_types.Session{}, errors.New("Error fetching user session" + err.Error())
+		}
+
+		err = db.CacheData(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", a.SessionId), a.SessionId, sealedSession, sessionTTL(result.Item))
+		if err != nil {
+			return axon_types.Session{}, errors.New("Error fetching user session" + err.Error())
+		}
+	}
+
+	return userSession, nil
+
+}
+
+// Keep the ttl of a session when it is written back
+func sessionTTL(item map[string]*dynamodb.AttributeValue) int64 {
+	if ttl, ok := item["ttl"]; ok && ttl.N != nil {
+		if value, err := strconv.ParseInt(*ttl.N, 10, 64); err == nil {
+			return value
+		}
+	}
+	return axon_types.SESSION_TTL
+}
+
+// Seals and writes a session back to the cache, keeping the ttl of the stored session
+func (u *User) UpdateSession(a *axon_types.AxonContext, session axon_types.Session) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return errors.New("could not update user session - " + err.Error())
+	}
+
+	result, err := db.QueryDatabase(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", session.SessionId), &session.SessionId)
+	if err != nil {
+		return errors.New("could not update user session - " + err.Error())
+	}
+
+	if len(result.Item) == 0 {
+		return errors.New("could not update user session - session does not exist")
+	}
+
+	keyRing, err := axon_keyring.New(a.Settings.SecuritySettings)
+	if err != nil {
+		return errors.New("could not update user session - " + err.Error())
+	}
+
+	sealedSession, err := keyRing.SealSession(session)
+	if err != nil {
+		return errors.New("could not update user session - " + err.Error())
+	}
+
+	return db.CacheData(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", session.SessionId), session.SessionId, sealedSession, sessionTTL(result.Item))
+}
+
+func (u *User) DeleteSession(a *axon_types.AxonContext, session_id string) error {
+
+	// Create the DynamoDB client
+	db, err := axon_coredb.NewDb()
+	if err != nil {
+		return errors.New("could not delete user session - " + err.Error())
+	}
+
+	err = db.DeleteRecord(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", session_id), &session_id)
+	if err != nil {
+		return errors.New("could not delete user session - " + err.Error())
+	}
 
+	return nil
 }
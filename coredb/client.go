@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	aws_session "github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -56,6 +57,96 @@ func (c DB) QueryDatabasePartition(table_name string, partition_key string) (*dy
 	return result, nil
 }
 
+// QueryDatabasePartitionPage reads one page of a partition from the table itself, so
+// records without a date_created are included. Pass the LastEvaluatedKey of a page to
+// read the next one, nil for the first. The last page has no LastEvaluatedKey.
+func (c DB) QueryDatabasePartitionPage(table_name string, partition_key string, start_key map[string]*dynamodb.AttributeValue) (*dynamodb.QueryOutput, error) {
+	input := &dynamodb.QueryInput{
+		TableName: jsii.String(table_name),
+		KeyConditions: map[string]*dynamodb.Condition{
+			"partition_key": {
+				ComparisonOperator: jsii.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{
+						S: jsii.String(partition_key),
+					},
+				},
+			},
+		},
+		ExclusiveStartKey: start_key,
+	}
+
+	result, err := c.Client.Query(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// QueryDatabasePrefixPage reads one page of the records of a partition whose sort key
+// starts with prefix, paged like QueryDatabasePartitionPage
+func (c DB) QueryDatabasePrefixPage(table_name string, partition_key string, prefix string, start_key map[string]*dynamodb.AttributeValue) (*dynamodb.QueryOutput, error) {
+	input := &dynamodb.QueryInput{
+		TableName: jsii.String(table_name),
+		KeyConditions: map[string]*dynamodb.Condition{
+			"partition_key": {
+				ComparisonOperator: jsii.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{
+						S: jsii.String(partition_key),
+					},
+				},
+			},
+			"sort_key": {
+				ComparisonOperator: jsii.String("BEGINS_WITH"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{
+						S: jsii.String(prefix),
+					},
+				},
+			},
+		},
+		ExclusiveStartKey: start_key,
+	}
+
+	result, err := c.Client.Query(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ScanDatabasePrefixPage reads one page of a scan of the whole table for the records
+// whose partition key starts with any of prefixes, with only their keys. It is for
+// finding partitions that nothing else points to, a scan reads every record. A page
+// can be empty and still have a LastEvaluatedKey.
+func (c DB) ScanDatabasePrefixPage(table_name string, prefixes []string, start_key map[string]*dynamodb.AttributeValue) (*dynamodb.ScanOutput, error) {
+	conditions := make([]string, len(prefixes))
+	values := map[string]*dynamodb.AttributeValue{}
+	for i, prefix := range prefixes {
+		name := fmt.Sprintf(":prefix%d", i)
+		conditions[i] = fmt.Sprintf("begins_with(partition_key, %s)", name)
+		values[name] = &dynamodb.AttributeValue{S: jsii.String(prefix)}
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 jsii.String(table_name),
+		FilterExpression:          jsii.String(strings.Join(conditions, " OR ")),
+		ExpressionAttributeValues: values,
+		ProjectionExpression:      jsii.String("partition_key, sort_key"),
+		ExclusiveStartKey:         start_key,
+	}
+
+	result, err := c.Client.Scan(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (c DB) QueryDatabase(table_name string, partition_key string, sort_key *string) (*dynamodb.GetItemOutput, error) {
 	// Interface to query the database
 	input := &dynamodb.GetItemInput{
@@ -181,22 +272,36 @@ func (c DB) DeleteRecord(table_name string, partition_key string, sort_key *stri
 
 func (c DB) UpdateRecord(table_name string, partition_key string, sort_key string, attributes interface{}) error {
 	
-	// Convert the interface to a map[string]*dynamodb.AttributeValue
-	attrs, err := dynamodbattribute.MarshalMap(attributes)
-	if err != nil {
-		return errors.New("failed to convert attributes to DynamoDB format - " + err.Error())
+	// Convert the interface to a map[string]*dynamodb.AttributeValue, unless it already is one
+	attrs, ok := attributes.(map[string]*dynamodb.AttributeValue)
+	if !ok {
+		var err error
+		attrs, err = dynamodbattribute.MarshalMap(attributes)
+		if err != nil {
+			return errors.New("failed to convert attributes to DynamoDB format - " + err.Error())
+		}
 	}
 
 	if len(attrs) == 0 {
 		return errors.New("attributes cannot be empty")
 	}
 
-	// Create the update expression for SET
+	// Create the update expression for SET. Names are sent as placeholders, so reserved
+	// words and nested paths such as data.label can be updated
 	updateExpression := "SET "
+	expressionAttributeNames := make(map[string]*string)
 	expressionAttributeValues := make(map[string]*dynamodb.AttributeValue)
+	index := 0
 	for attributeName, attributeValue := range attrs {
-		updateExpression += fmt.Sprintf("%s = :%s, ", attributeName, attributeName)
-		expressionAttributeValues[fmt.Sprintf(":%s", attributeName)] = attributeValue
+		path := []string{}
+		for part, name := range strings.Split(attributeName, ".") {
+			placeholder := fmt.Sprintf("#a%d_%d", index, part)
+			expressionAttributeNames[placeholder] = jsii.String(name)
+			path = append(path, placeholder)
+		}
+		updateExpression += fmt.Sprintf("%s = :v%d, ", strings.Join(path, "."), index)
+		expressionAttributeValues[fmt.Sprintf(":v%d", index)] = attributeValue
+		index++
 	}
 
 	// Remove the trailing comma and space
@@ -214,14 +319,135 @@ func (c DB) UpdateRecord(table_name string, partition_key string, sort_key strin
 			},
 		},
 		UpdateExpression:          jsii.String(updateExpression),
+		ExpressionAttributeNames:  expressionAttributeNames,
 		ExpressionAttributeValues: expressionAttributeValues,
 	}
 
 
-	_, err = c.Client.UpdateItem(input)
+	_, err := c.Client.UpdateItem(input)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+
+// IncrementRecord adds each delta to its number attribute with an atomic ADD, so
+// concurrent increments are not lost. A missing record or attribute starts from 0. The
+// attributes in set are written with the increment, and those in initial only when the
+// record does not have them yet. It returns the record as it is after the update.
+func (c DB) IncrementRecord(table_name string, partition_key string, sort_key string, deltas map[string]int, set map[string]interface{}, initial map[string]interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	if len(deltas) == 0 {
+		return nil, errors.New("deltas cannot be empty")
+	}
+
+	names := make(map[string]*string)
+	values := make(map[string]*dynamodb.AttributeValue)
+	index := 0
+	placeholders := func(name string, value *dynamodb.AttributeValue) (string, string) {
+		name_placeholder, value_placeholder := fmt.Sprintf("#a%d", index), fmt.Sprintf(":v%d", index)
+		names[name_placeholder] = jsii.String(name)
+		values[value_placeholder] = value
+		index++
+		return name_placeholder, value_placeholder
+	}
+
+	adds := []string{}
+	for attributeName, delta := range deltas {
+		name, value := placeholders(attributeName, &dynamodb.AttributeValue{N: jsii.String(strconv.Itoa(delta))})
+		adds = append(adds, name+" "+value)
+	}
+
+	sets := []string{}
+	for _, attributes := range []map[string]interface{}{set, initial} {
+		for attributeName, attribute := range attributes {
+			marshalled, err := dynamodbattribute.Marshal(attribute)
+			if err != nil {
+				return nil, errors.New("failed to convert attributes to DynamoDB format - " + err.Error())
+			}
+			name, value := placeholders(attributeName, marshalled)
+			if _, ok := initial[attributeName]; ok {
+				sets = append(sets, fmt.Sprintf("%s = if_not_exists(%s, %s)", name, name, value))
+			} else {
+				sets = append(sets, fmt.Sprintf("%s = %s", name, value))
+			}
+		}
+	}
+
+	updateExpression := "ADD " + strings.Join(adds, ", ")
+	if len(sets) > 0 {
+		updateExpression += " SET " + strings.Join(sets, ", ")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: jsii.String(table_name),
+		Key: map[string]*dynamodb.AttributeValue{
+			"partition_key": {
+				S: jsii.String(partition_key),
+			},
+			"sort_key": {
+				S: jsii.String(sort_key),
+			},
+		},
+		UpdateExpression:          jsii.String(updateExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              jsii.String(dynamodb.ReturnValueAllNew),
+	}
+
+	result, err := c.Client.UpdateItem(input)
+	if err != nil {
+		return nil, err
+	}
+	return result.Attributes, nil
+}
+
+// DeleteRecordIf deletes a record only when the condition holds, and reports whether
+// it did. Names and values are the placeholders the condition uses, such as #count and
+// :zero.
+func (c DB) DeleteRecordIf(table_name string, partition_key string, sort_key string, condition string, names map[string]string, values map[string]interface{}) (bool, error) {
+	expressionAttributeNames := make(map[string]*string)
+	for placeholder, name := range names {
+		expressionAttributeNames[placeholder] = jsii.String(name)
+	}
+
+	expressionAttributeValues := make(map[string]*dynamodb.AttributeValue)
+	for placeholder, value := range values {
+		marshalled, err := dynamodbattribute.Marshal(value)
+		if err != nil {
+			return false, errors.New("failed to convert condition values to DynamoDB format - " + err.Error())
+		}
+		expressionAttributeValues[placeholder] = marshalled
+	}
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: jsii.String(table_name),
+		Key: map[string]*dynamodb.AttributeValue{
+			"partition_key": {
+				S: jsii.String(partition_key),
+			},
+			"sort_key": {
+				S: jsii.String(sort_key),
+			},
+		},
+		ConditionExpression: jsii.String(condition),
+	}
+
+	// DynamoDB rejects empty placeholder maps
+	if len(expressionAttributeNames) > 0 {
+		input.ExpressionAttributeNames = expressionAttributeNames
+	}
+	if len(expressionAttributeValues) > 0 {
+		input.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	_, err := c.Client.DeleteItem(input)
+	if err != nil {
+		var failed *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
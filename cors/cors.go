@@ -0,0 +1,298 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+var defaultMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// Cors is a CORS middleware built from HttpSettings. Allowed origins are exact
+// origins such as https://axon.app, "*" for any origin, or a wildcard subdomain
+// such as https://*.axon.app, which matches any subdomain but not axon.app itself.
+type Cors struct {
+	current atomic.Pointer[policy]
+}
+
+// policy is an immutable, validated set of settings, swapped as a whole on Update
+type policy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	subdomains       []subdomainOrigin
+	methods          []string
+	anyHeader        bool
+	headers          map[string]bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           int
+}
+
+type subdomainOrigin struct {
+	scheme string
+	suffix string
+	port   string
+}
+
+// New validates the settings and builds the middleware. Allowing credentials for
+// any origin is refused, as browsers reject it and echoing origins would be unsafe.
+func New(settings axon_types.HttpSettings) (*Cors, error) {
+	p, err := newPolicy(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cors{}
+	c.current.Store(p)
+	return c, nil
+}
+
+// Update validates new settings and applies them to subsequent requests. Invalid
+// settings are rejected and the current policy is kept.
+func (c *Cors) Update(settings axon_types.HttpSettings) error {
+	p, err := newPolicy(settings)
+	if err != nil {
+		return err
+	}
+
+	c.current.Store(p)
+	return nil
+}
+
+func newPolicy(settings axon_types.HttpSettings) (*policy, error) {
+	c := &policy{
+		origins:          map[string]bool{},
+		headers:          map[string]bool{},
+		exposedHeaders:   strings.Join(settings.ExposedHeaders, ", "),
+		allowCredentials: settings.AllowCredentials,
+		maxAge:           settings.MaxAge,
+	}
+
+	for _, origin := range settings.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			subdomain, err := parseSubdomainOrigin(origin)
+			if err != nil {
+				return nil, err
+			}
+			c.subdomains = append(c.subdomains, *subdomain)
+		default:
+			u, err := parseOrigin(origin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed origin %q - %s", origin, err.Error())
+			}
+			c.origins[u.Scheme+"://"+u.Host] = true
+		}
+	}
+
+	if c.anyOrigin && c.allowCredentials {
+		return nil, errors.New("cors: allow_credentials cannot be used with the \"*\" allowed origin")
+	}
+
+	if c.maxAge < 0 {
+		return nil, errors.New("cors: max_age cannot be negative")
+	}
+
+	c.methods = defaultMethods
+	if len(settings.AllowedMethods) > 0 {
+		c.methods = make([]string, len(settings.AllowedMethods))
+		for i, method := range settings.AllowedMethods {
+			c.methods[i] = strings.ToUpper(strings.TrimSpace(method))
+		}
+	}
+
+	for _, header := range settings.AllowedHeaders {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers[header] = true
+	}
+
+	return c, nil
+}
+
+func (c *Cors) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the policy once, so a request is handled under a single version
+		p := c.current.Load()
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			p.preflight(w, r)
+			return
+		}
+
+		p.actual(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *policy) preflight(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !c.originAllowed(origin) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !c.methodAllowed(method) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	requestHeaders := parseHeaderList(r.Header.Values("Access-Control-Request-Headers"))
+	if !c.headersAllowed(requestHeaders) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setAllowOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	if len(requestHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+	}
+	if c.maxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(c.maxAge))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *policy) actual(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !c.originAllowed(origin) {
+		return
+	}
+
+	c.setAllowOrigin(header, origin)
+	if c.exposedHeaders != "" {
+		header.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+}
+
+func (c *policy) setAllowOrigin(header http.Header, origin string) {
+	if c.anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	header.Set("Access-Control-Allow-Origin", origin)
+	if c.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *policy) originAllowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+
+	if len(c.subdomains) == 0 {
+		return false
+	}
+
+	u, err := parseOrigin(origin)
+	if err != nil {
+		return false
+	}
+
+	for _, subdomain := range c.subdomains {
+		if u.Scheme == subdomain.scheme && u.Port() == subdomain.port && strings.HasSuffix(u.Hostname(), subdomain.suffix) && len(u.Hostname()) > len(subdomain.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *policy) methodAllowed(method string) bool {
+	for _, allowed := range c.methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Without credentials "*" allows any request header. With credentials browsers treat
+// "*" literally, so requested headers must be listed.
+func (c *policy) headersAllowed(headers []string) bool {
+	if c.anyHeader && !c.allowCredentials {
+		return true
+	}
+
+	for _, header := range headers {
+		if !c.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+func parseOrigin(origin string) (*url.URL, error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("origin must be scheme://host[:port]")
+	}
+
+	return u, nil
+}
+
+func parseSubdomainOrigin(origin string) (*subdomainOrigin, error) {
+	scheme, rest, found := strings.Cut(origin, "://")
+	if !found || !strings.HasPrefix(rest, "*.") || strings.Count(rest, "*") != 1 {
+		return nil, fmt.Errorf("invalid allowed origin %q - wildcards are only supported as scheme://*.domain", origin)
+	}
+
+	u, err := parseOrigin(scheme + "://" + strings.TrimPrefix(rest, "*."))
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed origin %q - %s", origin, err.Error())
+	}
+
+	return &subdomainOrigin{
+		scheme: u.Scheme,
+		suffix: "." + u.Hostname(),
+		port:   u.Port(),
+	}, nil
+}
+
+func parseHeaderList(values []string) []string {
+	headers := []string{}
+	for _, value := range values {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if header != "" {
+				headers = append(headers, strings.ToLower(header))
+			}
+		}
+	}
+	return headers
+}
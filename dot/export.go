@@ -0,0 +1,218 @@
+package dot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Prefix of the subgraph IDs group nodes are written as, so Graphviz draws a box
+const clusterPrefix = "cluster_"
+
+// Written when the category cannot be told from the shape, so it survives a round trip
+const categoryAttribute = "axon_category"
+
+type attribute struct {
+	name  string
+	value string
+}
+
+// Export writes a note as a DOT digraph. Node IDs are kept as DOT IDs, positions are
+// written as pinned pos attributes with the y axis pointing up as in Graphviz, and
+// group nodes become clusters around their members. Edges to group nodes and to nodes
+// that are not in the note are left out, as DOT cannot draw them.
+func Export(note axon_types.NoteDetail) (string, error) {
+	nodes := append([]axon_types.Node{}, note.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		if a.Position.X != b.Position.X {
+			return a.Position.X < b.Position.X
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	groups := map[string]bool{}
+	for _, node := range nodes {
+		if node.Data.NodeCategory == axon_types.NODE_CATEGORY_GROUP {
+			groups[node.NodeID] = true
+		}
+	}
+
+	// Members of each group, in the sorted order. A parent that is not a group node of
+	// the note puts the node at the top level.
+	children := map[string][]axon_types.Node{}
+	for _, node := range nodes {
+		parent := node.ParentID
+		if !groups[parent] || parent == node.NodeID {
+			parent = ""
+		}
+		children[parent] = append(children[parent], node)
+	}
+
+	name := note.NoteName
+	if name == "" {
+		name = note.NoteID
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quote(name))
+	fmt.Fprintf(&b, "    node [shape=%s];\n", defaultShape)
+
+	written := map[string]bool{}
+	var writeNodes func(parent string, indent string)
+	writeNodes = func(parent string, indent string) {
+		for _, node := range children[parent] {
+			if written[node.NodeID] {
+				continue
+			}
+			written[node.NodeID] = true
+
+			if !groups[node.NodeID] {
+				fmt.Fprintf(&b, "%s%s [%s];\n", indent, quote(node.NodeID), formatAttributes(nodeAttributes(node)))
+				continue
+			}
+
+			fmt.Fprintf(&b, "%ssubgraph %s {\n", indent, quote(clusterPrefix+node.NodeID))
+			for _, a := range clusterAttributes(node) {
+				fmt.Fprintf(&b, "%s    %s=%s;\n", indent, a.name, quote(a.value))
+			}
+			writeNodes(node.NodeID, indent+"    ")
+			fmt.Fprintf(&b, "%s}\n", indent)
+		}
+	}
+	writeNodes("", "    ")
+
+	// Groups whose parents form a cycle are not reached from the top level
+	children[""] = nil
+	for _, node := range nodes {
+		if !written[node.NodeID] {
+			children[""] = append(children[""], node)
+		}
+	}
+	writeNodes("", "    ")
+
+	edges := append([]axon_types.Edge{}, note.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.SourceID != b.SourceID {
+			return a.SourceID < b.SourceID
+		}
+		if a.TargetID != b.TargetID {
+			return a.TargetID < b.TargetID
+		}
+		return a.EdgeID < b.EdgeID
+	})
+
+	for _, edge := range edges {
+		if !written[edge.SourceID] || !written[edge.TargetID] || groups[edge.SourceID] || groups[edge.TargetID] {
+			continue
+		}
+
+		attributes := []attribute{}
+		if edge.Label != "" {
+			attributes = append(attributes, attribute{"label", edge.Label})
+		}
+		if edge.Animated {
+			attributes = append(attributes, attribute{"style", "dashed"})
+		}
+
+		line := fmt.Sprintf("    %s -> %s", quote(edge.SourceID), quote(edge.TargetID))
+		if len(attributes) > 0 {
+			line += fmt.Sprintf(" [%s]", formatAttributes(attributes))
+		}
+		b.WriteString(line + ";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+func nodeAttributes(node axon_types.Node) []attribute {
+	attributes := []attribute{{"label", nodeText(node)}}
+
+	category := node.Data.NodeCategory
+	shape := ShapeOf(category)
+	if shape != defaultShape {
+		attributes = append(attributes, attribute{"shape", shape})
+	}
+
+	styles := []string{}
+	if strings.EqualFold(category, "rounded") {
+		styles = append(styles, "rounded")
+	}
+	background := axon_types.StyleString(node.Styles.BackgroundStyles, axon_types.STYLE_BACKGROUND_COLOR)
+	if background != "" {
+		styles = append(styles, "filled")
+	}
+	if len(styles) > 0 {
+		attributes = append(attributes, attribute{"style", strings.Join(styles, ",")})
+	}
+
+	attributes = append(attributes, colorAttributes(node, "fillcolor", "color")...)
+
+	if node.Data.Description != "" {
+		attributes = append(attributes, attribute{"tooltip", node.Data.Description})
+	}
+	if category != "" && CategoryOf(shape, strings.Join(styles, ",")) != strings.ToLower(category) {
+		attributes = append(attributes, attribute{categoryAttribute, category})
+	}
+
+	attributes = append(attributes, attribute{"pos", fmt.Sprintf("%d,%d!", node.Position.X, -node.Position.Y)})
+	return attributes
+}
+
+func clusterAttributes(node axon_types.Node) []attribute {
+	attributes := []attribute{{"label", nodeText(node)}}
+	if axon_types.StyleString(node.Styles.BackgroundStyles, axon_types.STYLE_BACKGROUND_COLOR) != "" {
+		attributes = append(attributes, attribute{"style", "filled"})
+	}
+	return append(attributes, colorAttributes(node, "fillcolor", "pencolor")...)
+}
+
+// colorAttributes maps the background, border and text colours of a node
+func colorAttributes(node axon_types.Node, fill string, border string) []attribute {
+	attributes := []attribute{}
+	for _, color := range []struct {
+		name  string
+		value string
+	}{
+		{fill, axon_types.StyleString(node.Styles.BackgroundStyles, axon_types.STYLE_BACKGROUND_COLOR)},
+		{border, axon_types.StyleString(node.Styles.BackgroundStyles, axon_types.STYLE_BORDER_COLOR)},
+		{"fontcolor", axon_types.StyleString(node.Styles.LabelStyles, axon_types.STYLE_COLOR)},
+	} {
+		if color.value != "" {
+			attributes = append(attributes, attribute{color.name, color.value})
+		}
+	}
+	return attributes
+}
+
+func formatAttributes(attributes []attribute) string {
+	formatted := make([]string, len(attributes))
+	for i, a := range attributes {
+		formatted[i] = a.name + "=" + quote(a.value)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func nodeText(node axon_types.Node) string {
+	for _, text := range []string{node.Data.Title, node.Data.Label} {
+		if strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// quote writes a DOT string. Backslashes are doubled, as Graphviz reads them as label
+// escapes, and line breaks become \n.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(s) + `"`
+}
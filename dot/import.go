@@ -0,0 +1,262 @@
+package dot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	axon_layout "github.com/stephensanwo/axon-lib/layout"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type ImportOptions struct {
+	// Top left corner of the imported graph, to place it clear of existing nodes
+	Origin axon_types.Position
+}
+
+// ImportResult holds the nodes and edges of a graph, with new IDs, ready to be added
+// to a note
+type ImportResult struct {
+	Nodes    []axon_types.Node
+	Edges    []axon_types.Edge
+	Problems []Problem
+}
+
+// rankdir values mapped to layout directions
+var rankDirections = map[string]string{
+	"TB": axon_layout.DirectionTopDown,
+	"LR": axon_layout.DirectionLeftRight,
+	"BT": axon_layout.DirectionBottomUp,
+	"RL": axon_layout.DirectionRightLeft,
+}
+
+// Import parses a DOT graph into nodes and edges. When every node has a pos attribute
+// the positions are kept, moved to start at the origin, otherwise the graph is laid
+// out in layers following its rankdir. Clusters become group nodes.
+func Import(text string, options ImportOptions) (*ImportResult, error) {
+	graph, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{}
+	for _, node := range graph.Nodes {
+		ids[node.ID] = uuid.New().String()
+	}
+	clusterIds := map[string]string{}
+	for _, cluster := range graph.Clusters {
+		clusterIds[cluster.ID] = uuid.New().String()
+	}
+
+	shape := axon_layout.Graph{Direction: rankDirections[strings.ToUpper(graph.Attributes["rankdir"])]}
+	for _, node := range graph.Nodes {
+		shape.Nodes = append(shape.Nodes, axon_layout.Node{ID: node.ID, Group: node.Cluster})
+	}
+	for _, edge := range graph.Edges {
+		shape.Edges = append(shape.Edges, axon_layout.Edge{From: edge.From, To: edge.To})
+	}
+	for _, cluster := range graph.Clusters {
+		shape.Groups = append(shape.Groups, axon_layout.Group{ID: cluster.ID, Parent: cluster.Parent})
+	}
+
+	positions, problem := pinnedPositions(graph, options.Origin)
+	if positions == nil {
+		positions = axon_layout.Layered(shape, options.Origin)
+	}
+	if problem != nil {
+		graph.Problems = append(graph.Problems, *problem)
+	}
+	boxes := axon_layout.Boxes(shape, positions, options.Origin)
+
+	result := &ImportResult{
+		Nodes:    make([]axon_types.Node, 0, len(graph.Nodes)+len(graph.Clusters)),
+		Edges:    make([]axon_types.Edge, 0, len(graph.Edges)),
+		Problems: graph.Problems,
+	}
+
+	for _, node := range graph.Nodes {
+		attributes := node.Attributes
+
+		label, ok := attributes["label"]
+		if !ok {
+			label = `\N`
+		}
+
+		category := attributes[categoryAttribute]
+		if category == "" {
+			category = CategoryOf(attributes["shape"], attributes["style"])
+		}
+
+		fill := attributes["fillcolor"]
+		if fill == "" && hasStyle(attributes["style"], "filled") {
+			fill = attributes["color"]
+		}
+
+		result.Nodes = append(result.Nodes, axon_types.Node{
+			NodeID: ids[node.ID],
+			Data: axon_types.NodeData{
+				Title:        unescapeLabel(label, node.ID, graph.Name),
+				Description:  unescapeLabel(attributes["tooltip"], node.ID, graph.Name),
+				NodeCategory: category,
+			},
+			Position: positions[node.ID],
+			Styles:   colorStyles(axon_types.NodeStyles{}, fill, attributes["color"], attributes["fontcolor"]),
+			ParentID: clusterIds[node.Cluster],
+		})
+	}
+
+	for _, cluster := range graph.Clusters {
+		attributes := cluster.Attributes
+		box := boxes[cluster.ID]
+
+		label, ok := attributes["label"]
+		if !ok {
+			label = strings.TrimLeft(strings.TrimPrefix(cluster.ID, "cluster"), "_")
+		}
+
+		fill := attributes["fillcolor"]
+		if fill == "" {
+			fill = attributes["bgcolor"]
+		}
+		border := attributes["pencolor"]
+		if border == "" {
+			border = attributes["color"]
+		}
+
+		result.Nodes = append(result.Nodes, axon_types.Node{
+			NodeID: clusterIds[cluster.ID],
+			Data: axon_types.NodeData{
+				Title:        unescapeLabel(label, cluster.ID, graph.Name),
+				NodeCategory: axon_types.NODE_CATEGORY_GROUP,
+			},
+			Position: axon_types.Position{X: box.X, Y: box.Y},
+			Styles:   colorStyles(axon_layout.GroupStyles(box), fill, border, attributes["fontcolor"]),
+			ParentID: clusterIds[cluster.Parent],
+		})
+	}
+
+	for _, edge := range graph.Edges {
+		style := edge.Attributes["style"]
+		result.Edges = append(result.Edges, axon_types.Edge{
+			EdgeID:   uuid.New().String(),
+			SourceID: ids[edge.From],
+			TargetID: ids[edge.To],
+			Label:    unescapeLabel(edge.Attributes["label"], "", graph.Name),
+			Animated: hasStyle(style, "dashed") || hasStyle(style, "dotted"),
+		})
+	}
+
+	return result, nil
+}
+
+// pinnedPositions reads the pos attribute of every node, flipping the y axis, and
+// moves the positions so the top left node is at origin. It returns nil, and a problem
+// when only some nodes have a position, if the graph has to be laid out instead.
+func pinnedPositions(graph *Graph, origin axon_types.Position) (map[string]axon_types.Position, *Problem) {
+	positions := make(map[string]axon_types.Position, len(graph.Nodes))
+	minX, minY := math.MaxInt, math.MaxInt
+	var missing *GraphNode
+
+	for i, node := range graph.Nodes {
+		pos, ok := node.Attributes["pos"]
+		if !ok {
+			if missing == nil {
+				missing = &graph.Nodes[i]
+			}
+			continue
+		}
+
+		position, err := parsePos(pos)
+		if err != nil {
+			return nil, &Problem{Line: node.Line, Message: fmt.Sprintf("node %s has an invalid pos %q, the graph is laid out automatically", node.ID, pos)}
+		}
+
+		positions[node.ID] = position
+		if position.X < minX {
+			minX = position.X
+		}
+		if position.Y < minY {
+			minY = position.Y
+		}
+	}
+
+	if len(positions) == 0 {
+		return nil, nil
+	}
+	if missing != nil {
+		return nil, &Problem{Line: missing.Line, Message: fmt.Sprintf("node %s has no pos, the graph is laid out automatically", missing.ID)}
+	}
+
+	for id, position := range positions {
+		positions[id] = axon_types.Position{X: position.X - minX + origin.X, Y: position.Y - minY + origin.Y}
+	}
+	return positions, nil
+}
+
+// parsePos reads "x,y", with an optional third coordinate and ! for pinned nodes. The
+// y axis points up in Graphviz and down in notes.
+func parsePos(pos string) (axon_types.Position, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimSpace(pos), "!"), ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return axon_types.Position{}, fmt.Errorf("pos %q must be x,y", pos)
+	}
+
+	x, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return axon_types.Position{}, err
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return axon_types.Position{}, err
+	}
+
+	return axon_types.Position{X: int(math.Round(x)), Y: int(math.Round(-y))}, nil
+}
+
+func colorStyles(styles axon_types.NodeStyles, fill string, border string, text string) axon_types.NodeStyles {
+	if fill != "" || border != "" {
+		if styles.BackgroundStyles == nil {
+			styles.BackgroundStyles = map[string]interface{}{}
+		}
+		if fill != "" {
+			styles.BackgroundStyles[axon_types.STYLE_BACKGROUND_COLOR] = fill
+		}
+		if border != "" {
+			styles.BackgroundStyles[axon_types.STYLE_BORDER_COLOR] = border
+		}
+	}
+	if text != "" {
+		styles.LabelStyles = map[string]interface{}{axon_types.STYLE_COLOR: text}
+	}
+	return styles
+}
+
+// unescapeLabel applies the Graphviz label escapes: \N is the node ID, \G the graph
+// name, \n, \l and \r are line breaks and \\ is a backslash
+func unescapeLabel(label string, node_id string, graph_name string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(label); i++ {
+		if label[i] != '\\' || i+1 == len(label) {
+			b.WriteByte(label[i])
+			continue
+		}
+
+		i++
+		switch label[i] {
+		case 'N':
+			b.WriteString(node_id)
+		case 'G':
+			b.WriteString(graph_name)
+		case 'n', 'l', 'r':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(label[i])
+		}
+	}
+
+	// A trailing \l only ends the last line
+	return strings.TrimSuffix(b.String(), "\n")
+}
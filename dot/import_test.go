@@ -0,0 +1,152 @@
+package dot
+
+import (
+	"reflect"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// A note written as DOT and read back keeps its labels, categories, colours, edge
+// styles and positions. Group positions come from the layout of their members.
+func TestRoundTrip(t *testing.T) {
+	note := axon_types.NoteDetail{
+		NoteName: "Flow",
+		Nodes: []axon_types.Node{
+			{
+				NodeID:   "g",
+				Data:     axon_types.NodeData{Title: "Cluster", NodeCategory: axon_types.NODE_CATEGORY_GROUP},
+				Position: axon_types.Position{X: 380, Y: -20},
+				Styles: axon_types.NodeStyles{BackgroundStyles: map[string]interface{}{
+					axon_types.STYLE_BACKGROUND_COLOR: "#eeeeee", axon_types.STYLE_BORDER_COLOR: "#333333",
+				}},
+			},
+			{
+				NodeID:   "a",
+				Data:     axon_types.NodeData{Title: "Choose\nC:\\dir \"x\"", Description: "Pick one", NodeCategory: "decision"},
+				Position: axon_types.Position{X: 0, Y: 0},
+				Styles: axon_types.NodeStyles{
+					BackgroundStyles: map[string]interface{}{
+						axon_types.STYLE_BACKGROUND_COLOR: "#fef3c7", axon_types.STYLE_BORDER_COLOR: "#fb464c",
+					},
+					LabelStyles: map[string]interface{}{axon_types.STYLE_COLOR: "#111111"},
+				},
+			},
+			{
+				NodeID:   "b",
+				Data:     axon_types.NodeData{Title: "Rounded", NodeCategory: "rounded"},
+				Position: axon_types.Position{X: 200, Y: 100},
+			},
+			{
+				NodeID:   "c",
+				Data:     axon_types.NodeData{Title: "Store", NodeCategory: "database"},
+				Position: axon_types.Position{X: 400, Y: 0},
+				ParentID: "g",
+			},
+			{
+				// Ellipses have no category of their own, so it is written as axon_category
+				NodeID:   "d",
+				Data:     axon_types.NodeData{Title: "Begin", NodeCategory: "start"},
+				Position: axon_types.Position{X: 0, Y: 200},
+			},
+		},
+		Edges: []axon_types.Edge{
+			{EdgeID: "e1", SourceID: "a", TargetID: "b", Label: "yes", Animated: true},
+			{EdgeID: "e2", SourceID: "b", TargetID: "c"},
+		},
+	}
+
+	text, err := Export(note)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Import(text, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Problems) != 0 {
+		t.Errorf("problems = %v", result.Problems)
+	}
+	if len(result.Nodes) != len(note.Nodes) || len(result.Edges) != len(note.Edges) {
+		t.Fatalf("imported %d nodes and %d edges:\n%s", len(result.Nodes), len(result.Edges), text)
+	}
+
+	// Nodes are matched by title, as import gives them new IDs
+	ids := map[string]string{}
+	for _, node := range result.Nodes {
+		for _, want := range note.Nodes {
+			if node.Data.Title == want.Data.Title {
+				ids[node.NodeID] = want.NodeID
+			}
+		}
+	}
+
+	for _, node := range result.Nodes {
+		var want axon_types.Node
+		for _, n := range note.Nodes {
+			if n.NodeID == ids[node.NodeID] {
+				want = n
+			}
+		}
+		node.NodeID, node.ParentID = ids[node.NodeID], ids[node.ParentID]
+
+		if node.Data.NodeCategory == axon_types.NODE_CATEGORY_GROUP {
+			for _, style := range []string{axon_types.STYLE_BACKGROUND_COLOR, axon_types.STYLE_BORDER_COLOR} {
+				if got := node.Styles.BackgroundStyles[style]; got != want.Styles.BackgroundStyles[style] {
+					t.Errorf("group %s = %v, want %v", style, got, want.Styles.BackgroundStyles[style])
+				}
+			}
+			node.Position, node.Styles = want.Position, want.Styles
+		}
+
+		if !reflect.DeepEqual(node, want) {
+			t.Errorf("node %s = %+v\nwant %+v\n%s", want.NodeID, node, want, text)
+		}
+	}
+
+	for i, edge := range result.Edges {
+		edge.EdgeID, edge.SourceID, edge.TargetID = note.Edges[i].EdgeID, ids[edge.SourceID], ids[edge.TargetID]
+		if !reflect.DeepEqual(edge, note.Edges[i]) {
+			t.Errorf("edge = %+v, want %+v", edge, note.Edges[i])
+		}
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		nodes map[string]string
+	}{
+		{"wrapped", `digraph { a [label=<<b>x</b>>] }`, map[string]string{"a": "x"}},
+		{"bare", `digraph { a [label=<b>x</b>] }`, map[string]string{"a": "x"}},
+		{"bare with spaces", `digraph { a [label=<b>two words</b> shape=box] }`, map[string]string{"a": "two words"}},
+		{"bare with break", `digraph { a [label=<i>x</i><br>y, shape=box]; b }`, map[string]string{"a": "xy", "b": "b"}},
+		{"open tag", `digraph { a [label=<b>x] }`, map[string]string{"a": "x"}},
+		{"node IDs", `digraph { <a>-><b> [label=<b>x</b>] }`, map[string]string{"a": "a", "b": "b"}},
+	}
+
+	for _, test := range tests {
+		graph, err := Parse(test.text)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		got := map[string]string{}
+		for _, node := range graph.Nodes {
+			label, ok := node.Attributes["label"]
+			if !ok {
+				label = node.ID
+			}
+			got[node.ID] = label
+		}
+		if !reflect.DeepEqual(got, test.nodes) {
+			t.Errorf("%s: labels = %v, want %v", test.name, got, test.nodes)
+		}
+		if len(graph.Problems) != 1 || graph.Problems[0].Message != "HTML labels are imported as plain text" {
+			t.Errorf("%s: problems = %v", test.name, graph.Problems)
+		}
+	}
+}
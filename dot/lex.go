@@ -0,0 +1,352 @@
+package dot
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenId
+	tokenLBrace
+	tokenRBrace
+	tokenLBracket
+	tokenRBracket
+	tokenSemi
+	tokenComma
+	tokenEqual
+	tokenColon
+	tokenEdgeOp
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenEOF:      "end of file",
+	tokenId:       "ID",
+	tokenLBrace:   "{",
+	tokenRBrace:   "}",
+	tokenLBracket: "[",
+	tokenRBracket: "]",
+	tokenSemi:     ";",
+	tokenComma:    ",",
+	tokenEqual:    "=",
+	tokenColon:    ":",
+	tokenEdgeOp:   "edge operator",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+	// Set for "quoted" IDs, which are never keywords
+	quoted bool
+	// Set for <HTML> IDs
+	html bool
+}
+
+func (t token) String() string {
+	if t.kind == tokenId {
+		return fmt.Sprintf("%q", t.value)
+	}
+	return t.kind.String()
+}
+
+var punctuation = map[byte]tokenKind{
+	'{': tokenLBrace,
+	'}': tokenRBrace,
+	'[': tokenLBracket,
+	']': tokenRBracket,
+	';': tokenSemi,
+	',': tokenComma,
+	'=': tokenEqual,
+	':': tokenColon,
+}
+
+// lex splits DOT text into tokens, dropping comments and preprocessor lines. Quoted
+// strings joined with + become one ID.
+func lex(text string) ([]token, error) {
+	l := &lexer{text: text, line: 1, lineStart: true}
+	tokens := []token{}
+
+	for {
+		t, err := l.token()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type lexer struct {
+	text string
+	pos  int
+	line int
+	// Set until something other than white space is read on the current line
+	lineStart bool
+}
+
+func (l *lexer) token() (token, error) {
+	if err := l.skip(); err != nil {
+		return token{}, err
+	}
+
+	if l.pos >= len(l.text) {
+		return token{kind: tokenEOF, line: l.line}, nil
+	}
+
+	c := l.text[l.pos]
+	line := l.line
+
+	switch {
+	case strings.HasPrefix(l.text[l.pos:], "->") || strings.HasPrefix(l.text[l.pos:], "--"):
+		l.pos += 2
+		return token{kind: tokenEdgeOp, value: l.text[l.pos-2 : l.pos], line: line}, nil
+	case punctuation[c] != 0:
+		l.pos++
+		return token{kind: punctuation[c], line: line}, nil
+	case c == '"':
+		return l.quoted()
+	case c == '<':
+		return l.html()
+	case isIdStart(c):
+		start := l.pos
+		for l.pos < len(l.text) && (isIdStart(l.text[l.pos]) || isDigit(l.text[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenId, value: l.text[start:l.pos], line: line}, nil
+	case isDigit(c) || c == '-' || c == '.':
+		return l.numeral()
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.text[l.pos:])
+	return token{}, &SyntaxError{Line: line, Message: fmt.Sprintf("unexpected character %q", r)}
+}
+
+// skip moves past white space, comments and lines starting with #
+func (l *lexer) skip() error {
+	for l.pos < len(l.text) {
+		c := l.text[l.pos]
+		rest := l.text[l.pos:]
+
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+			l.lineStart = true
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#' && l.lineStart, strings.HasPrefix(rest, "//"):
+			for l.pos < len(l.text) && l.text[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return &SyntaxError{Line: l.line, Message: "comment is not closed with */"}
+			}
+			l.line += strings.Count(rest[:end+4], "\n")
+			l.pos += end + 4
+		default:
+			l.lineStart = false
+			return nil
+		}
+	}
+	return nil
+}
+
+// quoted reads a double quoted string. \" is a quote, a backslash before a line break
+// joins the lines, and other escapes are kept for the label escapes such as \n.
+func (l *lexer) quoted() (token, error) {
+	line := l.line
+	var b strings.Builder
+
+	for {
+		l.pos++ // opening quote
+		closed := false
+
+		for l.pos < len(l.text) {
+			c := l.text[l.pos]
+			if c == '"' {
+				l.pos++
+				closed = true
+				break
+			}
+			if c == '\\' && l.pos+1 < len(l.text) {
+				next := l.text[l.pos+1]
+				switch next {
+				case '"':
+					b.WriteByte('"')
+				case '\n':
+					l.line++
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(next)
+				}
+				l.pos += 2
+				continue
+			}
+			if c == '\n' {
+				l.line++
+			}
+			b.WriteByte(c)
+			l.pos++
+		}
+
+		if !closed {
+			return token{}, &SyntaxError{Line: line, Message: "string is not closed with \""}
+		}
+
+		// "a" + "b" is one string
+		save, saveLine := l.pos, l.line
+		if err := l.skip(); err != nil {
+			return token{}, err
+		}
+		if l.pos < len(l.text) && l.text[l.pos] == '+' {
+			l.pos++
+			if err := l.skip(); err != nil {
+				return token{}, err
+			}
+			if l.pos < len(l.text) && l.text[l.pos] == '"' {
+				continue
+			}
+			return token{}, &SyntaxError{Line: l.line, Message: "expected a string after +"}
+		}
+		l.pos, l.line = save, saveLine
+
+		return token{kind: tokenId, value: b.String(), line: line, quoted: true}, nil
+	}
+}
+
+// html reads an <HTML> string, which may contain nested angle brackets. Generated DOT
+// often leaves out the outer brackets, as in <b>x</b>, so text running on from the
+// first tag is read as part of the string, up to the end of the tags it opens.
+func (l *lexer) html() (token, error) {
+	line := l.line
+	start := l.pos
+	depth := 0
+	// Start of the current outermost tag, and tags opened but not closed
+	tag := 0
+	open := 0
+	bare := false
+
+	for l.pos < len(l.text) {
+		c := l.text[l.pos]
+		if bare && depth == 0 && l.bareEnd(open) {
+			break
+		}
+
+		switch c {
+		case '<':
+			if depth == 0 {
+				tag = l.pos
+			}
+			depth++
+		case '>':
+			if depth > 0 {
+				depth--
+			}
+		case '\n':
+			l.line++
+		}
+		l.pos++
+
+		if depth != 0 || c != '>' {
+			continue
+		}
+		if !bare && l.idEnd() {
+			return token{kind: tokenId, value: l.text[start+1 : l.pos-1], line: line, quoted: true, html: true}, nil
+		}
+		bare = true
+		open += tagDepth(l.text[tag+1 : l.pos-1])
+	}
+
+	if bare && depth == 0 {
+		return token{kind: tokenId, value: l.text[start:l.pos], line: line, quoted: true, html: true}, nil
+	}
+	return token{}, &SyntaxError{Line: line, Message: "HTML string is not closed with >"}
+}
+
+// idEnd reports whether an ID ends at the current position
+func (l *lexer) idEnd() bool {
+	if l.pos >= len(l.text) {
+		return true
+	}
+	rest := l.text[l.pos:]
+	return strings.IndexByte(" \t\r\n", rest[0]) >= 0 || punctuation[rest[0]] != 0 ||
+		strings.HasPrefix(rest, "->") || strings.HasPrefix(rest, "--")
+}
+
+// bareEnd reports whether an HTML string without outer brackets ends at the current
+// position. It runs on through spaces while a tag is open, but not past the end of
+// the statement or attribute list.
+func (l *lexer) bareEnd(open int) bool {
+	switch l.text[l.pos] {
+	case ']', ';', '}', '\n':
+		return true
+	}
+	return open <= 0 && l.idEnd()
+}
+
+// Elements that have no closing tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "vr": true}
+
+// tagDepth is 1 for an opening tag, -1 for a closing tag and 0 for others
+func tagDepth(tag string) int {
+	switch {
+	case strings.HasPrefix(tag, "/"):
+		return -1
+	case strings.HasPrefix(tag, "!"), strings.HasSuffix(tag, "/"):
+		return 0
+	}
+	name := tag
+	if end := strings.IndexAny(tag, " \t\r\n"); end >= 0 {
+		name = tag[:end]
+	}
+	if voidTags[strings.ToLower(name)] {
+		return 0
+	}
+	return 1
+}
+
+func (l *lexer) numeral() (token, error) {
+	line := l.line
+	start := l.pos
+	if l.text[l.pos] == '-' {
+		l.pos++
+	}
+
+	digits, dot := 0, false
+	for l.pos < len(l.text) {
+		c := l.text[l.pos]
+		if isDigit(c) {
+			digits++
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		l.pos++
+	}
+
+	if digits == 0 {
+		return token{}, &SyntaxError{Line: line, Message: fmt.Sprintf("unexpected %q", l.text[start:l.pos])}
+	}
+	return token{kind: tokenId, value: l.text[start:l.pos], line: line}, nil
+}
+
+func isIdStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
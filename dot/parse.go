@@ -0,0 +1,485 @@
+package dot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Graph is a parsed DOT graph. Attributes are the values written in the file, with
+// node and edge defaults already applied.
+type Graph struct {
+	Name     string
+	Directed bool
+	// Graph attributes of the root graph
+	Attributes map[string]string
+	// Nodes in the order they are first mentioned
+	Nodes []GraphNode
+	Edges []GraphEdge
+	// Subgraphs named cluster*, which Graphviz draws as boxes
+	Clusters []Cluster
+	// Statements that were skipped or only partly imported
+	Problems []Problem
+}
+
+type GraphNode struct {
+	ID         string
+	Attributes map[string]string
+	// ID of the innermost cluster the node is first mentioned in
+	Cluster string
+	Line    int
+}
+
+type GraphEdge struct {
+	From       string
+	To         string
+	Attributes map[string]string
+	Line       int
+}
+
+type Cluster struct {
+	ID         string
+	Attributes map[string]string
+	// ID of the enclosing cluster
+	Parent string
+}
+
+// Problem is a statement that could not be imported as written
+type Problem struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// SyntaxError is returned when the text is not a DOT graph
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("could not parse DOT graph - line %d: %s", e.Line, e.Message)
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// Parse reads a DOT graph: node, edge and attribute statements, subgraphs and
+// clusters. Ports are dropped and HTML labels are read as plain text, with a Problem
+// for each. Only one graph is read from the text.
+func Parse(text string) (*Graph, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		tokens: tokens,
+		graph:  &Graph{Attributes: map[string]string{}},
+		nodes:  map[string]int{},
+	}
+
+	if err := p.parseGraph(); err != nil {
+		return nil, err
+	}
+
+	return p.graph, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	graph  *Graph
+	// Index of each node in graph.Nodes
+	nodes map[string]int
+	// Lines already reported, so a long statement is reported once per kind
+	reported map[string]bool
+}
+
+// scope holds the defaults set by node and edge statements, which apply to the rest
+// of the graph or subgraph they are written in
+type scope struct {
+	nodeDefaults map[string]string
+	edgeDefaults map[string]string
+	// Attributes of the graph or subgraph itself
+	attributes map[string]string
+	cluster    string
+}
+
+func (s *scope) child() *scope {
+	return &scope{
+		nodeDefaults: copyAttributes(s.nodeDefaults),
+		edgeDefaults: copyAttributes(s.edgeDefaults),
+		attributes:   map[string]string{},
+		cluster:      s.cluster,
+	}
+}
+
+func (p *parser) parseGraph() error {
+	if p.keyword("strict") {
+		p.pos++
+	}
+
+	switch {
+	case p.keyword("digraph"):
+		p.graph.Directed = true
+	case p.keyword("graph"):
+	default:
+		return p.errorf("expected graph or digraph, other file types cannot be imported")
+	}
+	p.pos++
+
+	if p.peek().kind == tokenId {
+		p.graph.Name = p.next().value
+	}
+
+	if err := p.expect(tokenLBrace); err != nil {
+		return err
+	}
+
+	root := &scope{nodeDefaults: map[string]string{}, edgeDefaults: map[string]string{}, attributes: p.graph.Attributes}
+	if _, err := p.statements(root); err != nil {
+		return err
+	}
+
+	if err := p.expect(tokenRBrace); err != nil {
+		return err
+	}
+
+	if p.peek().kind != tokenEOF {
+		p.problem(p.peek().line, "only the first graph is imported")
+	}
+
+	return nil
+}
+
+// statements parses statements up to the closing brace and returns the nodes they
+// mention, for edges to and from a subgraph
+func (p *parser) statements(s *scope) ([]string, error) {
+	mentioned := []string{}
+
+	for {
+		t := p.peek()
+		switch t.kind {
+		case tokenRBrace:
+			return mentioned, nil
+		case tokenEOF:
+			return nil, p.errorf("unexpected end of file, expected }")
+		case tokenSemi:
+			p.pos++
+			continue
+		}
+
+		ids, err := p.statement(s)
+		if err != nil {
+			return nil, err
+		}
+		mentioned = append(mentioned, ids...)
+	}
+}
+
+func (p *parser) statement(s *scope) ([]string, error) {
+	t := p.peek()
+
+	// graph, node and edge attribute statements
+	for _, kind := range []string{"graph", "node", "edge"} {
+		if p.keyword(kind) && p.peekAt(1).kind == tokenLBracket {
+			p.pos++
+			attributes, err := p.attributeLists()
+			if err != nil {
+				return nil, err
+			}
+			switch kind {
+			case "graph":
+				mergeAttributes(s.attributes, attributes)
+			case "node":
+				mergeAttributes(s.nodeDefaults, attributes)
+			case "edge":
+				mergeAttributes(s.edgeDefaults, attributes)
+			}
+			return nil, nil
+		}
+	}
+
+	// ID = ID sets a graph attribute
+	if t.kind == tokenId && p.peekAt(1).kind == tokenEqual {
+		p.pos += 2
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		s.attributes[t.value] = value
+		return nil, nil
+	}
+
+	line := t.line
+	first, err := p.endpoint(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEdgeOp {
+		if first.subgraph {
+			return first.ids, nil
+		}
+
+		attributes, err := p.attributeLists()
+		if err != nil {
+			return nil, err
+		}
+		p.declare(first.ids[0], s, attributes, line)
+		return first.ids, nil
+	}
+
+	// Edge statement, a chain of endpoints
+	chain := [][]string{first.ids}
+	for p.peek().kind == tokenEdgeOp {
+		op := p.next()
+		switch {
+		case (op.value == "--") == p.graph.Directed:
+			p.problemOnce(op.line, "edgeop", fmt.Sprintf("%s does not match the graph type, the edge points from the first node written to the second", op.value))
+		case !p.graph.Directed:
+			p.problemOnce(op.line, "undirected", "undirected edges point from the first node written to the second")
+		}
+
+		next, err := p.endpoint(s)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, next.ids)
+	}
+
+	attributes, err := p.attributeLists()
+	if err != nil {
+		return nil, err
+	}
+
+	mentioned := []string{}
+	for _, ids := range chain {
+		mentioned = append(mentioned, ids...)
+	}
+
+	for i := 0; i+1 < len(chain); i++ {
+		for _, from := range chain[i] {
+			for _, to := range chain[i+1] {
+				edge := GraphEdge{From: from, To: to, Attributes: copyAttributes(s.edgeDefaults), Line: line}
+				mergeAttributes(edge.Attributes, attributes)
+				p.graph.Edges = append(p.graph.Edges, edge)
+			}
+		}
+	}
+
+	return mentioned, nil
+}
+
+type endpoint struct {
+	ids      []string
+	subgraph bool
+}
+
+// endpoint reads a node ID with an optional port, or a subgraph
+func (p *parser) endpoint(s *scope) (endpoint, error) {
+	if p.keyword("subgraph") || p.peek().kind == tokenLBrace {
+		ids, err := p.subgraph(s)
+		return endpoint{ids: ids, subgraph: true}, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenId {
+		return endpoint{}, p.errorf("expected a node ID, found %s", t)
+	}
+	p.pos++
+
+	// Ports name a point on the node, the edge is attached to the node itself
+	if p.peek().kind == tokenColon {
+		p.pos++
+		if p.next().kind != tokenId {
+			return endpoint{}, p.errorf("expected a port name")
+		}
+		if p.peek().kind == tokenColon {
+			p.pos++
+			if p.next().kind != tokenId {
+				return endpoint{}, p.errorf("expected a compass point")
+			}
+		}
+		p.problemOnce(t.line, "port", "ports are not supported, edges are attached to the node")
+	}
+
+	// Declared as soon as it is read, so nodes keep the order they are written in
+	p.declare(t.value, s, nil, t.line)
+	return endpoint{ids: []string{t.value}}, nil
+}
+
+func (p *parser) subgraph(s *scope) ([]string, error) {
+	inner := s.child()
+	line := p.peek().line
+
+	id := ""
+	if p.keyword("subgraph") {
+		p.pos++
+		if p.peek().kind == tokenId {
+			id = p.next().value
+		}
+	}
+
+	cluster := strings.HasPrefix(id, "cluster")
+	if cluster {
+		for _, existing := range p.graph.Clusters {
+			if existing.ID == id {
+				p.problem(line, fmt.Sprintf("cluster %s is written twice, its nodes are kept in the first", id))
+				cluster = false
+				break
+			}
+		}
+	}
+	if cluster {
+		p.graph.Clusters = append(p.graph.Clusters, Cluster{ID: id, Attributes: inner.attributes, Parent: s.cluster})
+		inner.cluster = id
+	}
+
+	if err := p.expect(tokenLBrace); err != nil {
+		return nil, err
+	}
+
+	ids, err := p.statements(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, p.expect(tokenRBrace)
+}
+
+// attributeLists reads any number of [a=b, c=d] lists
+func (p *parser) attributeLists() (map[string]string, error) {
+	attributes := map[string]string{}
+
+	for p.peek().kind == tokenLBracket {
+		p.pos++
+		for p.peek().kind != tokenRBracket {
+			name := p.next()
+			if name.kind != tokenId {
+				return nil, p.errorAt(name.line, fmt.Sprintf("expected an attribute name, found %s", name))
+			}
+			if err := p.expect(tokenEqual); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			attributes[name.value] = value
+
+			if kind := p.peek().kind; kind == tokenComma || kind == tokenSemi {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+
+	return attributes, nil
+}
+
+// value reads an attribute value. HTML strings keep only their text.
+func (p *parser) value() (string, error) {
+	t := p.next()
+	if t.kind != tokenId {
+		return "", p.errorAt(t.line, fmt.Sprintf("expected a value, found %s", t))
+	}
+
+	if t.html {
+		p.problemOnce(t.line, "html", "HTML labels are imported as plain text")
+		return strings.TrimSpace(htmlTag.ReplaceAllString(t.value, "")), nil
+	}
+	return t.value, nil
+}
+
+// declare adds a node with the current node defaults, or updates the attributes of a
+// node already mentioned
+func (p *parser) declare(id string, s *scope, attributes map[string]string, line int) {
+	index, exists := p.nodes[id]
+	if !exists {
+		p.nodes[id] = len(p.graph.Nodes)
+		p.graph.Nodes = append(p.graph.Nodes, GraphNode{ID: id, Attributes: copyAttributes(s.nodeDefaults), Cluster: s.cluster, Line: line})
+		index = len(p.graph.Nodes) - 1
+	}
+
+	node := &p.graph.Nodes[index]
+	if node.Cluster == "" {
+		node.Cluster = s.cluster
+	}
+	mergeAttributes(node.Attributes, attributes)
+}
+
+func (p *parser) peek() token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+// keyword matches an unquoted ID case insensitively, as DOT keywords are
+func (p *parser) keyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenId && !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+func (p *parser) expect(kind tokenKind) error {
+	t := p.next()
+	if t.kind != kind {
+		return p.errorAt(t.line, fmt.Sprintf("expected %s, found %s", kind, t))
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.peek().line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) errorAt(line int, message string) error {
+	return &SyntaxError{Line: line, Message: message}
+}
+
+func (p *parser) problem(line int, message string) {
+	p.graph.Problems = append(p.graph.Problems, Problem{Line: line, Message: message})
+}
+
+// problemOnce reports a kind of problem once per line
+func (p *parser) problemOnce(line int, kind string, message string) {
+	if p.reported == nil {
+		p.reported = map[string]bool{}
+	}
+	key := fmt.Sprintf("%d/%s", line, kind)
+	if p.reported[key] {
+		return
+	}
+	p.reported[key] = true
+	p.problem(line, message)
+}
+
+func copyAttributes(attributes map[string]string) map[string]string {
+	copied := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		copied[name] = value
+	}
+	return copied
+}
+
+func mergeAttributes(into map[string]string, from map[string]string) {
+	for name, value := range from {
+		into[name] = value
+	}
+}
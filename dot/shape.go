@@ -0,0 +1,74 @@
+package dot
+
+import "strings"
+
+// Shape of nodes without a category, written once as the node default
+const defaultShape = "box"
+
+// categoryShapes maps node categories to Graphviz shapes. Categories not listed are
+// drawn as boxes.
+var categoryShapes = map[string]string{
+	"process":     "box",
+	"rounded":     "box",
+	"start":       "ellipse",
+	"end":         "ellipse",
+	"terminal":    "ellipse",
+	"subroutine":  "component",
+	"database":    "cylinder",
+	"storage":     "cylinder",
+	"circle":      "circle",
+	"event":       "circle",
+	"stop":        "doublecircle",
+	"note":        "note",
+	"comment":     "note",
+	"decision":    "diamond",
+	"condition":   "diamond",
+	"preparation": "hexagon",
+	"hexagon":     "hexagon",
+	"input":       "parallelogram",
+	"output":      "parallelogram",
+	"manual":      "invtrapezoid",
+	"trapezoid":   "trapezoid",
+}
+
+// shapeCategories is the category a shape becomes on import. Boxes and ellipses, the
+// Graphviz default, get no category.
+var shapeCategories = map[string]string{
+	"component":     "subroutine",
+	"cylinder":      "database",
+	"circle":        "circle",
+	"doublecircle":  "stop",
+	"note":          "note",
+	"diamond":       "decision",
+	"hexagon":       "preparation",
+	"parallelogram": "input",
+	"invtrapezoid":  "manual",
+	"trapezoid":     "trapezoid",
+}
+
+func ShapeOf(category string) string {
+	if shape, ok := categoryShapes[strings.ToLower(category)]; ok {
+		return shape
+	}
+	return defaultShape
+}
+
+// CategoryOf is the category of a shape, including rounded boxes, which Graphviz
+// writes as a style
+func CategoryOf(shape string, style string) string {
+	shape = strings.ToLower(shape)
+	if (shape == "" || shape == "box" || shape == "rect" || shape == "rectangle") && hasStyle(style, "rounded") {
+		return "rounded"
+	}
+	return shapeCategories[shape]
+}
+
+// hasStyle reports whether a comma separated style list includes name
+func hasStyle(style string, name string) bool {
+	for _, part := range strings.Split(style, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), name) {
+			return true
+		}
+	}
+	return false
+}
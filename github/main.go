@@ -12,6 +12,11 @@ func GetGithubClient(ctx context.Context, token string) *github.Client {
 		&oauth2.Token{AccessToken: token},
 	)
 
+	return NewGithubClient(ctx, ts)
+}
+
+// NewGithubClient builds a client from a token source, so expired tokens can be refreshed
+func NewGithubClient(ctx context.Context, ts oauth2.TokenSource) *github.Client {
 	tc := oauth2.NewClient(ctx, ts)
 	client := github.NewClient(tc)
 	return client
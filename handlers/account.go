@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_portability "github.com/stephensanwo/axon-lib/portability"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Largest account archive accepted for import
+const maxArchiveSize = 512 << 20
+
+// Content types an archive may be uploaded with, besides none
+var archiveContentTypes = map[string]bool{
+	"application/zip":              true,
+	"application/x-zip-compressed": true,
+	"application/octet-stream":     true,
+}
+
+func accountRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/account/export", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: ExportAccount, Summary: "Download every folder and note of the account as a zip archive"},
+		{Path: "/account/import", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: ImportAccount, Summary: "Restore a zip archive from account export into an account without folders", Response: axon_portability.ImportReport{}, Status: http.StatusOK},
+		{Path: "/account", Method: http.MethodDelete, Auth: axon_types.PrivateRoute, Handler: DeleteAccount, Summary: "Delete the account and all of its data, resuming a deletion that was interrupted", Response: axon_types.DeletionReceipt{}, Status: http.StatusOK},
+	}
+}
+
+// ExportAccount streams the account archive. The response starts once the first
+// bytes of the archive are ready, an error before that is returned as usual.
+func ExportAccount(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	filename := fmt.Sprintf("axon-account-%s.zip", time.Now().UTC().Format("2006-01-02"))
+	stream := &streamWriter{w: w, start: func() {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+	}}
+
+	if _, err := axon_portability.Export(a, session(a), stream); err != nil {
+		if !stream.started {
+			writeError(w, err)
+			return
+		}
+		// The status is sent, the archive is left without its manifest
+		log.Errorln(err.Error())
+	}
+}
+
+// ImportAccount restores an archive sent as the request body. The archive is stored
+// in a temporary file, as zip files are read from the end.
+func ImportAccount(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !archiveContentTypes[mediaType] {
+			writeError(w, fmt.Errorf("%w - content type must be application/zip", errValidation))
+			return
+		}
+	}
+
+	file, err := os.CreateTemp("", "axon-account-*.zip")
+	if err != nil {
+		writeError(w, errors.New("could not import account - "+err.Error()))
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	size, err := io.Copy(file, http.MaxBytesReader(w, r.Body, maxArchiveSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, fmt.Errorf("%w - archive is larger than %d bytes", errValidation, maxArchiveSize))
+			return
+		}
+		writeError(w, errors.New("could not import account - "+err.Error()))
+		return
+	}
+	if size == 0 {
+		writeError(w, fmt.Errorf("%w - request body is empty", errValidation))
+		return
+	}
+
+	report, err := axon_portability.Import(a, session(a), file, size)
+	if err != nil {
+		if errors.Is(err, axon_portability.ErrInvalidArchive) {
+			err = fmt.Errorf("%w - %s", errValidation, err.Error())
+		}
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// DeleteAccount erases the user's data and signs them out everywhere. The receipt is
+// returned for the user's records, the same receipt is kept for compliance.
+func DeleteAccount(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	user := axon_core.User{}
+
+	receipt, err := user.DeleteAccount(a, session(a))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, receipt)
+}
+
+// streamWriter calls start before the first write, so headers can still change until
+// there is something to send
+type streamWriter struct {
+	w       io.Writer
+	start   func()
+	started bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if !s.started {
+		s.started = true
+		s.start()
+	}
+	return s.w.Write(p)
+}
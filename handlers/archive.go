@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v45/github"
+	axon_archive "github.com/stephensanwo/axon-lib/archive"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_github "github.com/stephensanwo/axon-lib/github"
+	axon_session "github.com/stephensanwo/axon-lib/session"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+func archiveRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/archive", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: CreateArchive, Summary: "Back up the user's notes to the GitHub archive repository", Response: axon_archive.Result{}, Status: http.StatusOK},
+		{Path: "/archive/restore", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: RestoreArchive, Summary: "Restore notes from a commit, tag or branch of the archive repository", Request: axon_archive.RestoreOptions{}, Response: axon_archive.RestoreReport{}, Status: http.StatusOK},
+		{Path: "/sync", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: SyncRepository, Summary: "Sync notes with the GitHub sync repository in both directions", Response: axon_archive.SyncReport{}, Status: http.StatusOK},
+	}
+}
+
+// CreateArchive commits the user's folders and notes to the configured archive
+// repository, as the signed in GitHub user
+func CreateArchive(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	archiver, err := newArchiver(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result, err := archiver.Archive(a, session(a))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// SyncRepository pulls changes made in the sync repository and pushes changes made
+// in axon. Notes changed on both sides are kept in both versions.
+func SyncRepository(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	repository := a.Settings.CoreSettings.GithubSyncRepo
+	if repository == "" {
+		repository = a.Settings.CoreSettings.GithubArchiveRepo
+	}
+
+	archiver, err := newRepositoryArchiver(a, repository)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	report, err := archiver.Sync(a, session(a))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func newArchiver(a *axon_types.AxonContext) (*axon_archive.Archiver, error) {
+	return newRepositoryArchiver(a, a.Settings.CoreSettings.GithubArchiveRepo)
+}
+
+func newRepositoryArchiver(a *axon_types.AxonContext, repository string) (*axon_archive.Archiver, error) {
+	if repository == "" {
+		return nil, errors.New("could not archive - core_settings.github_archive_repo is not configured")
+	}
+
+	client, err := githubClient(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return axon_archive.New(client, repository)
+}
+
+// githubClient acts as the signed in GitHub user, refreshing their token as needed
+func githubClient(a *axon_types.AxonContext) (*github.Client, error) {
+	// Personal access token sessions carry no GitHub token
+	if session(a).SessionData.Token == nil {
+		return nil, fmt.Errorf("a GitHub sign in is required - %w", axon_core.ErrForbidden)
+	}
+
+	return axon_github.NewGithubClient(a.Context, axon_session.TokenSource(a, session(a))), nil
+}
+
+// RestoreArchive recreates folders and notes from the archive repository. The
+// strategy decides what happens to items that still exist, skip by default.
+func RestoreArchive(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body axon_archive.RestoreOptions
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if body.Strategy == "" {
+		body.Strategy = axon_archive.StrategySkip
+	}
+
+	if !axon_archive.ValidStrategy(body.Strategy) {
+		writeError(w, fmt.Errorf("%w - strategy must be skip, overwrite or duplicate", errValidation))
+		return
+	}
+
+	archiver, err := newArchiver(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	report, err := archiver.Restore(a, session(a), body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Empty fields are left unchanged on update
+type EdgeRequest struct {
+	SourceID string `json:"source"`
+	TargetID string `json:"target"`
+	Animated bool   `json:"animated"`
+	Label    string `json:"label"`
+	EdgeType string `json:"edge_type"`
+}
+
+func edgeRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders/{folder_id}/notes/{note_id}/edges", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetEdges, Summary: "List the edges in a note", Response: []axon_types.Edge{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/edges", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: CreateEdge, Summary: "Create an edge", Request: EdgeRequest{}, Response: axon_types.Edge{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/edges/{edge_id}", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetEdge, Summary: "Get an edge", Response: axon_types.Edge{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/edges/{edge_id}", Method: http.MethodPatch, Auth: axon_types.PrivateRoute, Handler: UpdateEdge, Summary: "Update an edge", Request: EdgeRequest{}, Response: axon_types.Edge{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/edges/{edge_id}", Method: http.MethodDelete, Auth: axon_types.PrivateRoute, Handler: DeleteEdge, Summary: "Delete an edge", Errors: readErrors},
+	}
+}
+
+func GetEdges(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	edge := axon_core.Edge{Session: session(a), OwnerEmail: owner(r)}
+
+	edges, err := edge.GetEdges(a, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, edges)
+}
+
+func CreateEdge(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body EdgeRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"source": body.SourceID, "target": body.TargetID}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	folderId, noteId := param(a, "folder_id"), param(a, "note_id")
+
+	if err := nodesExist(r, a, folderId, noteId, body.SourceID, body.TargetID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	edge := axon_core.Edge{Session: session(a), OwnerEmail: owner(r)}
+
+	created, err := edge.CreateEdge(a, body.SourceID, body.TargetID, body.Animated, body.Label, body.EdgeType, folderId, noteId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func GetEdge(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	edge := axon_core.Edge{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := edge.FindEdge(a, param(a, "folder_id"), param(a, "note_id"), param(a, "edge_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func UpdateEdge(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body EdgeRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	edge := axon_core.Edge{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId, edgeId := param(a, "folder_id"), param(a, "note_id"), param(a, "edge_id")
+
+	if _, err := edge.FindEdge(a, folderId, noteId, edgeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := nodesExist(r, a, folderId, noteId, body.SourceID, body.TargetID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := edge.UpdateEdge(a, body.SourceID, body.TargetID, body.Animated, body.Label, body.EdgeType, folderId, noteId, edgeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updated, err := edge.FindEdge(a, folderId, noteId, edgeId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func DeleteEdge(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	edge := axon_core.Edge{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId, edgeId := param(a, "folder_id"), param(a, "note_id"), param(a, "edge_id")
+
+	if _, err := edge.FindEdge(a, folderId, noteId, edgeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := edge.DeleteEdge(a, folderId, noteId, edgeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
+
+// nodesExist checks that the nodes an edge connects are in the note, empty IDs are skipped
+func nodesExist(r *http.Request, a *axon_types.AxonContext, folder_id string, note_id string, node_ids ...string) error {
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+
+	for _, nodeId := range node_ids {
+		if nodeId == "" {
+			continue
+		}
+
+		_, err := node.FindNode(a, folder_id, note_id, nodeId)
+		if errors.Is(err, axon_core.ErrNotFound) {
+			return fmt.Errorf("%w - node %s does not exist in the note", errValidation, nodeId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_dot "github.com/stephensanwo/axon-lib/dot"
+	axon_jsoncanvas "github.com/stephensanwo/axon-lib/jsoncanvas"
+	axon_markdown "github.com/stephensanwo/axon-lib/markdown"
+	axon_mermaid "github.com/stephensanwo/axon-lib/mermaid"
+	axon_svg "github.com/stephensanwo/axon-lib/svg"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// exporter renders a note in one format. Options come from the query string.
+type exporter struct {
+	contentType string
+	extension   string
+	export      func(note axon_types.NoteDetail, r *http.Request) ([]byte, error)
+}
+
+var exporters = map[string]exporter{
+	"dot": {
+		contentType: "text/vnd.graphviz; charset=utf-8",
+		extension:   "gv",
+		export: func(note axon_types.NoteDetail, r *http.Request) ([]byte, error) {
+			text, err := axon_dot.Export(note)
+			return []byte(text), err
+		},
+	},
+	"jsoncanvas": {
+		contentType: "application/json",
+		extension:   "canvas",
+		export: func(note axon_types.NoteDetail, r *http.Request) ([]byte, error) {
+			text, err := axon_jsoncanvas.Export(note)
+			return []byte(text), err
+		},
+	},
+	"markdown": {
+		contentType: "text/markdown; charset=utf-8",
+		extension:   "md",
+		export: func(note axon_types.NoteDetail, r *http.Request) ([]byte, error) {
+			text, err := axon_markdown.Export(note)
+			return []byte(text), err
+		},
+	},
+	"mermaid": {
+		contentType: "text/plain; charset=utf-8",
+		extension:   "mmd",
+		export: func(note axon_types.NoteDetail, r *http.Request) ([]byte, error) {
+			text, err := axon_mermaid.Export(note, axon_mermaid.Options{Direction: strings.ToUpper(r.URL.Query().Get("direction"))})
+			return []byte(text), err
+		},
+	},
+	"svg": {
+		contentType: "image/svg+xml",
+		extension:   "svg",
+		export: func(note axon_types.NoteDetail, r *http.Request) ([]byte, error) {
+			image, err := axon_svg.Render(note)
+			return []byte(image), err
+		},
+	},
+}
+
+func exportRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders/{folder_id}/notes/{note_id}/export/{format}", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: ExportNote, Summary: "Export a note as " + strings.Join(exportFormats(), ", ")},
+	}
+}
+
+// ExportNote returns a note in the requested format. ?download=true adds a
+// Content-Disposition header, so browsers save the file.
+func ExportNote(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	format := param(a, "format")
+
+	exporter, ok := exporters[format]
+	if !ok {
+		writeError(w, fmt.Errorf("%w - format must be one of %s", errValidation, strings.Join(exportFormats(), ", ")))
+		return
+	}
+
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+
+	noteDetail, err := note.GetNoteDetail(a, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	body, err := exporter.export(*noteDetail, r)
+	if err != nil {
+		writeError(w, fmt.Errorf("%w - %s", errValidation, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.contentType)
+	if r.URL.Query().Get("download") == "true" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", noteDetail.NoteID+"."+exporter.extension))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func exportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
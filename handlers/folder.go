@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type FolderRequest struct {
+	FolderName string `json:"folder_name"`
+}
+
+func folderRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetFolderList, Summary: "List folders with their notes, including shared folders", Response: []axon_types.FolderList{}},
+		{Path: "/folders", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: CreateFolder, Summary: "Create a folder", Request: FolderRequest{}, Response: axon_types.Folder{}, Errors: []int{http.StatusBadRequest}},
+		{Path: "/folders/{folder_id}", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetFolder, Summary: "Get a folder", Response: axon_types.Folder{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}", Method: http.MethodPatch, Auth: axon_types.PrivateRoute, Handler: UpdateFolder, Summary: "Rename a folder", Request: FolderRequest{}, Response: axon_types.Folder{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}", Method: http.MethodDelete, Auth: axon_types.PrivateRoute, Handler: DeleteFolder, Summary: "Delete a folder", Errors: readErrors},
+	}
+}
+
+// GetFolderList returns the user's folders with their notes, and the items shared with them
+func GetFolderList(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	folder := axon_core.Folder{Session: session(a)}
+
+	folders, err := folder.GetFolderList(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, folders)
+}
+
+func CreateFolder(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body FolderRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"folder_name": body.FolderName}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	folder := axon_core.Folder{Session: session(a)}
+
+	folderId, err := folder.CreateFolder(a, body.FolderName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	created, err := folder.FindFolder(a, *folderId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func GetFolder(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	folder := axon_core.Folder{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := folder.FindFolder(a, param(a, "folder_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func UpdateFolder(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body FolderRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"folder_name": body.FolderName}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	folder := axon_core.Folder{Session: session(a), OwnerEmail: owner(r)}
+	folderId := param(a, "folder_id")
+
+	if _, err := folder.FindFolder(a, folderId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := folder.UpdateFolder(a, body.FolderName, folderId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updated, err := folder.FindFolder(a, folderId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func DeleteFolder(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	folder := axon_core.Folder{Session: session(a), OwnerEmail: owner(r)}
+	folderId := param(a, "folder_id")
+
+	if _, err := folder.FindFolder(a, folderId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := folder.DeleteFolder(a, folderId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_router "github.com/stephensanwo/axon-lib/router"
+	axon_session "github.com/stephensanwo/axon-lib/session"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Request bodies are small JSON documents, anything larger is rejected
+const maxBodySize = 1 << 20
+
+// ErrorResponse is the envelope every error is returned in
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var errValidation = errors.New("invalid request")
+
+// Error statuses of the folder, note, node and edge routes. Items have server made IDs
+// and names need not be unique, so none of them return 409.
+var (
+	readErrors  = []int{http.StatusForbidden, http.StatusNotFound}
+	writeErrors = []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}
+)
+
+// Routes returns the standard REST routes for folders, notes, nodes and edges. All
+// routes are private. Items shared by another user are addressed with ?owner=<email>.
+func Routes() []axon_types.Route {
+	routes := []axon_types.Route{}
+	routes = append(routes, folderRoutes()...)
+	routes = append(routes, noteRoutes()...)
+	routes = append(routes, nodeRoutes()...)
+	routes = append(routes, edgeRoutes()...)
+	routes = append(routes, archiveRoutes()...)
+	routes = append(routes, issueRoutes()...)
+	routes = append(routes, exportRoutes()...)
+	routes = append(routes, importRoutes()...)
+	routes = append(routes, searchRoutes()...)
+	routes = append(routes, tagRoutes()...)
+	routes = append(routes, accountRoutes()...)
+	return routes
+}
+
+// NotFound and MethodNotAllowed answer unmatched requests with the error envelope,
+// for use as the router's fallback handlers
+func NotFound() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeErrorStatus(w, http.StatusNotFound, "not_found", "no route for "+r.URL.Path)
+	})
+}
+
+func MethodNotAllowed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeErrorStatus(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path)
+	})
+}
+
+func session(a *axon_types.AxonContext) axon_types.Session {
+	session, _ := axon_session.GetSession(a)
+	return session
+}
+
+func owner(r *http.Request) string {
+	return r.URL.Query().Get("owner")
+}
+
+func param(a *axon_types.AxonContext, name string) string {
+	return axon_router.Param(a, name)
+}
+
+// decodeBody reads a JSON body into v, rejecting unknown fields and trailing data
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		return fmt.Errorf("%w - content type must be application/json", errValidation)
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w - request body is empty", errValidation)
+		}
+		return fmt.Errorf("%w - %s", errValidation, err.Error())
+	}
+
+	if decoder.More() {
+		return fmt.Errorf("%w - request body must be a single JSON object", errValidation)
+	}
+
+	return nil
+}
+
+func required(fields map[string]string) error {
+	missing := []string{}
+	for name, value := range fields {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("%w - %s is required", errValidation, strings.Join(missing, ", "))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError maps core errors onto status codes
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errValidation):
+		writeErrorStatus(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, axon_core.ErrNotFound):
+		writeErrorStatus(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, axon_core.ErrForbidden):
+		writeErrorStatus(w, http.StatusForbidden, "forbidden", err.Error())
+	case errors.Is(err, axon_core.ErrConflict):
+		writeErrorStatus(w, http.StatusConflict, "conflict", err.Error())
+	case errors.Is(err, axon_session.ErrSessionExpired):
+		writeErrorStatus(w, http.StatusUnauthorized, "session_expired", err.Error())
+	case errors.Is(err, axon_core.ErrPasswordRequired):
+		writeErrorStatus(w, http.StatusUnauthorized, "password_required", err.Error())
+	default:
+		// Database errors are logged, not returned to the client
+		log.Errorln(err.Error())
+		writeErrorStatus(w, http.StatusInternalServerError, "internal_error", "internal server error")
+	}
+}
+
+func writeErrorStatus(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, ErrorResponse{
+		Error: ErrorBody{
+			Status:  status,
+			Code:    code,
+			Message: message,
+		},
+	})
+}
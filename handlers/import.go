@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_dot "github.com/stephensanwo/axon-lib/dot"
+	axon_jsoncanvas "github.com/stephensanwo/axon-lib/jsoncanvas"
+	axon_mermaid "github.com/stephensanwo/axon-lib/mermaid"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Space left between the existing nodes of a note and an imported diagram
+const importMargin = 200
+
+type ImportRequest struct {
+	Content string `json:"content"`
+}
+
+type ImportResponse struct {
+	Nodes []axon_types.Node `json:"nodes"`
+	Edges []axon_types.Edge `json:"edges"`
+	// Parts of the content that were skipped or imported differently
+	Warnings []ImportWarning `json:"warnings"`
+}
+
+type ImportWarning struct {
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// importer turns content in one format into nodes and edges placed from origin. An
+// error means the content cannot be read at all.
+type importer struct {
+	parse func(content string, origin axon_types.Position) (*ImportResponse, error)
+}
+
+var importers = map[string]importer{
+	"dot": {
+		parse: func(content string, origin axon_types.Position) (*ImportResponse, error) {
+			result, err := axon_dot.Import(content, axon_dot.ImportOptions{Origin: origin})
+			if err != nil {
+				return nil, err
+			}
+
+			warnings := make([]ImportWarning, len(result.Problems))
+			for i, problem := range result.Problems {
+				warnings[i] = ImportWarning{Line: problem.Line, Message: problem.Message}
+			}
+			return &ImportResponse{Nodes: result.Nodes, Edges: result.Edges, Warnings: warnings}, nil
+		},
+	},
+	"jsoncanvas": {
+		parse: func(content string, origin axon_types.Position) (*ImportResponse, error) {
+			result, err := axon_jsoncanvas.Import(content, axon_jsoncanvas.ImportOptions{Origin: origin})
+			if err != nil {
+				return nil, err
+			}
+
+			warnings := make([]ImportWarning, len(result.Problems))
+			for i, problem := range result.Problems {
+				warnings[i] = ImportWarning{Message: problem.Message}
+			}
+			return &ImportResponse{Nodes: result.Nodes, Edges: result.Edges, Warnings: warnings}, nil
+		},
+	},
+	"mermaid": {
+		parse: func(content string, origin axon_types.Position) (*ImportResponse, error) {
+			result, err := axon_mermaid.Import(content, axon_mermaid.ImportOptions{Origin: origin})
+			if err != nil {
+				return nil, err
+			}
+
+			warnings := make([]ImportWarning, len(result.Problems))
+			for i, problem := range result.Problems {
+				warnings[i] = ImportWarning{Line: problem.Line, Message: problem.Message}
+			}
+			return &ImportResponse{Nodes: result.Nodes, Edges: result.Edges, Warnings: warnings}, nil
+		},
+	},
+}
+
+func importRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders/{folder_id}/notes/{note_id}/import/{format}", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: ImportNote, Summary: "Add the nodes and edges of a diagram in " + strings.Join(importFormats(), ", ") + " to a note", Request: ImportRequest{}, Response: ImportResponse{}, Status: http.StatusOK},
+	}
+}
+
+// ImportNote adds a diagram to a note, below the nodes it already has
+func ImportNote(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	format := param(a, "format")
+
+	importer, ok := importers[format]
+	if !ok {
+		writeError(w, fmt.Errorf("%w - format must be one of %s", errValidation, strings.Join(importFormats(), ", ")))
+		return
+	}
+
+	var body ImportRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"content": body.Content}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	folder_id, note_id := param(a, "folder_id"), param(a, "note_id")
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+
+	noteDetail, err := note.GetNoteDetail(a, folder_id, note_id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result, err := importer.parse(body.Content, importOrigin(noteDetail.Nodes))
+	if err != nil {
+		writeError(w, fmt.Errorf("%w - %s", errValidation, err.Error()))
+		return
+	}
+
+	graph := axon_core.Import{Session: session(a), OwnerEmail: owner(r)}
+
+	nodes, edges, err := graph.AddGraph(a, folder_id, note_id, result.Nodes, result.Edges)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ImportResponse{Nodes: nodes, Edges: edges, Warnings: result.Warnings})
+}
+
+// importOrigin is the left edge of the existing nodes, below the lowest of them
+func importOrigin(nodes []axon_types.Node) axon_types.Position {
+	if len(nodes) == 0 {
+		return axon_types.Position{}
+	}
+
+	origin := axon_types.Position{X: nodes[0].Position.X, Y: nodes[0].Position.Y}
+	for _, node := range nodes {
+		if node.Position.X < origin.X {
+			origin.X = node.Position.X
+		}
+		if node.Position.Y > origin.Y {
+			origin.Y = node.Position.Y
+		}
+	}
+
+	origin.Y += importMargin
+	return origin
+}
+
+func importFormats() []string {
+	formats := make([]string, 0, len(importers))
+	for format := range importers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
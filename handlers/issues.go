@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_issues "github.com/stephensanwo/axon-lib/issues"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+func issueRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders/{folder_id}/notes/{note_id}/github/issues", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: ImportIssues, Summary: "Add nodes for GitHub issues and pull requests, with edges for their references", Request: axon_issues.IssueFilter{}, Response: axon_issues.IssueImportResult{}, Status: http.StatusOK},
+		{Path: "/folders/{folder_id}/notes/{note_id}/github/issues/refresh", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: RefreshIssues, Summary: "Update the nodes imported from GitHub issues with their current state", Response: axon_issues.IssueRefreshResult{}, Status: http.StatusOK},
+	}
+}
+
+func ImportIssues(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body axon_issues.IssueFilter
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"owner": body.Owner, "repo": body.Repo}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	client, err := githubClient(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	importer := axon_core.Import{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := axon_issues.ImportIssues(a, client, importer, body, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func RefreshIssues(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	client, err := githubClient(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := axon_issues.RefreshIssues(a, client, node, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type CreateNodeRequest struct {
+	Data     axon_types.NodeData `json:"data"`
+	Position axon_types.Position `json:"position"`
+}
+
+// Empty fields are left unchanged
+type UpdateNodeRequest struct {
+	Data     axon_types.NodeData    `json:"data"`
+	Position axon_types.Position    `json:"position"`
+	Content  axon_types.NodeContent `json:"node_content"`
+	Styles   axon_types.NodeStyles  `json:"node_styles"`
+}
+
+func nodeRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders/{folder_id}/notes/{note_id}/nodes", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetNodes, Summary: "List the nodes in a note", Response: []axon_types.Node{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/nodes", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: CreateNode, Summary: "Create a node", Request: CreateNodeRequest{}, Response: axon_types.Node{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/nodes/{node_id}", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetNode, Summary: "Get a node", Response: axon_types.Node{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/nodes/{node_id}", Method: http.MethodPatch, Auth: axon_types.PrivateRoute, Handler: UpdateNode, Summary: "Update a node", Request: UpdateNodeRequest{}, Response: axon_types.Node{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/nodes/{node_id}", Method: http.MethodDelete, Auth: axon_types.PrivateRoute, Handler: DeleteNode, Summary: "Delete a node", Errors: readErrors},
+	}
+}
+
+func GetNodes(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+
+	nodes, err := node.GetNodes(a, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func CreateNode(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body CreateNodeRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"data.label": body.Data.Label}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+
+	created, err := node.CreateNode(a, body.Data, body.Position, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func GetNode(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := node.FindNode(a, param(a, "folder_id"), param(a, "note_id"), param(a, "node_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func UpdateNode(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body UpdateNodeRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId, nodeId := param(a, "folder_id"), param(a, "note_id"), param(a, "node_id")
+
+	if _, err := node.FindNode(a, folderId, noteId, nodeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := node.UpdateNode(a, body.Data, body.Position, body.Content, body.Styles, folderId, noteId, nodeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updated, err := node.FindNode(a, folderId, noteId, nodeId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func DeleteNode(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId, nodeId := param(a, "folder_id"), param(a, "note_id"), param(a, "node_id")
+
+	if _, err := node.FindNode(a, folderId, noteId, nodeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := node.DeleteNode(a, folderId, noteId, nodeId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
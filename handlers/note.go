@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type CreateNoteRequest struct {
+	NoteName    string `json:"note_name"`
+	Description string `json:"description"`
+}
+
+// Omitted fields are left unchanged
+type UpdateNoteRequest struct {
+	NoteName    *string `json:"note_name"`
+	Description *string `json:"description"`
+}
+
+func noteRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/folders/{folder_id}/notes", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetNotes, Summary: "List the notes in a folder", Response: []axon_types.Note{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: CreateNote, Summary: "Create a note", Request: CreateNoteRequest{}, Response: axon_types.Note{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetNote, Summary: "Get a note", Response: axon_types.Note{}, Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}", Method: http.MethodPatch, Auth: axon_types.PrivateRoute, Handler: UpdateNote, Summary: "Update a note", Request: UpdateNoteRequest{}, Response: axon_types.Note{}, Errors: writeErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}", Method: http.MethodDelete, Auth: axon_types.PrivateRoute, Handler: DeleteNote, Summary: "Delete a note", Errors: readErrors},
+		{Path: "/folders/{folder_id}/notes/{note_id}/detail", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetNoteDetail, Summary: "Get a note with its nodes and edges", Response: axon_types.NoteDetail{}, Errors: readErrors},
+	}
+}
+
+func GetNotes(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+
+	notes, err := note.GetNotes(a, param(a, "folder_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+func CreateNote(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body CreateNoteRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"note_name": body.NoteName}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	folder := axon_core.Folder{Session: session(a), OwnerEmail: owner(r)}
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+	folderId := param(a, "folder_id")
+
+	if _, err := folder.FindFolder(a, folderId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	noteId, err := note.CreateNote(a, body.NoteName, body.Description, folderId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	created, err := note.FindNote(a, folderId, *noteId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func GetNote(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := note.FindNote(a, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GetNoteDetail returns the note with all of its nodes and edges
+func GetNoteDetail(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+
+	result, err := note.GetNoteDetail(a, param(a, "folder_id"), param(a, "note_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func UpdateNote(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body UpdateNoteRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if body.NoteName != nil {
+		if err := required(map[string]string{"note_name": *body.NoteName}); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId := param(a, "folder_id"), param(a, "note_id")
+
+	if _, err := note.FindNote(a, folderId, noteId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := note.UpdateNote(a, body.NoteName, body.Description, folderId, noteId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updated, err := note.FindNote(a, folderId, noteId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func DeleteNote(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId := param(a, "folder_id"), param(a, "note_id")
+
+	if _, err := note.FindNote(a, folderId, noteId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if _, err := note.DeleteNote(a, folderId, noteId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
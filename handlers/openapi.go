@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	axon_openapi "github.com/stephensanwo/axon-lib/openapi"
+)
+
+// OpenAPIOptions describes the routes returned by Routes, for openapi.Route. The
+// specification they generate is committed as handlers/openapi.json.
+func OpenAPIOptions() axon_openapi.Options {
+	return axon_openapi.Options{
+		Title:         "Axon API",
+		Version:       "1.0.0",
+		Description:   "Folders, notes, nodes and edges of the signed in user, and the notes shared with them",
+		ErrorResponse: ErrorResponse{},
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	axon_openapi "github.com/stephensanwo/axon-lib/openapi"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json from the routes")
+
+const specFile = "openapi.json"
+
+// TestOpenAPISpec fails when a route changes without openapi.json. Run
+// go test ./handlers -run TestOpenAPISpec -update to rewrite it.
+func TestOpenAPISpec(t *testing.T) {
+	if *update {
+		doc, err := axon_openapi.Generate(Routes(), OpenAPIOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		spec, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(specFile, append(spec, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := axon_openapi.Check(spec, Routes(), OpenAPIOptions()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_search "github.com/stephensanwo/axon-lib/search"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Results returned when the request sets no limit, and the most it may ask for
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func searchRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/search", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: SearchNotes, Summary: "Search folder names, notes and node content with ?q=, word* for prefixes, word~ for typos and \"quotes\" for phrases", Response: axon_types.SearchResults{}, Status: http.StatusOK},
+		{Path: "/search/reindex", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: ReindexSearch, Summary: "Rebuild the search index of the account from its folders, notes and nodes", Response: axon_types.SearchIndexReport{}, Status: http.StatusOK},
+	}
+}
+
+// SearchNotes returns the best matches for ?q= in the user's own folders, notes and
+// nodes. ?limit= sets how many, up to 100.
+func SearchNotes(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	query := r.URL.Query()
+
+	if err := required(map[string]string{"q": query.Get("q")}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			writeError(w, fmt.Errorf("%w - limit must be a number from 1 to %d", errValidation, maxSearchLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	search := axon_core.Search{Session: session(a)}
+
+	results, err := search.Search(a, query.Get("q"), limit)
+	if err != nil {
+		if errors.Is(err, axon_search.ErrInvalidQuery) {
+			err = fmt.Errorf("%w - %s", errValidation, err.Error())
+		}
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// ReindexSearch rebuilds the user's search index, for notes written before search
+func ReindexSearch(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	search := axon_core.Search{Session: session(a)}
+
+	report, err := search.Reindex(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
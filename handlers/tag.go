@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Replaces all tags, an empty list removes them
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+type MergeTagsRequest struct {
+	Tags []string `json:"tags"`
+	Into string   `json:"into"`
+}
+
+func tagRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/tags", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetTags, Summary: "List the user's tags with the number of notes and nodes that have each", Response: []axon_types.Tag{}},
+		{Path: "/tags/merge", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Handler: MergeTags, Summary: "Merge tags into one, on every note and node that has them", Request: MergeTagsRequest{}, Response: axon_types.Tag{}, Status: http.StatusOK},
+		{Path: "/tags/{tag}", Method: http.MethodPatch, Auth: axon_types.PrivateRoute, Handler: RenameTag, Summary: "Rename a tag on every note and node that has it", Request: RenameTagRequest{}, Response: axon_types.Tag{}},
+		{Path: "/tags/{tag}/notes", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetTaggedNotes, Summary: "List the notes with a tag", Response: []axon_types.Note{}},
+		{Path: "/tags/{tag}/nodes", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Handler: GetTaggedNodes, Summary: "List the nodes with a tag across all notes", Response: []axon_types.Node{}},
+		{Path: "/folders/{folder_id}/notes/{note_id}/tags", Method: http.MethodPut, Auth: axon_types.PrivateRoute, Handler: SetNoteTags, Summary: "Replace the tags of a note", Request: TagsRequest{}, Response: axon_types.Note{}},
+		{Path: "/folders/{folder_id}/notes/{note_id}/nodes/{node_id}/tags", Method: http.MethodPut, Auth: axon_types.PrivateRoute, Handler: SetNodeTags, Summary: "Replace the tags of a node", Request: TagsRequest{}, Response: axon_types.Node{}},
+	}
+}
+
+func GetTags(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	tag := axon_core.Tag{Session: session(a)}
+
+	tags, err := tag.GetTags(a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func GetTaggedNotes(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	tag := axon_core.Tag{Session: session(a)}
+
+	notes, err := tag.TaggedNotes(a, param(a, "tag"))
+	if err != nil {
+		writeError(w, tagError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+func GetTaggedNodes(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	tag := axon_core.Tag{Session: session(a)}
+
+	nodes, err := tag.TaggedNodes(a, param(a, "tag"))
+	if err != nil {
+		writeError(w, tagError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func RenameTag(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body RenameTagRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"name": body.Name}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	tag := axon_core.Tag{Session: session(a)}
+
+	renamed, err := tag.RenameTag(a, param(a, "tag"), body.Name)
+	if err != nil {
+		writeError(w, tagError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, renamed)
+}
+
+func MergeTags(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body MergeTagsRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := required(map[string]string{"into": body.Into}); err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(body.Tags) == 0 {
+		writeError(w, fmt.Errorf("%w - tags is required", errValidation))
+		return
+	}
+
+	tag := axon_core.Tag{Session: session(a)}
+
+	merged, err := tag.MergeTags(a, body.Tags, body.Into)
+	if err != nil {
+		writeError(w, tagError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, merged)
+}
+
+func SetNoteTags(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body TagsRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	tag := axon_core.Tag{Session: session(a), OwnerEmail: owner(r)}
+	note := axon_core.Note{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId := param(a, "folder_id"), param(a, "note_id")
+
+	if _, err := tag.SetNoteTags(a, folderId, noteId, body.Tags); err != nil {
+		writeError(w, tagError(err))
+		return
+	}
+
+	updated, err := note.FindNote(a, folderId, noteId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func SetNodeTags(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+	var body TagsRequest
+	if err := decodeBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	tag := axon_core.Tag{Session: session(a), OwnerEmail: owner(r)}
+	node := axon_core.Node{Session: session(a), OwnerEmail: owner(r)}
+	folderId, noteId, nodeId := param(a, "folder_id"), param(a, "note_id"), param(a, "node_id")
+
+	if _, err := tag.SetNodeTags(a, folderId, noteId, nodeId, body.Tags); err != nil {
+		writeError(w, tagError(err))
+		return
+	}
+
+	updated, err := node.FindNode(a, folderId, noteId, nodeId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// tagError reports invalid tags as invalid requests
+func tagError(err error) error {
+	if errors.Is(err, axon_core.ErrInvalidTag) {
+		return fmt.Errorf("%w - %s", errValidation, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,415 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/google/uuid"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Node categories of imported issues and pull requests, by state
+const (
+	CATEGORY_ISSUE_OPEN          string = "github_issue_open"
+	CATEGORY_ISSUE_CLOSED        string = "github_issue_closed"
+	CATEGORY_PULL_REQUEST_OPEN   string = "github_pull_request_open"
+	CATEGORY_PULL_REQUEST_MERGED string = "github_pull_request_merged"
+	CATEGORY_PULL_REQUEST_CLOSED string = "github_pull_request_closed"
+)
+
+const (
+	EDGE_LABEL_REFERENCES string = "references"
+	EDGE_LABEL_CLOSES     string = "closes"
+
+	// Imported nodes are laid out on a grid
+	gridColumns = 4
+	gridWidth   = 320
+	gridHeight  = 220
+
+	maxIssues = 500
+)
+
+// IssueFilter selects the issues and pull requests of a repository to import. Query
+// is GitHub search syntax, e.g. "is:pr author:octocat", and is used instead of Labels
+// and Milestone when set.
+type IssueFilter struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	// Issues must have all of these labels
+	Labels []string `json:"labels,omitempty"`
+	// Milestone number, "*" for any milestone or "none"
+	Milestone string `json:"milestone,omitempty"`
+	// open, closed or all, open by default
+	State string `json:"state,omitempty"`
+	Query string `json:"query,omitempty"`
+	// At most 500, which is also the default
+	Limit int `json:"limit,omitempty"`
+}
+
+type IssueImportResult struct {
+	Nodes []axon_types.Node `json:"nodes"`
+	Edges []axon_types.Edge `json:"edges"`
+	// References of issues that were already in the note
+	Skipped []string `json:"skipped"`
+}
+
+type IssueRefreshResult struct {
+	Updated []string `json:"updated"`
+	// References that could not be read, e.g. deleted issues or lost access
+	Missing []string `json:"missing"`
+}
+
+// Node labels hold the issue reference, owner/repo#number, which is how refresh finds
+// the nodes it manages
+var referenceLabel = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+
+// References in issue text: owner/repo#1, #1, or an issue or pull request URL,
+// optionally preceded by a closing keyword
+var referencePattern = regexp.MustCompile(`(?i)(?:\b(close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s+)?(?:https://github\.com/([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d+)|(?:\b([\w.-]+)/([\w.-]+))?#(\d+)\b)`)
+
+// FetchIssues lists the issues and pull requests matching a filter, most recently
+// updated first. Pull requests are listed as issues, with PullRequestLinks set.
+func FetchIssues(ctx context.Context, client *github.Client, filter IssueFilter) ([]*github.Issue, error) {
+	if filter.Owner == "" || filter.Repo == "" {
+		return nil, errors.New("could not fetch issues - owner and repo are required")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > maxIssues {
+		limit = maxIssues
+	}
+
+	state := filter.State
+	if state == "" {
+		state = "open"
+	}
+
+	issues := []*github.Issue{}
+	page := 1
+
+	for page != 0 && len(issues) < limit {
+		var batch []*github.Issue
+		var response *github.Response
+		var err error
+
+		if filter.Query != "" {
+			query := fmt.Sprintf("repo:%s/%s %s", filter.Owner, filter.Repo, filter.Query)
+			if state != "all" && !strings.Contains(filter.Query, "is:open") && !strings.Contains(filter.Query, "is:closed") {
+				query += " is:" + state
+			}
+
+			var result *github.IssuesSearchResult
+			result, response, err = client.Search.Issues(ctx, query, &github.SearchOptions{
+				Sort:        "updated",
+				ListOptions: github.ListOptions{Page: page, PerPage: 100},
+			})
+			if result != nil {
+				batch = result.Issues
+			}
+		} else {
+			batch, response, err = client.Issues.ListByRepo(ctx, filter.Owner, filter.Repo, &github.IssueListByRepoOptions{
+				Labels:      filter.Labels,
+				Milestone:   filter.Milestone,
+				State:       state,
+				Sort:        "updated",
+				ListOptions: github.ListOptions{Page: page, PerPage: 100},
+			})
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch issues - %w", err)
+		}
+
+		issues = append(issues, batch...)
+		page = response.NextPage
+	}
+
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+
+	return issues, nil
+}
+
+// ImportIssues adds a node for each matching issue to a note, and an edge for each
+// reference between issues in the note. Issues already in the note are skipped, use
+// RefreshIssues to update them.
+func ImportIssues(a *axon_types.AxonContext, client *github.Client, importer axon_core.Import, filter IssueFilter, folder_id string, note_id string) (*IssueImportResult, error) {
+	node := axon_core.Node{Session: importer.Session, OwnerEmail: importer.OwnerEmail}
+	edge := axon_core.Edge{Session: importer.Session, OwnerEmail: importer.OwnerEmail}
+
+	existingNodes, err := node.GetNodes(a, folder_id, note_id)
+	if err != nil {
+		return nil, err
+	}
+
+	existingEdges, err := edge.GetEdges(a, folder_id, note_id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, edges, skipped, err := issueGraph(a.Context, client, filter, *existingNodes, *existingEdges)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IssueImportResult{Nodes: []axon_types.Node{}, Edges: []axon_types.Edge{}, Skipped: skipped}
+	if len(nodes) == 0 && len(edges) == 0 {
+		return result, nil
+	}
+
+	result.Nodes, result.Edges, err = importer.AddGraph(a, folder_id, note_id, nodes, edges)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// issueGraph fetches the issues matching a filter and returns the nodes and edges to
+// add to a note holding existingNodes and existingEdges, with the references of the
+// issues that are already in it
+func issueGraph(ctx context.Context, client *github.Client, filter IssueFilter, existingNodes []axon_types.Node, existingEdges []axon_types.Edge) ([]axon_types.Node, []axon_types.Edge, []string, error) {
+	issues, err := FetchIssues(ctx, client, filter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Node ID of each issue reference in the note
+	byReference := map[string]string{}
+	for _, n := range existingNodes {
+		if referenceLabel.MatchString(n.Data.Label) {
+			byReference[strings.ToLower(n.Data.Label)] = n.NodeID
+		}
+	}
+
+	nodes := []axon_types.Node{}
+	skipped := []string{}
+
+	for _, issue := range issues {
+		reference := IssueReference(filter.Owner, filter.Repo, issue.GetNumber())
+		if _, exists := byReference[strings.ToLower(reference)]; exists {
+			skipped = append(skipped, reference)
+			continue
+		}
+
+		merged, err := isMerged(ctx, client, filter.Owner, filter.Repo, issue)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		n := IssueNode(filter.Owner, filter.Repo, issue, merged)
+		n.NodeID = uuid.New().String()
+		n.Position = gridPosition(len(existingNodes) + len(nodes))
+
+		byReference[strings.ToLower(reference)] = n.NodeID
+		nodes = append(nodes, n)
+	}
+
+	connected := map[[2]string]bool{}
+	for _, e := range existingEdges {
+		connected[[2]string{e.SourceID, e.TargetID}] = true
+	}
+
+	edges := []axon_types.Edge{}
+	for _, issue := range issues {
+		source := byReference[strings.ToLower(IssueReference(filter.Owner, filter.Repo, issue.GetNumber()))]
+
+		for _, ref := range IssueReferences(filter.Owner, filter.Repo, issue) {
+			target, ok := byReference[strings.ToLower(ref.Reference)]
+			if !ok || target == source || connected[[2]string{source, target}] {
+				continue
+			}
+			connected[[2]string{source, target}] = true
+
+			label := EDGE_LABEL_REFERENCES
+			if ref.Closes {
+				label = EDGE_LABEL_CLOSES
+			}
+			edges = append(edges, axon_types.Edge{SourceID: source, TargetID: target, Label: label})
+		}
+	}
+
+	return nodes, edges, skipped, nil
+}
+
+// RefreshIssues updates the title, description, body and state category of every
+// node in a note that was imported from an issue. Positions and styles are kept.
+func RefreshIssues(a *axon_types.AxonContext, client *github.Client, node axon_core.Node, folder_id string, note_id string) (*IssueRefreshResult, error) {
+	nodes, err := node.GetNodes(a, folder_id, note_id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IssueRefreshResult{Updated: []string{}, Missing: []string{}}
+
+	for _, n := range *nodes {
+		match := referenceLabel.FindStringSubmatch(n.Data.Label)
+		if match == nil {
+			continue
+		}
+
+		owner, repo := match[1], match[2]
+		number, _ := strconv.Atoi(match[3])
+
+		issue, response, err := client.Issues.Get(a.Context, owner, repo, number)
+		if response != nil && (response.StatusCode == 404 || response.StatusCode == 410) {
+			result.Missing = append(result.Missing, n.Data.Label)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not refresh %s - %w", n.Data.Label, err)
+		}
+
+		merged, err := isMerged(a.Context, client, owner, repo, issue)
+		if err != nil {
+			return nil, err
+		}
+
+		refreshed := IssueNode(owner, repo, issue, merged)
+
+		_, err = node.UpdateNode(a, refreshed.Data, axon_types.Position{}, refreshed.Content, axon_types.NodeStyles{}, folder_id, note_id, n.NodeID)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Updated = append(result.Updated, n.Data.Label)
+	}
+
+	return result, nil
+}
+
+func IssueReference(owner string, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// IssueNode converts an issue or pull request into node data and content
+func IssueNode(owner string, repo string, issue *github.Issue, merged bool) axon_types.Node {
+	kind := "Issue"
+	if issue.IsPullRequest() {
+		kind = "Pull request"
+	}
+
+	description := fmt.Sprintf("%s #%d · %s", kind, issue.GetNumber(), issueState(issue, merged))
+	if login := issue.GetUser().GetLogin(); login != "" {
+		description += " · @" + login
+	}
+	if len(issue.Labels) > 0 {
+		labels := make([]string, len(issue.Labels))
+		for i, label := range issue.Labels {
+			labels[i] = label.GetName()
+		}
+		sort.Strings(labels)
+		description += " · " + strings.Join(labels, ", ")
+	}
+
+	body := strings.TrimSpace(issue.GetBody())
+	if url := issue.GetHTMLURL(); url != "" {
+		if body != "" {
+			body += "\n\n"
+		}
+		body += fmt.Sprintf("[View on GitHub](%s)", url)
+	}
+
+	return axon_types.Node{
+		Data: axon_types.NodeData{
+			Label:        IssueReference(owner, repo, issue.GetNumber()),
+			Title:        issue.GetTitle(),
+			Description:  description,
+			NodeCategory: IssueCategory(issue, merged),
+		},
+		Content: axon_types.NodeContent{MarkDown: body},
+	}
+}
+
+func IssueCategory(issue *github.Issue, merged bool) string {
+	closed := issue.GetState() == "closed"
+
+	switch {
+	case issue.IsPullRequest() && merged:
+		return CATEGORY_PULL_REQUEST_MERGED
+	case issue.IsPullRequest() && closed:
+		return CATEGORY_PULL_REQUEST_CLOSED
+	case issue.IsPullRequest():
+		return CATEGORY_PULL_REQUEST_OPEN
+	case closed:
+		return CATEGORY_ISSUE_CLOSED
+	}
+	return CATEGORY_ISSUE_OPEN
+}
+
+func issueState(issue *github.Issue, merged bool) string {
+	if merged {
+		return "merged"
+	}
+	return issue.GetState()
+}
+
+// Only closed pull requests need the extra request to tell merged from closed
+func isMerged(ctx context.Context, client *github.Client, owner string, repo string, issue *github.Issue) (bool, error) {
+	if !issue.IsPullRequest() || issue.GetState() != "closed" {
+		return false, nil
+	}
+
+	merged, _, err := client.PullRequests.IsMerged(ctx, owner, repo, issue.GetNumber())
+	if err != nil {
+		return false, fmt.Errorf("could not read pull request %d - %w", issue.GetNumber(), err)
+	}
+	return merged, nil
+}
+
+type Reference struct {
+	Reference string
+	// Referenced with a closing keyword, e.g. "fixes #12"
+	Closes bool
+}
+
+// IssueReferences finds the issues referenced in the title and body of an issue.
+// Short references such as #12 are resolved against owner/repo.
+func IssueReferences(owner string, repo string, issue *github.Issue) []Reference {
+	text := issue.GetTitle() + "\n" + issue.GetBody()
+
+	seen := map[string]int{}
+	references := []Reference{}
+
+	for _, match := range referencePattern.FindAllStringSubmatch(text, -1) {
+		refOwner, refRepo, number := match[2], match[3], match[4]
+		if number == "" {
+			refOwner, refRepo, number = match[5], match[6], match[7]
+		}
+		if refOwner == "" {
+			refOwner, refRepo = owner, repo
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+
+		reference := IssueReference(refOwner, refRepo, n)
+		closes := match[1] != ""
+
+		if i, ok := seen[strings.ToLower(reference)]; ok {
+			references[i].Closes = references[i].Closes || closes
+			continue
+		}
+		seen[strings.ToLower(reference)] = len(references)
+		references = append(references, Reference{Reference: reference, Closes: closes})
+	}
+
+	return references
+}
+
+func gridPosition(index int) axon_types.Position {
+	return axon_types.Position{
+		X: (index % gridColumns) * gridWidth,
+		Y: (index / gridColumns) * gridHeight,
+	}
+}
@@ -0,0 +1,131 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+func TestIssueReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		body  string
+		want  []Reference
+	}{
+		{"short", "", "See #12", []Reference{{"axon/app#12", false}}},
+		{"closing keywords", "", "Fixes #1, closed #2, resolves #3 and FIX #4", []Reference{{"axon/app#1", true}, {"axon/app#2", true}, {"axon/app#3", true}, {"axon/app#4", true}}},
+		{"keyword inside a word", "", "prefixes #3", []Reference{{"axon/app#3", false}}},
+		{"cross repository", "", "Needs other/lib.go#7", []Reference{{"other/lib.go#7", false}}},
+		{"issue URL", "", "https://github.com/other/lib/issues/9", []Reference{{"other/lib#9", false}}},
+		{"pull request URL", "", "closes https://github.com/other/lib/pull/10", []Reference{{"other/lib#10", true}}},
+		{"title and body", "Part of #1", "and #2", []Reference{{"axon/app#1", false}, {"axon/app#2", false}}},
+		{"repeated", "", "#4, then fixes #4 and AXON/APP#4", []Reference{{"axon/app#4", true}}},
+		{"not references", "", "colour #fff, #12abc and issue #", []Reference{}},
+	}
+
+	for _, test := range tests {
+		issue := &github.Issue{Title: github.String(test.title), Body: github.String(test.body)}
+		if got := IssueReferences("axon", "app", issue); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: references = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIssueCategory(t *testing.T) {
+	pull := &github.PullRequestLinks{URL: github.String("https://api.github.com/repos/axon/app/pulls/1")}
+
+	tests := []struct {
+		name   string
+		issue  *github.Issue
+		merged bool
+		want   string
+	}{
+		{"open issue", &github.Issue{State: github.String("open")}, false, CATEGORY_ISSUE_OPEN},
+		{"closed issue", &github.Issue{State: github.String("closed")}, false, CATEGORY_ISSUE_CLOSED},
+		{"open pull request", &github.Issue{State: github.String("open"), PullRequestLinks: pull}, false, CATEGORY_PULL_REQUEST_OPEN},
+		{"closed pull request", &github.Issue{State: github.String("closed"), PullRequestLinks: pull}, false, CATEGORY_PULL_REQUEST_CLOSED},
+		{"merged pull request", &github.Issue{State: github.String("closed"), PullRequestLinks: pull}, true, CATEGORY_PULL_REQUEST_MERGED},
+	}
+
+	for _, test := range tests {
+		if got := IssueCategory(test.issue, test.merged); got != test.want {
+			t.Errorf("%s: category = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// Issue 2 is already in the note, so it is skipped, and issue 1 is connected to the
+// existing node
+func TestImportIssuesSkipsExisting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/axon/app/issues":
+			if state := r.URL.Query().Get("state"); state != "all" {
+				t.Errorf("state = %q, want all", state)
+			}
+			fmt.Fprint(w, `[
+				{"number": 1, "title": "Crash", "state": "open", "body": "fixes #2"},
+				{"number": 2, "title": "Old", "state": "closed"},
+				{"number": 3, "title": "Patch", "state": "closed", "body": "refs #1", "pull_request": {"url": "https://api.github.com/repos/axon/app/pulls/3"}}
+			]`)
+		case "GET /repos/axon/app/pulls/3/merge":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	base, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = base
+
+	existing := []axon_types.Node{{NodeID: "existing", Data: axon_types.NodeData{Label: "axon/app#2"}}}
+	filter := IssueFilter{Owner: "axon", Repo: "app", State: "all"}
+
+	nodes, edges, skipped, err := issueGraph(context.Background(), client, filter, existing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(skipped, []string{"axon/app#2"}) {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	if len(nodes) != 2 {
+		t.Fatalf("nodes = %+v", nodes)
+	}
+	for i, want := range []struct {
+		label    string
+		category string
+		position axon_types.Position
+	}{
+		{"axon/app#1", CATEGORY_ISSUE_OPEN, gridPosition(1)},
+		{"axon/app#3", CATEGORY_PULL_REQUEST_MERGED, gridPosition(2)},
+	} {
+		node := nodes[i]
+		if node.Data.Label != want.label || node.Data.NodeCategory != want.category || node.Position != want.position {
+			t.Errorf("node %d = %+v, want %s %s at %v", i, node.Data, want.label, want.category, want.position)
+		}
+	}
+
+	want := []axon_types.Edge{
+		{SourceID: nodes[0].NodeID, TargetID: "existing", Label: EDGE_LABEL_CLOSES},
+		{SourceID: nodes[1].NodeID, TargetID: nodes[0].NodeID, Label: EDGE_LABEL_REFERENCES},
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges = %+v, want %+v", edges, want)
+	}
+}
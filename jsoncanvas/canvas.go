@@ -0,0 +1,87 @@
+package jsoncanvas
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Key of the fields kept in Node.Extra and Edge.Extra
+const FORMAT = "jsoncanvas"
+
+const (
+	NodeText  string = "text"
+	NodeFile  string = "file"
+	NodeLink  string = "link"
+	NodeGroup string = "group"
+)
+
+// Categories given to file and link nodes, which have no equivalent in a note
+const (
+	CategoryFile string = "file"
+	CategoryLink string = "link"
+)
+
+// presetColors are the six colours JSON Canvas numbers instead of writing them out,
+// as Obsidian draws them
+var presetColors = map[string]string{
+	"1": "#fb464c",
+	"2": "#e9973f",
+	"3": "#e0de71",
+	"4": "#44cf6e",
+	"5": "#53dfdd",
+	"6": "#a882ff",
+}
+
+// Fields read into nodes and edges, anything else is kept as extra
+var (
+	nodeFields = []string{"id", "type", "x", "y", "width", "height", "color", "text", "label"}
+	edgeFields = []string{"id", "fromNode", "toNode", "label"}
+)
+
+// Problem is part of a canvas that could not be imported as written
+type Problem struct {
+	Message string `json:"message"`
+}
+
+// presetColor returns the preset number of a colour, or the colour itself
+func presetColor(color string) string {
+	for preset, hex := range presetColors {
+		if strings.EqualFold(hex, color) {
+			return preset
+		}
+	}
+	return color
+}
+
+// hexColor returns the colour of a preset number, or the colour itself
+func hexColor(color string) string {
+	if hex, ok := presetColors[color]; ok {
+		return hex
+	}
+	return color
+}
+
+// split separates the known fields of a JSON object from the rest
+func split(raw map[string]json.RawMessage, known []string) (map[string]json.RawMessage, map[string]interface{}, error) {
+	fields := map[string]json.RawMessage{}
+	extra := map[string]interface{}{}
+
+	isKnown := map[string]bool{}
+	for _, name := range known {
+		isKnown[name] = true
+	}
+
+	for name, value := range raw {
+		if isKnown[name] {
+			fields[name] = value
+			continue
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return nil, nil, err
+		}
+		extra[name] = decoded
+	}
+
+	return fields, extra, nil
+}
@@ -0,0 +1,162 @@
+package jsoncanvas
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// A note written as a canvas and read back keeps every field of its nodes and edges,
+// apart from the IDs, which are new
+func TestNoteRoundTrip(t *testing.T) {
+	note := axon_types.NoteDetail{
+		Nodes: []axon_types.Node{
+			{
+				NodeID:   "g",
+				Data:     axon_types.NodeData{Label: "Group", NodeCategory: axon_types.NODE_CATEGORY_GROUP},
+				Position: axon_types.Position{X: -20, Y: -20},
+				Styles: axon_types.NodeStyles{BackgroundStyles: map[string]interface{}{
+					axon_types.STYLE_WIDTH: 700, axon_types.STYLE_HEIGHT: 200,
+				}},
+			},
+			{
+				NodeID:   "a",
+				Data:     axon_types.NodeData{Title: "Plan", Label: "P", Description: "The plan", NodeCategory: "process"},
+				Position: axon_types.Position{X: 0, Y: 0},
+				Content:  axon_types.NodeContent{MarkDown: "# Heading\n\nBody"},
+				Styles: axon_types.NodeStyles{
+					BackgroundStyles: map[string]interface{}{
+						axon_types.STYLE_WIDTH: 240, axon_types.STYLE_HEIGHT: 120,
+						axon_types.STYLE_BACKGROUND_COLOR: "#fef3c7", axon_types.STYLE_BORDER_COLOR: "#fb464c",
+					},
+					LabelStyles: map[string]interface{}{"fontSize": 14.0},
+				},
+				ParentID: "g",
+			},
+			{
+				// Without Markdown the canvas text is made from the title and description
+				NodeID:   "b",
+				Data:     axon_types.NodeData{Title: "Only title", Description: "desc"},
+				Position: axon_types.Position{X: 400, Y: 0},
+				Styles: axon_types.NodeStyles{BackgroundStyles: map[string]interface{}{
+					axon_types.STYLE_WIDTH: 200, axon_types.STYLE_HEIGHT: 100,
+				}},
+				ParentID: "g",
+			},
+		},
+		Edges: []axon_types.Edge{
+			{EdgeID: "e1", SourceID: "a", TargetID: "b", Label: "next", Animated: true, EdgeType: "smoothstep"},
+		},
+	}
+
+	canvas, err := Export(note)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Import(canvas, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Problems) != 0 {
+		t.Errorf("problems = %v", result.Problems)
+	}
+	if len(result.Nodes) != len(note.Nodes) || len(result.Edges) != len(note.Edges) {
+		t.Fatalf("imported %d nodes and %d edges:\n%s", len(result.Nodes), len(result.Edges), canvas)
+	}
+
+	// Groups are written first, then the nodes in reading order, as they are listed here
+	ids := map[string]string{}
+	for i, node := range result.Nodes {
+		ids[node.NodeID] = note.Nodes[i].NodeID
+	}
+
+	for i, node := range result.Nodes {
+		node.NodeID, node.ParentID = ids[node.NodeID], ids[node.ParentID]
+		if !reflect.DeepEqual(node, note.Nodes[i]) {
+			t.Errorf("node %s = %+v\nwant %+v", note.Nodes[i].NodeID, node, note.Nodes[i])
+		}
+	}
+
+	edge := result.Edges[0]
+	edge.EdgeID, edge.SourceID, edge.TargetID = "e1", ids[edge.SourceID], ids[edge.TargetID]
+	if !reflect.DeepEqual(edge, note.Edges[0]) {
+		t.Errorf("edge = %+v\nwant %+v", edge, note.Edges[0])
+	}
+}
+
+// A canvas read as a note and written back is unchanged, apart from the IDs, with no
+// extension added for what the canvas already says
+func TestCanvasRoundTrip(t *testing.T) {
+	canvas := `{
+		"nodes": [
+			{"id": "g1", "type": "group", "x": -20, "y": -20, "width": 700, "height": 300, "label": "Sources", "background": "bg.png"},
+			{"id": "t1", "type": "text", "x": 0, "y": 0, "width": 250, "height": 60, "text": "# Idea\n\nDetails", "color": "4"},
+			{"id": "f1", "type": "file", "x": 300, "y": 0, "width": 250, "height": 60, "file": "notes/a.md", "subpath": "#x"},
+			{"id": "l1", "type": "link", "x": 0, "y": 100, "width": 250, "height": 60, "url": "https://example.com"}
+		],
+		"edges": [
+			{"id": "e1", "fromNode": "t1", "toNode": "f1", "label": "see", "fromSide": "right", "toEnd": "arrow"}
+		]
+	}`
+
+	result, err := Import(canvas, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Problems) != 0 {
+		t.Errorf("problems = %v", result.Problems)
+	}
+
+	exported, err := Export(axon_types.NoteDetail{Nodes: result.Nodes, Edges: result.Edges})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Put the canvas IDs back, in the order the nodes and edges were read
+	var want, got map[string][]map[string]interface{}
+	if err := json.Unmarshal([]byte(canvas), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(exported), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[interface{}]interface{}{}
+	for i, node := range result.Nodes {
+		ids[node.NodeID] = want["nodes"][i]["id"]
+	}
+	for i, edge := range result.Edges {
+		ids[edge.EdgeID] = want["edges"][i]["id"]
+	}
+	for _, list := range got {
+		for _, object := range list {
+			for _, field := range []string{"id", "fromNode", "toNode"} {
+				if id, ok := object[field]; ok {
+					object[field] = ids[id]
+				}
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exported canvas differs:\n%s", exported)
+	}
+}
+
+func TestImportInvalidExtension(t *testing.T) {
+	result, err := Import(`{"nodes": [{"id": "t1", "type": "text", "text": "Hi", "axon": "title"}]}`, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Problems) != 1 || !strings.Contains(result.Problems[0].Message, "axon of node t1 is not an object") {
+		t.Errorf("problems = %v", result.Problems)
+	}
+	if value := result.Nodes[0].Extra[FORMAT][EXTENSION]; value != "title" {
+		t.Errorf("extension kept as %v, want it in extra", value)
+	}
+}
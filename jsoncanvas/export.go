@@ -0,0 +1,185 @@
+package jsoncanvas
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	axon_layout "github.com/stephensanwo/axon-lib/layout"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Export writes a note as a JSON Canvas document. Group nodes are written first, the
+// largest first, so they are drawn behind their members, then the other nodes in
+// reading order. Fields kept from an import are written back, fields of the note with
+// no place in the format are written under EXTENSION, and edges to nodes that are not
+// in the note are left out.
+func Export(note axon_types.NoteDetail) (string, error) {
+	nodes := append([]axon_types.Node{}, note.Nodes...)
+	areas := map[string]float64{}
+	for _, node := range nodes {
+		if node.Data.NodeCategory == axon_types.NODE_CATEGORY_GROUP {
+			_, _, width, height := groupBounds(node, note.Nodes)
+			areas[node.NodeID] = width * height
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		aGroup, bGroup := isGroup(a), isGroup(b)
+		if aGroup != bGroup {
+			return aGroup
+		}
+		if aGroup && areas[a.NodeID] != areas[b.NodeID] {
+			return areas[a.NodeID] > areas[b.NodeID]
+		}
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		if a.Position.X != b.Position.X {
+			return a.Position.X < b.Position.X
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	written := map[string]bool{}
+	canvasNodes := make([]map[string]interface{}, 0, len(nodes))
+
+	for _, node := range nodes {
+		object := extraFields(node.Extra)
+		object["id"] = node.NodeID
+		written[node.NodeID] = true
+
+		x, y, width, height := float64(node.Position.X), float64(node.Position.Y), 0.0, 0.0
+		if isGroup(node) {
+			x, y, width, height = groupBounds(node, note.Nodes)
+		} else {
+			_, _, width, height = bounds(node)
+		}
+		object["x"], object["y"], object["width"], object["height"] = round(x), round(y), round(width), round(height)
+
+		if color := axon_types.StyleString(node.Styles.BackgroundStyles, axon_types.STYLE_BORDER_COLOR); color != "" {
+			object["color"] = presetColor(color)
+		}
+
+		kind, _ := object["type"].(string)
+		switch {
+		case isGroup(node):
+			object["type"] = NodeGroup
+			if label := nodeText(node); label != "" {
+				object["label"] = label
+			}
+		case kind == NodeFile && object["file"] != nil, kind == NodeLink && object["url"] != nil:
+			// Written back as the file or link it was imported as
+		case kind != "" && kind != NodeText && kind != NodeFile && kind != NodeLink:
+			// A type this package does not know, written back unchanged
+		default:
+			object["type"] = NodeText
+			object["text"] = nodeMarkdown(node)
+		}
+
+		if extension := nodeExtension(node, object); extension != nil {
+			object[EXTENSION] = extension
+		}
+
+		canvasNodes = append(canvasNodes, object)
+	}
+
+	edges := append([]axon_types.Edge{}, note.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.SourceID != b.SourceID {
+			return a.SourceID < b.SourceID
+		}
+		if a.TargetID != b.TargetID {
+			return a.TargetID < b.TargetID
+		}
+		return a.EdgeID < b.EdgeID
+	})
+
+	canvasEdges := make([]map[string]interface{}, 0, len(edges))
+	for _, edge := range edges {
+		if !written[edge.SourceID] || !written[edge.TargetID] {
+			continue
+		}
+
+		object := extraFields(edge.Extra)
+		object["id"] = edge.EdgeID
+		object["fromNode"] = edge.SourceID
+		object["toNode"] = edge.TargetID
+		if edge.Label != "" {
+			object["label"] = edge.Label
+		}
+		if extension := edgeExtension(edge); extension != nil {
+			object[EXTENSION] = extension
+		}
+
+		canvasEdges = append(canvasEdges, object)
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"nodes": canvasNodes, "edges": canvasEdges}, "", "\t")
+	if err != nil {
+		return "", errors.New("could not encode canvas - " + err.Error())
+	}
+	return string(data) + "\n", nil
+}
+
+// extraFields copies the fields kept from an import, to add the known fields to
+func extraFields(extra map[string]map[string]interface{}) map[string]interface{} {
+	object := map[string]interface{}{}
+	for name, value := range extra[FORMAT] {
+		object[name] = value
+	}
+	return object
+}
+
+func isGroup(node axon_types.Node) bool {
+	return node.Data.NodeCategory == axon_types.NODE_CATEGORY_GROUP
+}
+
+// groupBounds is the stored size of a group, or the area around its members when it
+// has none
+func groupBounds(group axon_types.Node, nodes []axon_types.Node) (float64, float64, float64, float64) {
+	x, y, width, height := bounds(group)
+	if _, ok := axon_types.StyleNumber(group.Styles.BackgroundStyles, axon_types.STYLE_WIDTH); ok {
+		return x, y, width, height
+	}
+
+	shape := axon_layout.Graph{Groups: []axon_layout.Group{{ID: group.NodeID}}}
+	positions := map[string]axon_types.Position{}
+	for _, node := range nodes {
+		if node.ParentID == group.NodeID && node.NodeID != group.NodeID {
+			shape.Nodes = append(shape.Nodes, axon_layout.Node{ID: node.NodeID, Group: group.NodeID})
+			positions[node.NodeID] = node.Position
+		}
+	}
+
+	box := axon_layout.Boxes(shape, positions, group.Position)[group.NodeID]
+	return float64(box.X), float64(box.Y), float64(box.Width), float64(box.Height)
+}
+
+// nodeMarkdown is the content of a node, or its title and description when it has none
+func nodeMarkdown(node axon_types.Node) string {
+	if node.Content.MarkDown != "" {
+		return node.Content.MarkDown
+	}
+
+	parts := []string{}
+	if text := nodeText(node); text != "" {
+		parts = append(parts, text)
+	}
+	if node.Data.Description != "" {
+		parts = append(parts, node.Data.Description)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func nodeText(node axon_types.Node) string {
+	for _, text := range []string{node.Data.Title, node.Data.Label} {
+		if strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return ""
+}
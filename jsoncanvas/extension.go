@@ -0,0 +1,163 @@
+package jsoncanvas
+
+import (
+	"fmt"
+	"path"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// EXTENSION is the key of the object on canvas nodes and edges holding the fields of a
+// note that JSON Canvas has no place for. Other canvas apps keep unknown keys as they
+// are, so the fields come back on import.
+const EXTENSION = "axon"
+
+// Background styles written as canvas fields, and not repeated in the extension
+var canvasStyles = map[string]bool{
+	axon_types.STYLE_WIDTH:        true,
+	axon_types.STYLE_HEIGHT:       true,
+	axon_types.STYLE_BORDER_COLOR: true,
+}
+
+// nodeExtension returns the fields of a node that import would not read back from the
+// canvas object written for it, or nil when there are none
+func nodeExtension(node axon_types.Node, object map[string]interface{}) map[string]interface{} {
+	kind, _ := object["type"].(string)
+	text, _ := object["text"].(string)
+	extension := map[string]interface{}{}
+
+	title, category := derived(kind, object)
+	if node.Data.Title != title {
+		extension["title"] = node.Data.Title
+	}
+	if node.Data.Label != "" {
+		extension["label"] = node.Data.Label
+	}
+	if node.Data.Description != "" {
+		extension["description"] = node.Data.Description
+	}
+	if node.Data.NodeCategory != category {
+		extension["category"] = node.Data.NodeCategory
+	}
+
+	markdown := ""
+	if kind == NodeText {
+		markdown = text
+	}
+	if node.Content.MarkDown != markdown {
+		extension["markdown"] = node.Content.MarkDown
+	}
+
+	background := map[string]interface{}{}
+	for name, value := range node.Styles.BackgroundStyles {
+		if !canvasStyles[name] {
+			background[name] = value
+		}
+	}
+	for name, styles := range map[string]map[string]interface{}{
+		"background_styles":  background,
+		"label_styles":       node.Styles.LabelStyles,
+		"description_styles": node.Styles.DescriptionStyles,
+	} {
+		if len(styles) > 0 {
+			extension[name] = styles
+		}
+	}
+
+	if len(extension) == 0 {
+		return nil
+	}
+	return extension
+}
+
+// edgeExtension returns the fields of an edge JSON Canvas has no place for, or nil
+func edgeExtension(edge axon_types.Edge) map[string]interface{} {
+	extension := map[string]interface{}{}
+	if edge.Animated {
+		extension["animated"] = true
+	}
+	if edge.EdgeType != "" {
+		extension["edge_type"] = edge.EdgeType
+	}
+
+	if len(extension) == 0 {
+		return nil
+	}
+	return extension
+}
+
+// derived is the title and category import gives a canvas node of a type
+func derived(kind string, object map[string]interface{}) (string, string) {
+	switch kind {
+	case NodeText:
+		text, _ := object["text"].(string)
+		return titleOf(text), ""
+	case NodeGroup:
+		label, _ := object["label"].(string)
+		return label, axon_types.NODE_CATEGORY_GROUP
+	case NodeFile:
+		file, _ := object["file"].(string)
+		return path.Base(file), CategoryFile
+	case NodeLink:
+		url, _ := object["url"].(string)
+		return url, CategoryLink
+	}
+	return "", ""
+}
+
+// takeExtension removes the extension from the extra fields of a node or edge. A value
+// that is not an object is left in extra, to be written back as it was.
+func takeExtension(extra map[string]interface{}, id string) (map[string]interface{}, *Problem) {
+	value, ok := extra[EXTENSION]
+	if !ok {
+		return nil, nil
+	}
+
+	extension, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, &Problem{Message: fmt.Sprintf("%s of %s is not an object and is kept as it is", EXTENSION, id)}
+	}
+
+	delete(extra, EXTENSION)
+	return extension, nil
+}
+
+// applyNodeExtension restores the fields of a node written by nodeExtension
+func applyNodeExtension(node *axon_types.Node, extension map[string]interface{}) {
+	for name, field := range map[string]*string{
+		"title":       &node.Data.Title,
+		"label":       &node.Data.Label,
+		"description": &node.Data.Description,
+		"category":    &node.Data.NodeCategory,
+		"markdown":    &node.Content.MarkDown,
+	} {
+		if value, ok := extension[name].(string); ok {
+			*field = value
+		}
+	}
+
+	// Canvas fields win, they may have been changed in another app
+	if background, ok := extension["background_styles"].(map[string]interface{}); ok {
+		for name, value := range background {
+			if _, set := node.Styles.BackgroundStyles[name]; !set {
+				node.Styles.BackgroundStyles[name] = value
+			}
+		}
+	}
+	if styles, ok := extension["label_styles"].(map[string]interface{}); ok {
+		node.Styles.LabelStyles = styles
+	}
+	if styles, ok := extension["description_styles"].(map[string]interface{}); ok {
+		node.Styles.DescriptionStyles = styles
+	}
+}
+
+// applyEdgeExtension restores the fields of an edge written by edgeExtension
+func applyEdgeExtension(edge *axon_types.Edge, extension map[string]interface{}) {
+	if animated, ok := extension["animated"].(bool); ok {
+		edge.Animated = animated
+	}
+	if edgeType, ok := extension["edge_type"].(string); ok {
+		edge.EdgeType = edgeType
+	}
+}
@@ -0,0 +1,297 @@
+package jsoncanvas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	axon_layout "github.com/stephensanwo/axon-lib/layout"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type ImportOptions struct {
+	// Top left corner of the imported canvas. When zero, canvas positions are kept as
+	// they are.
+	Origin axon_types.Position
+}
+
+// ImportResult holds the nodes and edges of a canvas, with new IDs, ready to be added
+// to a note
+type ImportResult struct {
+	Nodes    []axon_types.Node
+	Edges    []axon_types.Edge
+	Problems []Problem
+}
+
+// canvasNode is a node with the fields every type shares, decoded from a JSON object
+type canvasNode struct {
+	ID     string  `json:"id"`
+	Type   string  `json:"type"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Color  string  `json:"color"`
+	Text   string  `json:"text"`
+	Label  string  `json:"label"`
+}
+
+type canvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	ToNode   string `json:"toNode"`
+	Label    string `json:"label"`
+}
+
+// Import reads a JSON Canvas document. Text nodes keep their Markdown as node content,
+// groups become group nodes and their members are the nodes drawn inside them, as
+// canvas groups are. Fields with no equivalent are kept in Extra, so Export writes them
+// back, and the fields Export writes under EXTENSION are restored.
+func Import(content string, options ImportOptions) (*ImportResult, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &document); err != nil {
+		return nil, errors.New("could not parse canvas - " + err.Error())
+	}
+
+	result := &ImportResult{Nodes: []axon_types.Node{}, Edges: []axon_types.Edge{}, Problems: []Problem{}}
+
+	unknown := []string{}
+	for name := range document {
+		if name != "nodes" && name != "edges" {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		result.Problems = append(result.Problems, Problem{Message: fmt.Sprintf("canvas field %q is not kept, only fields of nodes and edges are", name)})
+	}
+
+	var rawNodes, rawEdges []map[string]json.RawMessage
+	if err := decodeList(document, "nodes", &rawNodes); err != nil {
+		return nil, err
+	}
+	if err := decodeList(document, "edges", &rawEdges); err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{}
+	groups := []int{}
+
+	for i, raw := range rawNodes {
+		fields, extra, err := split(raw, nodeFields)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse canvas node %d - %s", i+1, err.Error())
+		}
+
+		var node canvasNode
+		if err := decodeFields(fields, &node); err != nil {
+			return nil, fmt.Errorf("could not parse canvas node %d - %s", i+1, err.Error())
+		}
+
+		if node.ID == "" {
+			result.Problems = append(result.Problems, Problem{Message: fmt.Sprintf("node %d has no id and is skipped", i+1)})
+			continue
+		}
+		if _, duplicate := ids[node.ID]; duplicate {
+			result.Problems = append(result.Problems, Problem{Message: fmt.Sprintf("node id %s is used twice, the second node is skipped", node.ID)})
+			continue
+		}
+		ids[node.ID] = uuid.New().String()
+
+		extension, problem := takeExtension(extra, "node "+node.ID)
+		if problem != nil {
+			result.Problems = append(result.Problems, *problem)
+		}
+
+		imported := axon_types.Node{
+			NodeID:   ids[node.ID],
+			Position: axon_types.Position{X: round(node.X), Y: round(node.Y)},
+			Styles: axon_types.NodeStyles{
+				BackgroundStyles: map[string]interface{}{
+					axon_types.STYLE_WIDTH:  round(node.Width),
+					axon_types.STYLE_HEIGHT: round(node.Height),
+				},
+			},
+		}
+		if node.Color != "" {
+			imported.Styles.BackgroundStyles[axon_types.STYLE_BORDER_COLOR] = hexColor(node.Color)
+		}
+
+		switch node.Type {
+		case NodeText:
+			imported.Data.Title = titleOf(node.Text)
+			imported.Content.MarkDown = node.Text
+		case NodeGroup:
+			imported.Data.Title = node.Label
+			imported.Data.NodeCategory = axon_types.NODE_CATEGORY_GROUP
+			groups = append(groups, len(result.Nodes))
+		case NodeFile:
+			file, _ := extra["file"].(string)
+			imported.Data.Title = path.Base(file)
+			imported.Data.NodeCategory = CategoryFile
+			extra["type"] = node.Type
+		case NodeLink:
+			imported.Data.Title, _ = extra["url"].(string)
+			imported.Data.NodeCategory = CategoryLink
+			extra["type"] = node.Type
+		default:
+			result.Problems = append(result.Problems, Problem{Message: fmt.Sprintf("node %s has unknown type %q, it is imported as an empty node", node.ID, node.Type)})
+			extra["type"] = node.Type
+		}
+
+		// Fields of other types are kept too, in case a newer version of the format adds them
+		if node.Type != NodeText && node.Text != "" {
+			extra["text"] = node.Text
+		}
+		if node.Type != NodeGroup && node.Label != "" {
+			extra["label"] = node.Label
+		}
+
+		applyNodeExtension(&imported, extension)
+
+		if len(extra) > 0 {
+			imported.Extra = map[string]map[string]interface{}{FORMAT: extra}
+		}
+
+		result.Nodes = append(result.Nodes, imported)
+	}
+
+	assignGroups(result.Nodes, groups)
+	moveTo(result.Nodes, options.Origin)
+
+	for i, raw := range rawEdges {
+		fields, extra, err := split(raw, edgeFields)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse canvas edge %d - %s", i+1, err.Error())
+		}
+
+		var edge canvasEdge
+		if err := decodeFields(fields, &edge); err != nil {
+			return nil, fmt.Errorf("could not parse canvas edge %d - %s", i+1, err.Error())
+		}
+
+		source, sourceOk := ids[edge.FromNode]
+		target, targetOk := ids[edge.ToNode]
+		if !sourceOk || !targetOk {
+			result.Problems = append(result.Problems, Problem{Message: fmt.Sprintf("edge %s connects a node that is not in the canvas and is skipped", edge.ID)})
+			continue
+		}
+
+		imported := axon_types.Edge{
+			EdgeID:   uuid.New().String(),
+			SourceID: source,
+			TargetID: target,
+			Label:    edge.Label,
+		}
+
+		extension, problem := takeExtension(extra, "edge "+edge.ID)
+		if problem != nil {
+			result.Problems = append(result.Problems, *problem)
+		}
+		applyEdgeExtension(&imported, extension)
+		if len(extra) > 0 {
+			imported.Extra = map[string]map[string]interface{}{FORMAT: extra}
+		}
+
+		result.Edges = append(result.Edges, imported)
+	}
+
+	return result, nil
+}
+
+func decodeList(document map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := document[name]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("could not parse canvas %s - %s", name, err.Error())
+	}
+	return nil
+}
+
+func decodeFields(fields map[string]json.RawMessage, v interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// assignGroups makes each node a member of the smallest group drawn around it
+func assignGroups(nodes []axon_types.Node, groups []int) {
+	for i := range nodes {
+		best, bestArea := -1, math.Inf(1)
+		x, y, width, height := bounds(nodes[i])
+
+		for _, g := range groups {
+			if g == i {
+				continue
+			}
+			gx, gy, gWidth, gHeight := bounds(nodes[g])
+			area := gWidth * gHeight
+			inside := x >= gx && y >= gy && x+width <= gx+gWidth && y+height <= gy+gHeight
+			// A group of the same size as the node cannot be told apart from it
+			if inside && area > width*height && area < bestArea {
+				best, bestArea = g, area
+			}
+		}
+
+		if best >= 0 {
+			nodes[i].ParentID = nodes[best].NodeID
+		}
+	}
+}
+
+func bounds(node axon_types.Node) (float64, float64, float64, float64) {
+	width, ok := axon_types.StyleNumber(node.Styles.BackgroundStyles, axon_types.STYLE_WIDTH)
+	if !ok {
+		width = axon_layout.NodeWidth
+	}
+	height, ok := axon_types.StyleNumber(node.Styles.BackgroundStyles, axon_types.STYLE_HEIGHT)
+	if !ok {
+		height = axon_layout.NodeHeight
+	}
+	return float64(node.Position.X), float64(node.Position.Y), width, height
+}
+
+// moveTo shifts the nodes so the top left one is at origin, unless origin is zero
+func moveTo(nodes []axon_types.Node, origin axon_types.Position) {
+	if len(nodes) == 0 || origin == (axon_types.Position{}) {
+		return
+	}
+
+	minX, minY := nodes[0].Position.X, nodes[0].Position.Y
+	for _, node := range nodes {
+		if node.Position.X < minX {
+			minX = node.Position.X
+		}
+		if node.Position.Y < minY {
+			minY = node.Position.Y
+		}
+	}
+
+	for i := range nodes {
+		nodes[i].Position.X += origin.X - minX
+		nodes[i].Position.Y += origin.Y - minY
+	}
+}
+
+// titleOf is the first line of Markdown text, without heading marks
+func titleOf(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#")); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func round(f float64) int {
+	return int(math.Round(f))
+}
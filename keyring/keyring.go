@@ -0,0 +1,179 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+const keySize = 32
+
+// KeyRing holds the locally configured key encryption keys. New values are always
+// sealed with the active key, older keys are kept to open existing records.
+type KeyRing struct {
+	activeKeyId string
+	keys        map[string][]byte
+}
+
+func New(settings axon_types.SecuritySettings) (*KeyRing, error) {
+	if settings.ActiveKeyId == "" {
+		return nil, errors.New("key ring is not configured - active_key_id is empty")
+	}
+
+	keys := make(map[string][]byte, len(settings.Keys))
+	for keyId, encoded := range settings.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key ring key %s is not valid base64 - %s", keyId, err.Error())
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key ring key %s must be %d bytes, got %d", keyId, keySize, len(key))
+		}
+		keys[keyId] = key
+	}
+
+	if _, ok := keys[settings.ActiveKeyId]; !ok {
+		return nil, fmt.Errorf("key ring active key %s is not in keys", settings.ActiveKeyId)
+	}
+
+	return &KeyRing{activeKeyId: settings.ActiveKeyId, keys: keys}, nil
+}
+
+func (k *KeyRing) ActiveKeyId() string {
+	return k.activeKeyId
+}
+
+// Seal encrypts plaintext under a fresh data key, and wraps the data key with the
+// active key. The additional data binds the value to the record it is stored in.
+func (k *KeyRing) Seal(plaintext []byte, additionalData []byte) (*axon_types.SealedField, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := encrypt(k.keys[k.activeKeyId], dataKey, []byte(k.activeKeyId))
+	if err != nil {
+		return nil, err
+	}
+
+	return &axon_types.SealedField{
+		KeyId:      k.activeKeyId,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrappedKey),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+func (k *KeyRing) Open(field *axon_types.SealedField, additionalData []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(field)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(field.Ciphertext)
+	if err != nil {
+		return nil, errors.New("could not open sealed field - " + err.Error())
+	}
+
+	plaintext, err := decrypt(dataKey, ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.New("could not open sealed field - " + err.Error())
+	}
+
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether a field was sealed with a key other than the active key
+func (k *KeyRing) NeedsRotation(field *axon_types.SealedField) bool {
+	return field != nil && field.KeyId != k.activeKeyId
+}
+
+// Rewrap re-encrypts the data key of a field with the active key. The ciphertext
+// itself is unchanged, which is what makes rotating keys cheap.
+func (k *KeyRing) Rewrap(field *axon_types.SealedField) (*axon_types.SealedField, error) {
+	dataKey, err := k.unwrap(field)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := encrypt(k.keys[k.activeKeyId], dataKey, []byte(k.activeKeyId))
+	if err != nil {
+		return nil, err
+	}
+
+	return &axon_types.SealedField{
+		KeyId:      k.activeKeyId,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrappedKey),
+		Ciphertext: field.Ciphertext,
+	}, nil
+}
+
+func (k *KeyRing) unwrap(field *axon_types.SealedField) ([]byte, error) {
+	if field == nil {
+		return nil, errors.New("could not open sealed field - field is empty")
+	}
+
+	key, ok := k.keys[field.KeyId]
+	if !ok {
+		return nil, fmt.Errorf("could not open sealed field - unknown key %s", field.KeyId)
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(field.WrappedKey)
+	if err != nil {
+		return nil, errors.New("could not open sealed field - " + err.Error())
+	}
+
+	dataKey, err := decrypt(key, wrappedKey, []byte(field.KeyId))
+	if err != nil {
+		return nil, errors.New("could not unwrap data key - " + err.Error())
+	}
+
+	return dataKey, nil
+}
+
+// encrypt returns nonce || AES-GCM ciphertext
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
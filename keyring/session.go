@@ -0,0 +1,68 @@
+package keyring
+
+import (
+	"encoding/json"
+	"fmt"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+	"golang.org/x/oauth2"
+)
+
+// SealSession returns a copy of the session that is safe to persist, with the OAuth
+// token sealed and bound to the session ID.
+func (k *KeyRing) SealSession(session axon_types.Session) (*axon_types.Session, error) {
+	sealed := session
+	sealed.SessionData.AccessToken = ""
+
+	if session.SessionData.Token != nil {
+		token, err := json.Marshal(session.SessionData.Token)
+		if err != nil {
+			return nil, fmt.Errorf("could not seal session - %s", err.Error())
+		}
+
+		field, err := k.Seal(token, sessionAdditionalData(session.SessionId))
+		if err != nil {
+			return nil, fmt.Errorf("could not seal session - %s", err.Error())
+		}
+		sealed.SessionData.SealedToken = field
+	}
+	sealed.SessionData.Token = nil
+
+	return &sealed, nil
+}
+
+// OpenSession decrypts the sealed fields of a session loaded from the database in place,
+// and moves a plaintext access token stored before sessions were sealed into Token.
+// It returns true when the session should be sealed and persisted again: it was sealed
+// with a retired key, or it still holds a plaintext token.
+func (k *KeyRing) OpenSession(session *axon_types.Session) (bool, error) {
+	if legacy := session.SessionData.AccessToken; legacy != "" {
+		session.SessionData.AccessToken = ""
+		if session.SessionData.SealedToken == nil {
+			session.SessionData.Token = &oauth2.Token{AccessToken: legacy, TokenType: "Bearer"}
+			return true, nil
+		}
+	}
+
+	field := session.SessionData.SealedToken
+	if field == nil {
+		return false, nil
+	}
+
+	plaintext, err := k.Open(field, sessionAdditionalData(session.SessionId))
+	if err != nil {
+		return false, err
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return false, fmt.Errorf("could not open session token - %s", err.Error())
+	}
+	session.SessionData.Token = &token
+
+	return k.NeedsRotation(field), nil
+}
+
+func sessionAdditionalData(sessionId string) []byte {
+	return []byte(fmt.Sprintf("SESSION#%s", sessionId))
+}
@@ -0,0 +1,273 @@
+package layout
+
+import (
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Grid sized for the default node in the editor
+const (
+	ColumnWidth  = 240
+	RowHeight    = 160
+	NodeWidth    = 180
+	NodeHeight   = 60
+	GroupPadding = 40
+	// Room for the group title above its members
+	GroupHeader = 40
+)
+
+const (
+	DirectionTopDown   string = "TD"
+	DirectionLeftRight string = "LR"
+	DirectionBottomUp  string = "BT"
+	DirectionRightLeft string = "RL"
+)
+
+// Graph is the shape of a diagram without positions. Nodes and groups are listed in
+// the order they appear in the source, which decides their order within a layer.
+type Graph struct {
+	// Direction the layers run in, top down when empty
+	Direction string
+	Nodes     []Node
+	Edges     []Edge
+	Groups    []Group
+}
+
+type Node struct {
+	ID string
+	// ID of the innermost group the node belongs to
+	Group string
+}
+
+type Edge struct {
+	From string
+	To   string
+}
+
+type Group struct {
+	ID string
+	// ID of the enclosing group
+	Parent string
+}
+
+// Box is the area a group node covers, with its top left corner at X, Y
+type Box struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Layered places nodes in layers along the graph direction, starting at origin. Each
+// node is one layer past the furthest node linking to it, ignoring links that close a
+// cycle. Across the layers, nodes are kept in bands by group, so group boxes do not
+// cover other nodes, then in the order they appear.
+func Layered(graph Graph, origin axon_types.Position) map[string]axon_types.Position {
+	layers := assignLayers(graph.Nodes, graph.Edges)
+
+	// Nodes outside any group come first, then groups in the order they appear
+	groups := []string{""}
+	for _, group := range graph.Groups {
+		groups = append(groups, group.ID)
+	}
+
+	byLayer := map[int]map[string][]string{}
+	depth := 0
+	for _, node := range graph.Nodes {
+		layer := layers[node.ID]
+		if byLayer[layer] == nil {
+			byLayer[layer] = map[string][]string{}
+		}
+		byLayer[layer][node.Group] = append(byLayer[layer][node.Group], node.ID)
+		if layer > depth {
+			depth = layer
+		}
+	}
+
+	bandStart := map[string]int{}
+	slots := 0
+	for _, group := range groups {
+		bandStart[group] = slots
+		for _, members := range byLayer {
+			if len(members[group]) > slots-bandStart[group] {
+				slots = bandStart[group] + len(members[group])
+			}
+		}
+	}
+
+	positions := make(map[string]axon_types.Position, len(graph.Nodes))
+
+	for layer := 0; layer <= depth; layer++ {
+		for _, group := range groups {
+			for rank, id := range byLayer[layer][group] {
+				across := bandStart[group] + rank
+
+				var position axon_types.Position
+				switch graph.Direction {
+				case DirectionLeftRight:
+					position = axon_types.Position{X: layer * ColumnWidth, Y: across * RowHeight}
+				case DirectionRightLeft:
+					position = axon_types.Position{X: (depth - layer) * ColumnWidth, Y: across * RowHeight}
+				case DirectionBottomUp:
+					position = axon_types.Position{X: across * ColumnWidth, Y: (depth - layer) * RowHeight}
+				default:
+					position = axon_types.Position{X: across * ColumnWidth, Y: layer * RowHeight}
+				}
+
+				position.X += origin.X
+				position.Y += origin.Y
+				positions[id] = position
+			}
+		}
+	}
+
+	return positions
+}
+
+// assignLayers gives each node the length of the longest path reaching it. Links are
+// followed depth first in the order they were written, and a link back to a node still
+// being visited closes a cycle and is left out.
+func assignLayers(nodes []Node, edges []Edge) map[string]int {
+	outgoing := map[string][]string{}
+	for _, edge := range edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	incoming := map[string][]string{}
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		for _, to := range outgoing[id] {
+			if state[to] == visiting {
+				continue
+			}
+			incoming[to] = append(incoming[to], id)
+			if state[to] == unvisited {
+				visit(to)
+			}
+		}
+		state[id] = visited
+	}
+
+	for _, node := range nodes {
+		if state[node.ID] == unvisited {
+			visit(node.ID)
+		}
+	}
+
+	layers := map[string]int{}
+	var layer func(id string) int
+	layer = func(id string) int {
+		if l, ok := layers[id]; ok {
+			return l
+		}
+		l := 0
+		for _, from := range incoming[id] {
+			if above := layer(from) + 1; above > l {
+				l = above
+			}
+		}
+		layers[id] = l
+		return l
+	}
+
+	for _, node := range nodes {
+		layer(node.ID)
+	}
+
+	return layers
+}
+
+type bounds struct {
+	minX, minY, maxX, maxY int
+	empty                  bool
+}
+
+func (b *bounds) add(x, y, width, height int) {
+	if b.empty {
+		b.minX, b.minY, b.maxX, b.maxY, b.empty = x, y, x+width, y+height, false
+		return
+	}
+	if x < b.minX {
+		b.minX = x
+	}
+	if y < b.minY {
+		b.minY = y
+	}
+	if x+width > b.maxX {
+		b.maxX = x + width
+	}
+	if y+height > b.maxY {
+		b.maxY = y + height
+	}
+}
+
+// Boxes sizes each group to contain its member nodes and nested groups, with room for
+// its title. A group with no members gets the size of one node, at origin.
+func Boxes(graph Graph, positions map[string]axon_types.Position, origin axon_types.Position) map[string]Box {
+	children := map[string][]string{}
+	for _, group := range graph.Groups {
+		children[group.Parent] = append(children[group.Parent], group.ID)
+	}
+
+	members := map[string][]string{}
+	for _, node := range graph.Nodes {
+		if node.Group != "" {
+			members[node.Group] = append(members[node.Group], node.ID)
+		}
+	}
+
+	measured := map[string]bounds{}
+	var measure func(id string) bounds
+	measure = func(id string) bounds {
+		box := bounds{empty: true}
+		for _, member := range members[id] {
+			position := positions[member]
+			box.add(position.X, position.Y, NodeWidth, NodeHeight)
+		}
+		for _, child := range children[id] {
+			if inner := measure(child); !inner.empty {
+				box.add(inner.minX, inner.minY, inner.maxX-inner.minX, inner.maxY-inner.minY)
+			}
+		}
+		if !box.empty {
+			box.minX -= GroupPadding
+			box.minY -= GroupPadding + GroupHeader
+			box.maxX += GroupPadding
+			box.maxY += GroupPadding
+		}
+		measured[id] = box
+		return box
+	}
+	for _, id := range children[""] {
+		measure(id)
+	}
+
+	boxes := make(map[string]Box, len(graph.Groups))
+	for _, group := range graph.Groups {
+		box, ok := measured[group.ID]
+		if !ok || box.empty {
+			boxes[group.ID] = Box{X: origin.X, Y: origin.Y, Width: NodeWidth + 2*GroupPadding, Height: NodeHeight + 2*GroupPadding + GroupHeader}
+			continue
+		}
+		boxes[group.ID] = Box{X: box.minX, Y: box.minY, Width: box.maxX - box.minX, Height: box.maxY - box.minY}
+	}
+
+	return boxes
+}
+
+// GroupStyles stores the size of a group node in its background styles
+func GroupStyles(box Box) axon_types.NodeStyles {
+	return axon_types.NodeStyles{
+		BackgroundStyles: map[string]interface{}{
+			axon_types.STYLE_WIDTH:  box.Width,
+			axon_types.STYLE_HEIGHT: box.Height,
+		},
+	}
+}
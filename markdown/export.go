@@ -0,0 +1,245 @@
+package markdown
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Export writes a note as one Markdown document: the note name and description, then a
+// section for each node with its title, description and content. Sections follow the
+// edges where they can, and the position of the nodes where the edges form a cycle or
+// leave a choice. Each section ends with links to the sections its edges join.
+func Export(note axon_types.NoteDetail) (string, error) {
+	nodes := order(note.Nodes, note.Edges)
+
+	byId := make(map[string]axon_types.Node, len(nodes))
+	for _, node := range nodes {
+		byId[node.NodeID] = node
+	}
+
+	outgoing := map[string][]axon_types.Edge{}
+	incoming := map[string][]axon_types.Edge{}
+	for _, edge := range sortedEdges(note.Edges) {
+		if _, ok := byId[edge.SourceID]; !ok {
+			continue
+		}
+		if _, ok := byId[edge.TargetID]; !ok {
+			continue
+		}
+		outgoing[edge.SourceID] = append(outgoing[edge.SourceID], edge)
+		incoming[edge.TargetID] = append(incoming[edge.TargetID], edge)
+	}
+
+	members := map[string][]axon_types.Node{}
+	for _, node := range nodes {
+		if parent, ok := byId[node.ParentID]; ok && isGroup(parent) && node.ParentID != node.NodeID {
+			members[node.ParentID] = append(members[node.ParentID], node)
+		}
+	}
+
+	// Anchors are worked out in the order headings are written, as a renderer numbers
+	// repeated headings in that order
+	slugs := slugger{}
+	title := oneLine(note.NoteName)
+	if title == "" {
+		title = "Untitled note"
+	}
+	slugs.slug(title)
+
+	anchors := make(map[string]string, len(nodes))
+	contents := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		anchors[node.NodeID] = slugs.slug(heading(node))
+		contents[node.NodeID] = demote(nodeContent(node), &slugs)
+	}
+
+	link := func(node_id string) string {
+		return fmt.Sprintf("[%s](#%s)", escapeLink(heading(byId[node_id])), anchors[node_id])
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	if description := strings.TrimSpace(note.Description); description != "" {
+		fmt.Fprintf(&b, "\n%s\n", description)
+	}
+
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "\n## %s\n", heading(node))
+
+		if description := strings.TrimSpace(node.Data.Description); description != "" {
+			fmt.Fprintf(&b, "\n%s\n", description)
+		}
+		if content := contents[node.NodeID]; content != "" {
+			fmt.Fprintf(&b, "\n%s\n", content)
+		}
+
+		if parent, ok := byId[node.ParentID]; ok && isGroup(parent) && node.ParentID != node.NodeID {
+			fmt.Fprintf(&b, "\nPart of %s\n", link(node.ParentID))
+		}
+		contains := []string{}
+		for _, member := range members[node.NodeID] {
+			contains = append(contains, link(member.NodeID))
+		}
+		linksTo := []string{}
+		for _, edge := range outgoing[node.NodeID] {
+			linksTo = append(linksTo, link(edge.TargetID)+edgeLabel(edge))
+		}
+		linkedFrom := []string{}
+		for _, edge := range incoming[node.NodeID] {
+			linkedFrom = append(linkedFrom, link(edge.SourceID)+edgeLabel(edge))
+		}
+
+		writeLinks(&b, "Contains", contains)
+		writeLinks(&b, "Links to", linksTo)
+		writeLinks(&b, "Linked from", linkedFrom)
+	}
+
+	return b.String(), nil
+}
+
+func writeLinks(b *strings.Builder, title string, links []string) {
+	if len(links) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n**%s**\n\n", title)
+	for _, link := range links {
+		fmt.Fprintf(b, "- %s\n", link)
+	}
+}
+
+func edgeLabel(edge axon_types.Edge) string {
+	if label := oneLine(edge.Label); label != "" {
+		return " - " + label
+	}
+	return ""
+}
+
+// order sorts nodes so each comes after the nodes with edges to it. Of the nodes that
+// are ready, the one highest on the note, then furthest left, comes first. When every
+// node left waits on a cycle, the highest node of a cycle is taken as if it were ready.
+func order(nodes []axon_types.Node, edges []axon_types.Edge) []axon_types.Node {
+	remaining := append([]axon_types.Node{}, nodes...)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		a, b := remaining[i], remaining[j]
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		if a.Position.X != b.Position.X {
+			return a.Position.X < b.Position.X
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	present := map[string]bool{}
+	for _, node := range remaining {
+		present[node.NodeID] = true
+	}
+
+	waiting := map[string]int{}
+	targets := map[string][]string{}
+	for _, edge := range edges {
+		if !present[edge.SourceID] || !present[edge.TargetID] || edge.SourceID == edge.TargetID {
+			continue
+		}
+		waiting[edge.TargetID]++
+		targets[edge.SourceID] = append(targets[edge.SourceID], edge.TargetID)
+	}
+
+	ordered := make([]axon_types.Node, 0, len(remaining))
+	done := map[string]bool{}
+	for len(ordered) < len(remaining) {
+		next := -1
+		for i, node := range remaining {
+			if !done[node.NodeID] && waiting[node.NodeID] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			for i, node := range remaining {
+				if !done[node.NodeID] && onCycle(node.NodeID, targets, done) {
+					next = i
+					break
+				}
+			}
+		}
+
+		node := remaining[next]
+		done[node.NodeID] = true
+		ordered = append(ordered, node)
+		for _, target := range targets[node.NodeID] {
+			waiting[target]--
+		}
+	}
+
+	return ordered
+}
+
+// onCycle tells whether a node can reach itself through nodes not yet done
+func onCycle(node_id string, targets map[string][]string, done map[string]bool) bool {
+	visited := map[string]bool{}
+	stack := append([]string{}, targets[node_id]...)
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == node_id {
+			return true
+		}
+		if visited[current] || done[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, targets[current]...)
+	}
+	return false
+}
+
+func sortedEdges(edges []axon_types.Edge) []axon_types.Edge {
+	sorted := append([]axon_types.Edge{}, edges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.SourceID != b.SourceID {
+			return a.SourceID < b.SourceID
+		}
+		if a.TargetID != b.TargetID {
+			return a.TargetID < b.TargetID
+		}
+		return a.EdgeID < b.EdgeID
+	})
+	return sorted
+}
+
+func isGroup(node axon_types.Node) bool {
+	return node.Data.NodeCategory == axon_types.NODE_CATEGORY_GROUP
+}
+
+func heading(node axon_types.Node) string {
+	for _, text := range []string{node.Data.Title, node.Data.Label} {
+		if text = oneLine(text); text != "" {
+			return text
+		}
+	}
+	return "Untitled node"
+}
+
+// nodeContent is the Markdown of a node, without a first heading that repeats the
+// title, as imported text often starts with one
+func nodeContent(node axon_types.Node) string {
+	content := strings.TrimSpace(node.Content.MarkDown)
+	first, rest, _ := strings.Cut(content, "\n")
+	if level, text := headingLine(first); level > 0 && text == heading(node) {
+		content = strings.TrimSpace(rest)
+	}
+	return content
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func escapeLink(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+}
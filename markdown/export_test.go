@@ -0,0 +1,119 @@
+package markdown
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func node(id string, title string, x int, y int) axon_types.Node {
+	return axon_types.Node{
+		NodeID:   id,
+		Data:     axon_types.NodeData{Title: title},
+		Position: axon_types.Position{X: x, Y: y},
+	}
+}
+
+func withContent(n axon_types.Node, markdown string) axon_types.Node {
+	n.Content.MarkDown = markdown
+	return n
+}
+
+func TestExportGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		note axon_types.NoteDetail
+	}{
+		{
+			// Edges decide the order over position: c is drawn first but follows b,
+			// which follows a. d has no edges and comes by position among the ready nodes.
+			name: "order",
+			note: axon_types.NoteDetail{
+				NoteName:    "Order",
+				Description: "Sections follow the edges.",
+				Nodes: []axon_types.Node{
+					node("c", "Third", 0, 0),
+					node("b", "Second", 0, 100),
+					node("a", "First", 0, 200),
+					node("d", "Apart", 200, 150),
+				},
+				Edges: []axon_types.Edge{
+					{EdgeID: "e2", SourceID: "b", TargetID: "c"},
+					{EdgeID: "e1", SourceID: "a", TargetID: "b", Label: "then"},
+				},
+			},
+		},
+		{
+			// A cycle starts from its highest node, a node that only hangs off the cycle
+			// waits for it, and a self loop is not a dependency
+			name: "cycle",
+			note: axon_types.NoteDetail{
+				NoteName: "Cycle",
+				Nodes: []axon_types.Node{
+					node("tail", "Tail", 0, 0),
+					node("y", "Loop end", 100, 100),
+					node("x", "Loop start", 0, 50),
+					node("self", "Self", 200, 300),
+				},
+				Edges: []axon_types.Edge{
+					{EdgeID: "e1", SourceID: "x", TargetID: "y"},
+					{EdgeID: "e2", SourceID: "y", TargetID: "x"},
+					{EdgeID: "e3", SourceID: "y", TargetID: "tail"},
+					{EdgeID: "e4", SourceID: "self", TargetID: "self"},
+				},
+			},
+		},
+		{
+			// Repeated headings, headings inside node content and punctuation all count
+			// toward the anchors links point at. Content headings move below the node
+			// section, except in code blocks, and a first heading repeating the title
+			// is dropped.
+			name: "anchors",
+			note: axon_types.NoteDetail{
+				NoteName: "Setup",
+				Nodes: []axon_types.Node{
+					withContent(node("a", "Setup", 0, 0), "# Setup\n\nInstall it.\n\n# Usage\n\n```sh\n# not a heading\n```"),
+					withContent(node("b", "Usage", 0, 100), "#### Deep\n\n###### Deepest"),
+					node("c", "What's [next]?", 0, 200),
+					{NodeID: "g", Data: axon_types.NodeData{Label: "Group", NodeCategory: axon_types.NODE_CATEGORY_GROUP}, Position: axon_types.Position{X: 300, Y: 0}},
+					{NodeID: "m", Data: axon_types.NodeData{Title: "Member"}, ParentID: "g", Position: axon_types.Position{X: 300, Y: 50}},
+					{NodeID: "u", Position: axon_types.Position{X: 300, Y: 300}},
+				},
+				Edges: []axon_types.Edge{
+					{EdgeID: "e1", SourceID: "a", TargetID: "b"},
+					{EdgeID: "e2", SourceID: "b", TargetID: "c", Label: "next"},
+					{EdgeID: "e3", SourceID: "c", TargetID: "missing"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Export(test.note)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", test.name+".md")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Export() differs from %s:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
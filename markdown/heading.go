@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Levels headings in node content move down, below the ## of the node section
+const demoteBy = 2
+
+// slugger makes heading anchors the way GitHub does: lower case, punctuation dropped,
+// spaces as dashes, and -1, -2 added to repeated headings
+type slugger map[string]int
+
+func (s slugger) slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+
+	base := b.String()
+	slug := base
+	if count := s[base]; count > 0 {
+		slug = base + "-" + strconv.Itoa(count)
+	}
+	s[base]++
+	return slug
+}
+
+// demote moves the ATX headings of content down so they sit inside the node section,
+// at most to level 6, and counts their anchors. Lines in fenced code blocks are left
+// as they are.
+func demote(content string, slugs *slugger) string {
+	if content == "" {
+		return ""
+	}
+
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		level, text := headingLine(line)
+		if level == 0 {
+			continue
+		}
+		level += demoteBy
+		if level > 6 {
+			level = 6
+		}
+		lines[i] = strings.Repeat("#", level) + " " + text
+		slugs.slug(text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headingLine returns the level and text of an ATX heading, or 0 when the line is not
+// one
+func headingLine(line string) (int, string) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, ""
+	}
+
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+
+	// A closing sequence of # is not part of the text
+	text := strings.TrimSpace(rest)
+	if stripped := strings.TrimRight(text, "#"); stripped == "" || strings.HasSuffix(stripped, " ") {
+		text = strings.TrimSpace(stripped)
+	}
+	return level, oneLine(text)
+}
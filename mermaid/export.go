@@ -0,0 +1,148 @@
+package mermaid
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+const (
+	DirectionTopDown   string = "TD"
+	DirectionLeftRight string = "LR"
+	DirectionBottomUp  string = "BT"
+	DirectionRightLeft string = "RL"
+)
+
+var unsafeId = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+type Options struct {
+	// Flowchart direction, top down when empty
+	Direction string
+}
+
+// Export writes a note as a Mermaid flowchart. Nodes are written in reading order,
+// top to bottom then left to right, and edges by source, target and ID, so the same
+// note always produces the same text. Edges to nodes that are not in the note are
+// left out, as Mermaid would otherwise draw an empty node for them.
+func Export(note axon_types.NoteDetail, options Options) (string, error) {
+	direction := options.Direction
+	if direction == "" {
+		direction = DirectionTopDown
+	}
+
+	switch direction {
+	case DirectionTopDown, "TB", DirectionLeftRight, DirectionBottomUp, DirectionRightLeft:
+	default:
+		return "", fmt.Errorf("could not export note - unknown flowchart direction %q", direction)
+	}
+
+	nodes := append([]axon_types.Node{}, note.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		if a.Position.X != b.Position.X {
+			return a.Position.X < b.Position.X
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	ids := mermaidIds(note.Nodes)
+
+	var b strings.Builder
+
+	if note.NoteName != "" {
+		fmt.Fprintf(&b, "---\ntitle: %q\n---\n", note.NoteName)
+	}
+	fmt.Fprintf(&b, "flowchart %s\n", direction)
+
+	for _, node := range nodes {
+		shape := ShapeOf(node.Data.NodeCategory)
+		fmt.Fprintf(&b, "    %s%s\"%s\"%s\n", ids[node.NodeID], shape.Open, escapeText(nodeText(node)), shape.Close)
+	}
+
+	edges := append([]axon_types.Edge{}, note.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if ids[a.SourceID] != ids[b.SourceID] {
+			return ids[a.SourceID] < ids[b.SourceID]
+		}
+		if ids[a.TargetID] != ids[b.TargetID] {
+			return ids[a.TargetID] < ids[b.TargetID]
+		}
+		return a.EdgeID < b.EdgeID
+	})
+
+	for _, edge := range edges {
+		source, sourceOk := ids[edge.SourceID]
+		target, targetOk := ids[edge.TargetID]
+		if !sourceOk || !targetOk {
+			continue
+		}
+
+		arrow := "-->"
+		if edge.Animated {
+			arrow = "-.->"
+		}
+
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "    %s %s|\"%s\"| %s\n", source, arrow, escapeText(edge.Label), target)
+		} else {
+			fmt.Fprintf(&b, "    %s %s %s\n", source, arrow, target)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// mermaidIds maps node IDs to Mermaid IDs. Characters Mermaid reads as syntax, such as
+// the dashes of a UUID, become underscores, and a counter keeps the result unique.
+func mermaidIds(nodes []axon_types.Node) map[string]string {
+	sorted := make([]string, len(nodes))
+	for i, node := range nodes {
+		sorted[i] = node.NodeID
+	}
+	sort.Strings(sorted)
+
+	ids := make(map[string]string, len(nodes))
+	used := map[string]bool{}
+
+	for _, nodeId := range sorted {
+		base := "n_" + unsafeId.ReplaceAllString(nodeId, "_")
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[id] = true
+		ids[nodeId] = id
+	}
+
+	return ids
+}
+
+func nodeText(node axon_types.Node) string {
+	for _, text := range []string{node.Data.Title, node.Data.Label} {
+		if strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return " "
+}
+
+// escapeText makes text safe inside a quoted Mermaid string. # starts an entity code,
+// so it is escaped first, and line breaks become <br/>.
+func escapeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	replacer := strings.NewReplacer(
+		"#", "#35;",
+		`"`, "#quot;",
+		"\n", "<br/>",
+		"<", "#lt;",
+		">", "#gt;",
+	)
+	return replacer.Replace(text)
+}
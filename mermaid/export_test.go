@@ -0,0 +1,119 @@
+package mermaid
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func node(id string, category string, title string, x int, y int) axon_types.Node {
+	return axon_types.Node{
+		NodeID:   id,
+		Data:     axon_types.NodeData{Title: title, NodeCategory: category},
+		Position: axon_types.Position{X: x, Y: y},
+	}
+}
+
+func TestExportGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		note    axon_types.NoteDetail
+		options Options
+	}{
+		{
+			// One node per shape, in reading order
+			name: "shapes",
+			note: axon_types.NoteDetail{
+				NoteName: "Shapes",
+				Nodes: []axon_types.Node{
+					node("process", "process", "Process", 0, 0),
+					node("rounded", "rounded", "Rounded", 200, 0),
+					node("start", "start", "Start", 400, 0),
+					node("subroutine", "subroutine", "Subroutine", 0, 100),
+					node("database", "database", "Database", 200, 100),
+					node("circle", "circle", "Circle", 400, 100),
+					node("stop", "stop", "Stop", 0, 200),
+					node("note", "note", "Note", 200, 200),
+					node("decision", "decision", "Decision", 400, 200),
+					node("hexagon", "hexagon", "Hexagon", 0, 300),
+					node("input", "input", "Input", 200, 300),
+					node("output", "output", "Output", 400, 300),
+					node("manual", "manual", "Manual", 0, 400),
+					node("trapezoid", "trapezoid", "Trapezoid", 200, 400),
+					node("unknown", "something else", "Unknown", 400, 400),
+				},
+			},
+		},
+		{
+			// UUIDs and IDs that only differ in characters Mermaid cannot read, node
+			// text that looks like syntax, and labels taken from the title or label
+			name: "ids",
+			note: axon_types.NoteDetail{
+				NoteName: `Quoted "name"`,
+				Nodes: []axon_types.Node{
+					node("0b6a8f2e-61c1-4d5e-9a3b-1f7e2c9d4a10", "process", `Say "hi" #1 <b>`, 0, 0),
+					node("a-b", "process", "Dashed", 0, 100),
+					node("a_b", "process", "Underscored", 200, 100),
+					node("a.b", "process", "Line one\r\nLine two", 400, 100),
+					{NodeID: "label", Data: axon_types.NodeData{Label: "From the label"}, Position: axon_types.Position{X: 0, Y: 200}},
+					{NodeID: "empty", Position: axon_types.Position{X: 200, Y: 200}},
+				},
+			},
+		},
+		{
+			// Plain, dotted and labelled edges, and an edge to a node outside the note
+			name:    "edges",
+			options: Options{Direction: DirectionLeftRight},
+			note: axon_types.NoteDetail{
+				Nodes: []axon_types.Node{
+					node("a", "start", "Start", 0, 0),
+					node("b", "decision", "Valid?", 200, 0),
+					node("c", "process", "Save", 400, 0),
+					node("d", "end", "Reject", 400, 100),
+				},
+				Edges: []axon_types.Edge{
+					{EdgeID: "e4", SourceID: "b", TargetID: "d", Label: "no", Animated: true},
+					{EdgeID: "e1", SourceID: "a", TargetID: "b"},
+					{EdgeID: "e3", SourceID: "b", TargetID: "c", Label: `yes "valid" #ok`},
+					{EdgeID: "e2", SourceID: "a", TargetID: "b", Animated: true},
+					{EdgeID: "e5", SourceID: "c", TargetID: "missing"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Export(test.note, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", test.name+".mmd")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Export() differs from %s:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestExportDirection(t *testing.T) {
+	if _, err := Export(axon_types.NoteDetail{}, Options{Direction: "XY"}); err == nil {
+		t.Error("Export with an unknown direction did not fail")
+	}
+}
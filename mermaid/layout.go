@@ -0,0 +1,119 @@
+package mermaid
+
+import (
+	"github.com/google/uuid"
+	axon_layout "github.com/stephensanwo/axon-lib/layout"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type ImportOptions struct {
+	// Top left corner of the imported flowchart, to place it clear of existing nodes
+	Origin axon_types.Position
+}
+
+// ImportResult holds the nodes and edges of a flowchart, with new IDs, ready to be
+// added to a note
+type ImportResult struct {
+	Nodes    []axon_types.Node
+	Edges    []axon_types.Edge
+	Problems []Problem
+}
+
+// Import parses a flowchart and lays it out as nodes and edges. Subgraphs become group
+// nodes sized to contain their members.
+func Import(text string, options ImportOptions) (*ImportResult, error) {
+	chart, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, edges := Layout(chart, options.Origin)
+	return &ImportResult{Nodes: nodes, Edges: edges, Problems: chart.Problems}, nil
+}
+
+// Layout turns a flowchart into nodes and edges with new IDs, laid out in layers along
+// its direction. Subgraphs become group nodes sized to contain their members.
+func Layout(chart *Flowchart, origin axon_types.Position) ([]axon_types.Node, []axon_types.Edge) {
+	subgraphs := map[string]Subgraph{}
+	for _, subgraph := range chart.Subgraphs {
+		subgraphs[subgraph.ID] = subgraph
+	}
+
+	graph := axon_layout.Graph{Direction: chart.Direction}
+	for _, subgraph := range chart.Subgraphs {
+		graph.Groups = append(graph.Groups, axon_layout.Group{ID: subgraph.ID, Parent: subgraph.Parent})
+	}
+
+	// A node that is only a reference to a subgraph ID links to the group instead
+	flowNodes := []FlowNode{}
+	for _, node := range chart.Nodes {
+		if _, isSubgraph := subgraphs[node.ID]; isSubgraph && !node.Declared {
+			continue
+		}
+		flowNodes = append(flowNodes, node)
+		graph.Nodes = append(graph.Nodes, axon_layout.Node{ID: node.ID, Group: node.Subgraph})
+	}
+	for _, edge := range chart.Edges {
+		graph.Edges = append(graph.Edges, axon_layout.Edge{From: edge.From, To: edge.To})
+	}
+
+	ids := map[string]string{}
+	for _, node := range flowNodes {
+		ids[node.ID] = uuid.New().String()
+	}
+	groupIds := map[string]string{}
+	for _, subgraph := range chart.Subgraphs {
+		groupIds[subgraph.ID] = uuid.New().String()
+	}
+
+	// Links name nodes first, then subgraphs
+	endpoint := func(id string) string {
+		if nodeId, ok := ids[id]; ok {
+			return nodeId
+		}
+		return groupIds[id]
+	}
+
+	positions := axon_layout.Layered(graph, origin)
+	boxes := axon_layout.Boxes(graph, positions, origin)
+
+	nodes := make([]axon_types.Node, 0, len(flowNodes)+len(chart.Subgraphs))
+	for _, node := range flowNodes {
+		nodes = append(nodes, axon_types.Node{
+			NodeID: ids[node.ID],
+			Data: axon_types.NodeData{
+				Title:        node.Text,
+				NodeCategory: CategoryOf(node.Shape),
+			},
+			Position: positions[node.ID],
+			ParentID: groupIds[node.Subgraph],
+		})
+	}
+
+	for _, subgraph := range chart.Subgraphs {
+		box := boxes[subgraph.ID]
+		nodes = append(nodes, axon_types.Node{
+			NodeID: groupIds[subgraph.ID],
+			Data: axon_types.NodeData{
+				Title:        subgraph.Title,
+				NodeCategory: axon_types.NODE_CATEGORY_GROUP,
+			},
+			Position: axon_types.Position{X: box.X, Y: box.Y},
+			Styles:   axon_layout.GroupStyles(box),
+			ParentID: groupIds[subgraph.Parent],
+		})
+	}
+
+	edges := make([]axon_types.Edge, 0, len(chart.Edges))
+	for _, edge := range chart.Edges {
+		edges = append(edges, axon_types.Edge{
+			EdgeID:   uuid.New().String(),
+			SourceID: endpoint(edge.From),
+			TargetID: endpoint(edge.To),
+			Label:    edge.Label,
+			Animated: edge.Dotted,
+		})
+	}
+
+	return nodes, edges
+}
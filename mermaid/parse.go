@@ -0,0 +1,550 @@
+package mermaid
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Flowchart is a parsed Mermaid flowchart. Nodes and subgraphs are in the order they
+// first appear.
+type Flowchart struct {
+	Title     string
+	Direction string
+	Nodes     []FlowNode
+	Edges     []FlowEdge
+	Subgraphs []Subgraph
+	// Statements that were skipped or only partly imported
+	Problems []Problem
+}
+
+type FlowNode struct {
+	ID    string
+	Text  string
+	Shape Shape
+	// ID of the innermost subgraph the node belongs to
+	Subgraph string
+	// Set when the node is written with a shape, rather than only referenced by ID
+	Declared bool
+}
+
+type FlowEdge struct {
+	From   string
+	To     string
+	Label  string
+	Dotted bool
+	Thick  bool
+}
+
+type Subgraph struct {
+	ID    string
+	Title string
+	// ID of the enclosing subgraph
+	Parent string
+}
+
+// Problem is a statement that could not be imported as written
+type Problem struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// SyntaxError is returned when the text is not a flowchart at all
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("could not parse flowchart - line %d: %s", e.Line, e.Message)
+}
+
+var (
+	headerPattern   = regexp.MustCompile(`^(flowchart|graph)(?:\s+([A-Za-z]+))?\s*(?:;(.*))?$`)
+	subgraphPattern = regexp.MustCompile(`^([\w-]+)\s*\[(.*)\]$`)
+	bareIdPattern   = regexp.MustCompile(`^[\w-]+$`)
+	classPattern    = regexp.MustCompile(`^:::[\w-]+`)
+	// -- text -->, == text ==> and -. text .->
+	labelledLink = regexp.MustCompile(`^(<?)(--|==|-\.)\s+(.*?)\s*(-{2,}[->xo]|={2,}[=>xo]|\.+-[>xo]?)`)
+	// -->, ---, ==>, ===, -.->, -.- and ~~~, with an optional |text| label
+	plainLink     = regexp.MustCompile(`^(<?)(-{2,}[->xo]|={2,}[=>xo]|-\.+-[>xo]?|~{3,})(?:\s*\|([^|]*)\|)?`)
+	entityPattern = regexp.MustCompile(`#(\d+|[A-Za-z]+);`)
+	breakPattern  = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// Statements that are valid Mermaid but have no equivalent in a note
+var unsupportedStatements = map[string]string{
+	"classDef":  "class definitions are not supported, the statement is ignored",
+	"class":     "classes are not supported, the statement is ignored",
+	"style":     "styles are not supported, the statement is ignored",
+	"linkStyle": "link styles are not supported, the statement is ignored",
+	"click":     "click handlers are not supported, the statement is ignored",
+	"accTitle":  "accessibility titles are not supported, the statement is ignored",
+	"accDescr":  "accessibility descriptions are not supported, the statement is ignored",
+}
+
+var entities = map[string]string{
+	"quot": `"`,
+	"amp":  "&",
+	"lt":   "<",
+	"gt":   ">",
+	"nbsp": " ",
+}
+
+// Parse reads the flowchart subset of Mermaid: nodes with their shapes, links with
+// or without labels, chains and & groups, and subgraphs. Other statements are skipped
+// and listed in Problems with their line numbers. Only text that is not a flowchart
+// returns an error.
+func Parse(text string) (*Flowchart, error) {
+	p := &parser{
+		chart: &Flowchart{Direction: DirectionTopDown},
+		nodes: map[string]int{},
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	start, err := p.frontMatter(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	header := false
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		number := i + 1
+
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+
+		if !header {
+			match := headerPattern.FindStringSubmatch(line)
+			if match == nil {
+				return nil, &SyntaxError{Line: number, Message: "expected a flowchart or graph declaration, other diagram types cannot be imported"}
+			}
+			header = true
+			p.direction(match[2], number)
+			line = strings.TrimSpace(match[3])
+		}
+
+		for _, statement := range splitStatements(line) {
+			p.statement(strings.TrimSpace(statement), number)
+		}
+	}
+
+	if !header {
+		return nil, &SyntaxError{Line: len(lines), Message: "the text has no flowchart declaration"}
+	}
+
+	for _, open := range p.open {
+		p.problem(open.line, fmt.Sprintf("subgraph %s is not closed with end", open.id))
+	}
+
+	return p.chart, nil
+}
+
+type parser struct {
+	chart *Flowchart
+	// Index of each node in chart.Nodes
+	nodes map[string]int
+	// Subgraphs open at the current line, innermost last
+	open []openSubgraph
+}
+
+type openSubgraph struct {
+	id   string
+	line int
+}
+
+// nodeRef is a node as written in a statement, applied once the whole statement parses
+type nodeRef struct {
+	id       string
+	text     string
+	shape    Shape
+	declared bool
+}
+
+type link struct {
+	label     string
+	dotted    bool
+	thick     bool
+	invisible bool
+}
+
+// frontMatter reads the title from a --- delimited YAML block and returns the first
+// line after it
+func (p *parser) frontMatter(lines []string) (int, error) {
+	first := 0
+	for first < len(lines) && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	if first == len(lines) || strings.TrimSpace(lines[first]) != "---" {
+		return 0, nil
+	}
+
+	for i := first + 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" {
+			return i + 1, nil
+		}
+		if title, found := strings.CutPrefix(line, "title:"); found {
+			p.chart.Title = unquote(strings.TrimSpace(title))
+		}
+	}
+
+	return 0, &SyntaxError{Line: first + 1, Message: "front matter is not closed with ---"}
+}
+
+func (p *parser) direction(direction string, line int) {
+	switch strings.ToUpper(direction) {
+	case "":
+	case "TB", DirectionTopDown:
+		p.chart.Direction = DirectionTopDown
+	case DirectionLeftRight, DirectionBottomUp, DirectionRightLeft:
+		p.chart.Direction = strings.ToUpper(direction)
+	default:
+		p.problem(line, fmt.Sprintf("unknown direction %q, laid out top down", direction))
+	}
+}
+
+func (p *parser) statement(statement string, line int) {
+	if statement == "" {
+		return
+	}
+
+	keyword, rest, _ := strings.Cut(statement, " ")
+	rest = strings.TrimSpace(rest)
+	// accTitle: and accDescr: are followed by a colon
+	if name, _, found := strings.Cut(keyword, ":"); found && unsupportedStatements[name] != "" {
+		keyword = name
+	}
+
+	switch {
+	case statement == "end":
+		if len(p.open) == 0 {
+			p.problem(line, "end without a subgraph")
+			return
+		}
+		p.open = p.open[:len(p.open)-1]
+	case keyword == "subgraph":
+		p.subgraph(rest, line)
+	case keyword == "direction":
+		p.problem(line, "subgraph directions are not supported, the flowchart direction is used")
+	case unsupportedStatements[keyword] != "":
+		p.problem(line, unsupportedStatements[keyword])
+	default:
+		p.chain(statement, line)
+	}
+}
+
+// subgraph opens a subgraph written as "subgraph id", "subgraph id [title]" or
+// "subgraph title"
+func (p *parser) subgraph(rest string, line int) {
+	subgraph := Subgraph{Parent: p.current()}
+
+	if match := subgraphPattern.FindStringSubmatch(rest); match != nil {
+		subgraph.ID, subgraph.Title = match[1], unescape(match[2])
+	} else if bareIdPattern.MatchString(rest) {
+		subgraph.ID, subgraph.Title = rest, rest
+	} else {
+		subgraph.ID = fmt.Sprintf("subgraph_%d", len(p.chart.Subgraphs)+1)
+		subgraph.Title = unescape(rest)
+	}
+
+	for _, existing := range p.chart.Subgraphs {
+		if existing.ID == subgraph.ID {
+			p.problem(line, fmt.Sprintf("subgraph %s is declared twice, the second is imported as a separate group", subgraph.ID))
+			subgraph.ID = fmt.Sprintf("%s_%d", subgraph.ID, len(p.chart.Subgraphs)+1)
+			break
+		}
+	}
+
+	p.chart.Subgraphs = append(p.chart.Subgraphs, subgraph)
+	p.open = append(p.open, openSubgraph{id: subgraph.ID, line: line})
+}
+
+// chain parses "a --> b & c -- text --> d". Nothing from a statement is kept unless
+// all of it parses.
+func (p *parser) chain(statement string, line int) {
+	s := &scanner{text: statement}
+	groups := [][]nodeRef{}
+	links := []link{}
+	classes := false
+
+	for {
+		group, ok := p.nodeGroup(s, &classes)
+		if !ok {
+			p.problem(line, fmt.Sprintf("could not read a node at column %d of %q, the statement is ignored", s.pos+1, statement))
+			return
+		}
+		groups = append(groups, group)
+
+		s.skipSpace()
+		if s.done() {
+			break
+		}
+
+		l, ok := s.link()
+		if !ok {
+			p.problem(line, fmt.Sprintf("unexpected %q at column %d, the statement is ignored", s.rest(), s.pos+1))
+			return
+		}
+		links = append(links, l)
+	}
+
+	if len(groups) == len(links) {
+		p.problem(line, fmt.Sprintf("link without a target in %q, the statement is ignored", statement))
+		return
+	}
+
+	if classes {
+		p.problem(line, "classes are not supported, ::: class names are ignored")
+	}
+
+	for _, group := range groups {
+		for _, ref := range group {
+			p.declare(ref)
+		}
+	}
+
+	for i, l := range links {
+		if l.invisible {
+			p.problem(line, "invisible links are not supported, the link is ignored")
+			continue
+		}
+		for _, from := range groups[i] {
+			for _, to := range groups[i+1] {
+				p.chart.Edges = append(p.chart.Edges, FlowEdge{From: from.id, To: to.id, Label: l.label, Dotted: l.dotted, Thick: l.thick})
+			}
+		}
+	}
+}
+
+func (p *parser) nodeGroup(s *scanner, classes *bool) ([]nodeRef, bool) {
+	group := []nodeRef{}
+	for {
+		s.skipSpace()
+		ref, ok := s.node()
+		if !ok {
+			return nil, false
+		}
+		if class := classPattern.FindString(s.rest()); class != "" {
+			s.pos += len(class)
+			*classes = true
+		}
+		group = append(group, ref)
+
+		s.skipSpace()
+		if !strings.HasPrefix(s.rest(), "&") {
+			return group, true
+		}
+		s.pos++
+	}
+}
+
+// declare adds a node, or updates its text and shape when it is declared again. A
+// node belongs to the subgraph it is first written in.
+func (p *parser) declare(ref nodeRef) {
+	index, exists := p.nodes[ref.id]
+	if !exists {
+		p.nodes[ref.id] = len(p.chart.Nodes)
+		p.chart.Nodes = append(p.chart.Nodes, FlowNode{ID: ref.id, Text: ref.id, Shape: ShapeRectangle, Subgraph: p.current()})
+		index = len(p.chart.Nodes) - 1
+	}
+
+	node := &p.chart.Nodes[index]
+	if node.Subgraph == "" {
+		node.Subgraph = p.current()
+	}
+	if ref.declared {
+		node.Text, node.Shape, node.Declared = ref.text, ref.shape, true
+	}
+}
+
+func (p *parser) current() string {
+	if len(p.open) == 0 {
+		return ""
+	}
+	return p.open[len(p.open)-1].id
+}
+
+func (p *parser) problem(line int, message string) {
+	p.chart.Problems = append(p.chart.Problems, Problem{Line: line, Message: message})
+}
+
+type scanner struct {
+	text string
+	pos  int
+}
+
+func (s *scanner) rest() string {
+	return s.text[s.pos:]
+}
+
+func (s *scanner) done() bool {
+	return s.pos >= len(s.text)
+}
+
+func (s *scanner) skipSpace() {
+	for !s.done() && (s.text[s.pos] == ' ' || s.text[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+// node reads an ID and an optional shape with its text
+func (s *scanner) node() (nodeRef, bool) {
+	start := s.pos
+	for !s.done() {
+		c := s.text[s.pos]
+		// Dashes are part of an ID unless they start a link
+		if isIdChar(c) || (c == '-' && s.pos+1 < len(s.text) && isIdChar(s.text[s.pos+1]) && s.pos > start) {
+			s.pos++
+			continue
+		}
+		break
+	}
+
+	ref := nodeRef{id: s.text[start:s.pos]}
+	if ref.id == "" {
+		return ref, false
+	}
+
+	// Shapes sharing an opening bracket are told apart by whichever closes first
+	for length := 3; length > 0; length-- {
+		best, bestEnd, text := Shape{}, -1, ""
+		for _, shape := range Shapes {
+			if len(shape.Open) != length || !strings.HasPrefix(s.rest(), shape.Open) {
+				continue
+			}
+			if t, end, ok := s.shapeText(shape); ok && (bestEnd < 0 || end < bestEnd) {
+				best, bestEnd, text = shape, end, t
+			}
+		}
+		if bestEnd >= 0 {
+			ref.shape, ref.text, ref.declared = best, text, true
+			s.pos = bestEnd
+			return ref, true
+		}
+	}
+
+	return ref, true
+}
+
+// shapeText reads the text inside a shape and returns it with the position after the
+// closing bracket
+func (s *scanner) shapeText(shape Shape) (string, int, bool) {
+	pos := s.pos + len(shape.Open)
+	rest := s.text[pos:]
+
+	if trimmed := strings.TrimLeft(rest, " "); strings.HasPrefix(trimmed, `"`) {
+		quote := pos + len(rest) - len(trimmed)
+		end := strings.Index(s.text[quote+1:], `"`)
+		if end < 0 {
+			return "", 0, false
+		}
+		text := s.text[quote+1 : quote+1+end]
+		after := quote + 1 + end + 1
+		for after < len(s.text) && s.text[after] == ' ' {
+			after++
+		}
+		if !strings.HasPrefix(s.text[after:], shape.Close) {
+			return "", 0, false
+		}
+		return unescape(text), after + len(shape.Close), true
+	}
+
+	end := strings.Index(rest, shape.Close)
+	if end < 0 {
+		return "", 0, false
+	}
+	return unescape(rest[:end]), pos + end + len(shape.Close), true
+}
+
+func (s *scanner) link() (link, bool) {
+	var l link
+	var arrow, label string
+	var match []int
+
+	if match = labelledLink.FindStringSubmatchIndex(s.rest()); match != nil {
+		arrow = s.rest()[match[4]:match[5]] + s.rest()[match[8]:match[9]]
+		label = s.rest()[match[6]:match[7]]
+	} else if match = plainLink.FindStringSubmatchIndex(s.rest()); match != nil {
+		arrow = s.rest()[match[4]:match[5]]
+		if match[6] >= 0 {
+			label = s.rest()[match[6]:match[7]]
+		}
+	} else {
+		return l, false
+	}
+
+	l.label = unescape(strings.TrimSpace(label))
+	l.dotted = strings.Contains(arrow, ".")
+	l.thick = strings.Contains(arrow, "=")
+	l.invisible = strings.HasPrefix(arrow, "~")
+
+	s.pos += match[1]
+	return l, true
+}
+
+func isIdChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// splitStatements splits a line on semicolons outside quoted text
+func splitStatements(line string) []string {
+	statements := []string{}
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				statements = append(statements, line[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(statements, line[start:])
+}
+
+// unescape turns Mermaid text into plain text: quotes and Markdown string backticks
+// are removed, entity codes decoded and <br> tags become line breaks
+func unescape(text string) string {
+	text = unquote(strings.TrimSpace(text))
+	if len(text) >= 2 && strings.HasPrefix(text, "`") && strings.HasSuffix(text, "`") {
+		text = text[1 : len(text)-1]
+	}
+
+	text = breakPattern.ReplaceAllString(text, "\n")
+	return entityPattern.ReplaceAllStringFunc(text, func(entity string) string {
+		name := entity[1 : len(entity)-1]
+		if code, err := strconv.Atoi(name); err == nil {
+			return string(rune(code))
+		}
+		if value, ok := entities[strings.ToLower(name)]; ok {
+			return value
+		}
+		return entity
+	})
+}
+
+func unquote(text string) string {
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		if unquoted, err := strconv.Unquote(text); err == nil {
+			return unquoted
+		}
+		return text[1 : len(text)-1]
+	}
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return text[1 : len(text)-1]
+	}
+	return text
+}
@@ -0,0 +1,214 @@
+package mermaid
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseShapes(t *testing.T) {
+	for _, shape := range Shapes {
+		chart, err := Parse("flowchart LR\n    n" + shape.Open + "Some text" + shape.Close)
+		if err != nil {
+			t.Errorf("%s: %v", shape.Name, err)
+			continue
+		}
+
+		want := []FlowNode{{ID: "n", Text: "Some text", Shape: shape, Declared: true}}
+		if !reflect.DeepEqual(chart.Nodes, want) || len(chart.Problems) != 0 {
+			t.Errorf("%s: nodes = %+v, problems = %v", shape.Name, chart.Nodes, chart.Problems)
+		}
+	}
+}
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		statement string
+		text      string
+	}{
+		{`a["Say #quot;hi#quot;<br>there"]`, "Say \"hi\"\nthere"},
+		{`a["semi; colon"]`, "semi; colon"},
+		{"a[\"`Markdown`\"]", "Markdown"},
+		{`a["#35; and #amp;"]`, "# and &"},
+		{`a(["quoted ) bracket"])`, "quoted ) bracket"},
+		{`a`, "a"},
+	}
+
+	for _, test := range tests {
+		chart, err := Parse("graph\n" + test.statement)
+		if err != nil {
+			t.Errorf("%s: %v", test.statement, err)
+			continue
+		}
+		if len(chart.Nodes) != 1 || chart.Nodes[0].Text != test.text {
+			t.Errorf("%s: nodes = %+v, want text %q", test.statement, chart.Nodes, test.text)
+		}
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		statement string
+		edges     []FlowEdge
+	}{
+		{"a --> b", []FlowEdge{{From: "a", To: "b"}}},
+		{"a --- b", []FlowEdge{{From: "a", To: "b"}}},
+		{"a-->b", []FlowEdge{{From: "a", To: "b"}}},
+		{"a -- yes --> b", []FlowEdge{{From: "a", To: "b", Label: "yes"}}},
+		{"a -->|yes| b", []FlowEdge{{From: "a", To: "b", Label: "yes"}}},
+		{"a -.-> b", []FlowEdge{{From: "a", To: "b", Dotted: true}}},
+		{"a -. maybe .-> b", []FlowEdge{{From: "a", To: "b", Label: "maybe", Dotted: true}}},
+		{"a -.->|maybe| b", []FlowEdge{{From: "a", To: "b", Label: "maybe", Dotted: true}}},
+		{"a ==> b", []FlowEdge{{From: "a", To: "b", Thick: true}}},
+		{"a == big ==> b", []FlowEdge{{From: "a", To: "b", Label: "big", Thick: true}}},
+		{"a --> b --> c", []FlowEdge{{From: "a", To: "b"}, {From: "b", To: "c"}}},
+		{"a & b --> c & d", []FlowEdge{{From: "a", To: "c"}, {From: "a", To: "d"}, {From: "b", To: "c"}, {From: "b", To: "d"}}},
+		{"a[A] -- one --> b{B} -.-> c & d", []FlowEdge{{From: "a", To: "b", Label: "one"}, {From: "b", To: "c", Dotted: true}, {From: "b", To: "d", Dotted: true}}},
+		{"a --> b; b --> c", []FlowEdge{{From: "a", To: "b"}, {From: "b", To: "c"}}},
+		{"my-node --> other-node", []FlowEdge{{From: "my-node", To: "other-node"}}},
+	}
+
+	for _, test := range tests {
+		chart, err := Parse("flowchart TD\n" + test.statement)
+		if err != nil {
+			t.Errorf("%s: %v", test.statement, err)
+			continue
+		}
+		if !reflect.DeepEqual(chart.Edges, test.edges) || len(chart.Problems) != 0 {
+			t.Errorf("%s: edges = %+v, problems = %v\nwant %+v", test.statement, chart.Edges, chart.Problems, test.edges)
+		}
+	}
+}
+
+func TestParseSubgraphs(t *testing.T) {
+	chart, err := Parse(`flowchart TB
+    subgraph outer [Outer box]
+        a
+        subgraph inner
+            b --> c
+        end
+        subgraph Two words
+            d
+        end
+    end
+    a --> e
+    c --> f`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subgraphs := []Subgraph{
+		{ID: "outer", Title: "Outer box"},
+		{ID: "inner", Title: "inner", Parent: "outer"},
+		{ID: "subgraph_3", Title: "Two words", Parent: "outer"},
+	}
+	if !reflect.DeepEqual(chart.Subgraphs, subgraphs) {
+		t.Errorf("subgraphs = %+v", chart.Subgraphs)
+	}
+
+	// A node belongs to the subgraph it is first written in
+	got := map[string]string{}
+	for _, node := range chart.Nodes {
+		got[node.ID] = node.Subgraph
+	}
+	want := map[string]string{"a": "outer", "b": "inner", "c": "inner", "d": "subgraph_3", "e": "", "f": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("node subgraphs = %v, want %v", got, want)
+	}
+	if len(chart.Problems) != 0 {
+		t.Errorf("problems = %v", chart.Problems)
+	}
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	chart, err := Parse("\n---\ntitle: \"My chart\"\nconfig:\n  theme: dark\n---\nflowchart LR\n    a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart.Title != "My chart" || chart.Direction != DirectionLeftRight || len(chart.Nodes) != 1 {
+		t.Errorf("chart = %+v", chart)
+	}
+
+	chart, err = Parse("---\ntitle: 'Single'\n---\ngraph\n    a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart.Title != "Single" || chart.Direction != DirectionTopDown {
+		t.Errorf("chart = %+v", chart)
+	}
+}
+
+func TestParseProblems(t *testing.T) {
+	chart, err := Parse(`flowchart XY; a --> b
+    classDef red fill:#f00
+    class a red
+    style a fill:#f00
+    %% a comment
+    linkStyle 0 stroke:#f00
+
+    click a callback
+    accTitle: Title
+    a ~~~ b
+    c:::red --> d
+    e -->
+    f --> [x]
+    subgraph s
+        direction LR
+    end
+    end
+    subgraph s
+    subgraph open`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Problem{
+		{1, `unknown direction "XY", laid out top down`},
+		{2, unsupportedStatements["classDef"]},
+		{3, unsupportedStatements["class"]},
+		{4, unsupportedStatements["style"]},
+		{6, unsupportedStatements["linkStyle"]},
+		{8, unsupportedStatements["click"]},
+		{9, unsupportedStatements["accTitle"]},
+		{10, "invisible links are not supported, the link is ignored"},
+		{11, "classes are not supported, ::: class names are ignored"},
+		{12, `could not read a node at column 6 of "e -->", the statement is ignored`},
+		{13, `could not read a node at column 7 of "f --> [x]", the statement is ignored`},
+		{15, "subgraph directions are not supported, the flowchart direction is used"},
+		{17, "end without a subgraph"},
+		{18, "subgraph s is declared twice, the second is imported as a separate group"},
+		{18, "subgraph s_2 is not closed with end"},
+		{19, "subgraph open is not closed with end"},
+	}
+	if !reflect.DeepEqual(chart.Problems, want) {
+		t.Errorf("problems:")
+		for _, problem := range chart.Problems {
+			t.Errorf("    %s", problem)
+		}
+	}
+
+	// Statements with problems keep nothing, and the rest are imported
+	if len(chart.Edges) != 2 || chart.Direction != DirectionTopDown {
+		t.Errorf("edges = %+v, direction = %s", chart.Edges, chart.Direction)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		line int
+	}{
+		{"sequenceDiagram\n    a->>b: hi", 1},
+		{"\n%% only a comment\n", 3},
+		{"---\ntitle: x\nflowchart LR", 1},
+		{"\n\n---\ntitle: x", 3},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.text)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) || syntax.Line != test.line {
+			t.Errorf("%q: error = %v, want a syntax error on line %d", test.text, err, test.line)
+		}
+	}
+}
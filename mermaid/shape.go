@@ -0,0 +1,89 @@
+package mermaid
+
+import "strings"
+
+// Shape is a Mermaid flowchart node shape, written as the brackets around the node text
+type Shape struct {
+	Name  string
+	Open  string
+	Close string
+}
+
+var (
+	ShapeRectangle        = Shape{"rectangle", "[", "]"}
+	ShapeRounded          = Shape{"rounded", "(", ")"}
+	ShapeStadium          = Shape{"stadium", "([", "])"}
+	ShapeSubroutine       = Shape{"subroutine", "[[", "]]"}
+	ShapeCylinder         = Shape{"cylinder", "[(", ")]"}
+	ShapeCircle           = Shape{"circle", "((", "))"}
+	ShapeDoubleCircle     = Shape{"double_circle", "(((", ")))"}
+	ShapeAsymmetric       = Shape{"asymmetric", ">", "]"}
+	ShapeRhombus          = Shape{"rhombus", "{", "}"}
+	ShapeHexagon          = Shape{"hexagon", "{{", "}}"}
+	ShapeParallelogram    = Shape{"parallelogram", "[/", "/]"}
+	ShapeParallelogramAlt = Shape{"parallelogram_alt", "[\\", "\\]"}
+	ShapeTrapezoid        = Shape{"trapezoid", "[/", "\\]"}
+	ShapeTrapezoidAlt     = Shape{"trapezoid_alt", "[\\", "/]"}
+)
+
+// Shapes lists every shape, longest brackets first, the order a parser must try them in
+var Shapes = []Shape{
+	ShapeDoubleCircle,
+	ShapeStadium, ShapeSubroutine, ShapeCylinder, ShapeCircle, ShapeHexagon,
+	ShapeParallelogram, ShapeParallelogramAlt, ShapeTrapezoid, ShapeTrapezoidAlt,
+	ShapeRectangle, ShapeRounded, ShapeAsymmetric, ShapeRhombus,
+}
+
+// categoryShapes maps node categories to shapes. Categories not listed are drawn as
+// rectangles.
+var categoryShapes = map[string]Shape{
+	"process":     ShapeRectangle,
+	"rounded":     ShapeRounded,
+	"start":       ShapeStadium,
+	"end":         ShapeStadium,
+	"terminal":    ShapeStadium,
+	"subroutine":  ShapeSubroutine,
+	"database":    ShapeCylinder,
+	"storage":     ShapeCylinder,
+	"circle":      ShapeCircle,
+	"event":       ShapeCircle,
+	"stop":        ShapeDoubleCircle,
+	"note":        ShapeAsymmetric,
+	"comment":     ShapeAsymmetric,
+	"decision":    ShapeRhombus,
+	"condition":   ShapeRhombus,
+	"preparation": ShapeHexagon,
+	"hexagon":     ShapeHexagon,
+	"input":       ShapeParallelogram,
+	"output":      ShapeParallelogramAlt,
+	"manual":      ShapeTrapezoid,
+	"trapezoid":   ShapeTrapezoidAlt,
+}
+
+// shapeCategories is the category a parsed shape becomes. Rectangles get no category.
+var shapeCategories = map[string]string{
+	ShapeRounded.Name:          "rounded",
+	ShapeStadium.Name:          "terminal",
+	ShapeSubroutine.Name:       "subroutine",
+	ShapeCylinder.Name:         "database",
+	ShapeCircle.Name:           "circle",
+	ShapeDoubleCircle.Name:     "stop",
+	ShapeAsymmetric.Name:       "note",
+	ShapeRhombus.Name:          "decision",
+	ShapeHexagon.Name:          "preparation",
+	ShapeParallelogram.Name:    "input",
+	ShapeParallelogramAlt.Name: "output",
+	ShapeTrapezoid.Name:        "manual",
+	ShapeTrapezoidAlt.Name:     "trapezoid",
+}
+
+func ShapeOf(category string) Shape {
+	if shape, ok := categoryShapes[strings.ToLower(category)]; ok {
+		return shape
+	}
+	return ShapeRectangle
+}
+
+func CategoryOf(shape Shape) string {
+	return shapeCategories[shape.Name]
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Check compares a committed specification with the one generated from the route
+// table, and returns an error describing the drift. It is meant to be called from a
+// test in the service that owns the routes, so a handler change without a spec update
+// fails the build.
+func Check(spec []byte, routes []axon_types.Route, options Options) error {
+	var committed Document
+	if err := json.Unmarshal(spec, &committed); err != nil {
+		return fmt.Errorf("could not parse the committed specification - %s", err.Error())
+	}
+
+	generated, err := Generate(routes, options)
+	if err != nil {
+		return err
+	}
+
+	problems := []string{}
+
+	committedOperations := toSet(committed.Operations())
+	for _, operation := range generated.Operations() {
+		if !committedOperations[operation] {
+			problems = append(problems, "missing from specification: "+operation)
+		}
+	}
+
+	generatedOperations := toSet(generated.Operations())
+	for _, operation := range committed.Operations() {
+		if !generatedOperations[operation] {
+			problems = append(problems, "no handler for: "+operation)
+		}
+	}
+
+	for path, item := range generated.Paths {
+		for method, operation := range item {
+			committedOperation, ok := committed.Paths[path][method]
+			if ok && !sameJSON(operation, committedOperation) {
+				problems = append(problems, fmt.Sprintf("operation differs: %s %s", strings.ToUpper(method), path))
+			}
+		}
+	}
+
+	for name, schema := range generated.Components.Schemas {
+		committedSchema, ok := committed.Components.Schemas[name]
+		if !ok {
+			problems = append(problems, "schema missing from specification: "+name)
+			continue
+		}
+		if !sameJSON(schema, committedSchema) {
+			problems = append(problems, "schema differs: "+name)
+		}
+	}
+
+	for name := range committed.Components.Schemas {
+		if _, ok := generated.Components.Schemas[name]; !ok {
+			problems = append(problems, "schema no longer used: "+name)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.Strings(problems)
+	return fmt.Errorf("specification has drifted from the routes:\n  %s", strings.Join(problems, "\n  "))
+}
+
+func sameJSON(a interface{}, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+const (
+	OPENAPI_VERSION string = "3.0.3"
+
+	cookieAuth = "cookieAuth"
+	bearerAuth = "bearerAuth"
+)
+
+type Options struct {
+	Title       string
+	Version     string
+	Description string
+	// Body of every error response, e.g. handlers.ErrorResponse{}
+	ErrorResponse interface{}
+	// Extra types documented as component schemas even if no route uses them
+	Schemas []interface{}
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower case methods to operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Generate builds an OpenAPI 3 document from a route table. Body schemas are derived
+// from the json tags of the route Request and Response types.
+func Generate(routes []axon_types.Route, options Options) (*Document, error) {
+	schemas := newSchemaRegistry()
+
+	doc := &Document{
+		OpenAPI: OPENAPI_VERSION,
+		Info: Info{
+			Title:       options.Title,
+			Version:     options.Version,
+			Description: options.Description,
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas: schemas.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				cookieAuth: {Type: "apiKey", In: "cookie", Name: axon_types.AUTH_SESSION},
+				bearerAuth: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	var errorSchema *Schema
+	if options.ErrorResponse != nil {
+		errorSchema = schemas.schemaOf(options.ErrorResponse)
+	}
+
+	for _, schema := range options.Schemas {
+		schemas.schemaOf(schema)
+	}
+
+	for _, route := range routes {
+		method := strings.ToLower(route.Method)
+		if method == "" {
+			return nil, fmt.Errorf("route %s has no method", route.Path)
+		}
+
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[route.Path] = item
+		}
+
+		if _, exists := item[method]; exists {
+			return nil, fmt.Errorf("route %s %s is defined twice", route.Method, route.Path)
+		}
+
+		item[method] = operation(route, schemas, errorSchema)
+	}
+
+	return doc, nil
+}
+
+// Route returns a public route serving the generated document as JSON
+func Route(path string, routes []axon_types.Route, options Options) (axon_types.Route, error) {
+	doc, err := Generate(routes, options)
+	if err != nil {
+		return axon_types.Route{}, err
+	}
+
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return axon_types.Route{}, err
+	}
+
+	return axon_types.Route{
+		Path:    path,
+		Method:  http.MethodGet,
+		Auth:    axon_types.PublicRoute,
+		Summary: "OpenAPI specification",
+		Handler: func(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(body)
+		},
+	}, nil
+}
+
+func operation(route axon_types.Route, schemas *schemaRegistry, errorSchema *Schema) *Operation {
+	op := &Operation{
+		OperationId: operationId(route),
+		Summary:     route.Summary,
+		Responses:   map[string]Response{},
+	}
+
+	for _, segment := range strings.Split(route.Path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     segment[1 : len(segment)-1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: schemas.schemaOf(route.Request)}},
+		}
+	}
+
+	status := successStatus(route)
+	response := Response{Description: http.StatusText(status)}
+	if route.Response != nil && status != http.StatusNoContent {
+		response.Content = map[string]MediaType{"application/json": {Schema: schemas.schemaOf(route.Response)}}
+	}
+	op.Responses[fmt.Sprint(status)] = response
+
+	if errorSchema != nil {
+		content := map[string]MediaType{"application/json": {Schema: errorSchema}}
+		for _, status := range route.Errors {
+			op.Responses[fmt.Sprint(status)] = Response{Description: http.StatusText(status), Content: content}
+		}
+		op.Responses["default"] = Response{Description: "Error", Content: content}
+	}
+
+	if route.Auth == axon_types.PrivateRoute {
+		op.Security = []map[string][]string{{cookieAuth: {}}, {bearerAuth: {}}}
+	}
+
+	return op
+}
+
+func successStatus(route axon_types.Route) int {
+	if route.Status != 0 {
+		return route.Status
+	}
+
+	switch strings.ToUpper(route.Method) {
+	case http.MethodPost:
+		return http.StatusCreated
+	case http.MethodDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// GET /folders/{folder_id}/notes becomes get_folders_folder_id_notes
+func operationId(route axon_types.Route) string {
+	parts := []string{strings.ToLower(route.Method)}
+	for _, segment := range strings.Split(route.Path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment != "" {
+			parts = append(parts, strings.ReplaceAll(segment, "-", "_"))
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// Operations lists "METHOD path" for every operation in the document, sorted
+func (d *Document) Operations() []string {
+	operations := []string{}
+	for path, item := range d.Paths {
+		for method := range item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
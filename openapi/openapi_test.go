@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Tag shares its name with types.Tag
+type Tag struct {
+	Label string `json:"label"`
+}
+
+func testRoutes() []axon_types.Route {
+	return []axon_types.Route{
+		{Path: "/tags", Method: http.MethodGet, Auth: axon_types.PrivateRoute, Response: []axon_types.Tag{}},
+		{Path: "/labels", Method: http.MethodPost, Auth: axon_types.PrivateRoute, Request: Tag{}, Response: Tag{}, Status: http.StatusCreated},
+	}
+}
+
+func TestComponentNamesIncludePackage(t *testing.T) {
+	doc, err := Generate(testRoutes(), Options{Title: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"types.Tag", "openapi.Tag"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is missing, have %v", name, keys(doc.Components.Schemas))
+		}
+	}
+
+	if _, ok := doc.Components.Schemas["openapi.Tag"].Properties["label"]; !ok {
+		t.Errorf("openapi.Tag = %+v, want the label property", doc.Components.Schemas["openapi.Tag"])
+	}
+	if _, ok := doc.Components.Schemas["types.Tag"].Properties["notes"]; !ok {
+		t.Errorf("types.Tag = %+v, want the notes property", doc.Components.Schemas["types.Tag"])
+	}
+}
+
+func TestCheck(t *testing.T) {
+	options := Options{Title: "Test"}
+
+	doc, err := Generate(testRoutes(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Check(spec, testRoutes(), options); err != nil {
+		t.Fatalf("the generated specification does not check: %v", err)
+	}
+
+	// A route added without updating the specification
+	added := append(testRoutes(), axon_types.Route{Path: "/labels/{label}", Method: http.MethodDelete, Auth: axon_types.PrivateRoute})
+	err = Check(spec, added, options)
+	if err == nil || !strings.Contains(err.Error(), "missing from specification: DELETE /labels/{label}") {
+		t.Errorf("Check with an added route = %v", err)
+	}
+
+	// A response type changed without updating the specification
+	changed := testRoutes()
+	changed[1].Response = axon_types.Tag{}
+	err = Check(spec, changed, options)
+	if err == nil || !strings.Contains(err.Error(), "operation differs: POST /labels") {
+		t.Errorf("Check with a changed response = %v", err)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	routes := testRoutes()
+	routes[1].Errors = []int{http.StatusBadRequest}
+
+	doc, err := Generate(routes, Options{Title: "Test", ErrorResponse: Tag{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := doc.Paths["/labels"]["post"].Responses
+	for _, status := range []string{"201", "400", "default"} {
+		if _, ok := got[status]; !ok {
+			t.Errorf("POST /labels has no %s response, have %v", status, got)
+		}
+	}
+	if response := got["400"]; response.Description != "Bad Request" || response.Content["application/json"].Schema.Ref != "#/components/schemas/openapi.Tag" {
+		t.Errorf("400 response = %+v", response)
+	}
+
+	if len(doc.Paths["/tags"]["get"].Responses) != 2 {
+		t.Errorf("GET /tags responses = %v, want the success and default responses", doc.Paths["/tags"]["get"].Responses)
+	}
+}
+
+func keys(schemas map[string]*Schema) []string {
+	names := []string{}
+	for name := range schemas {
+		names = append(names, name)
+	}
+	return names
+}
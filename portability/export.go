@@ -0,0 +1,110 @@
+package portability
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Export writes every folder and note owned by the session user to w as a zip archive.
+// Notes are read and written one at a time, so large accounts are not held in memory.
+// Nothing is written to w when the folders cannot be listed. An error after that
+// leaves the archive without its manifest.
+func Export(a *axon_types.AxonContext, session axon_types.Session, w io.Writer) (*Manifest, error) {
+	folder := axon_core.Folder{Session: session}
+	note := axon_core.Note{Session: session}
+
+	// Folders are listed in full before anything is written, every page of them
+	sorted := []axon_types.Folder{}
+	err := folder.EachFolder(a, func(f axon_types.Folder) error {
+		sorted = append(sorted, f)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("could not export account - " + err.Error())
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FolderID < sorted[j].FolderID
+	})
+
+	user := session.SessionData.User
+	manifest := Manifest{
+		Format:     FORMAT,
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		UserID:     user.UserId,
+		Email:      user.Email,
+		Contents:   contents,
+	}
+
+	archive := zip.NewWriter(w)
+
+	if err := writeFile(archive, README_FILE, []byte(readme)); err != nil {
+		return nil, err
+	}
+	if err := writeDocument(archive, PROFILE_FILE, user); err != nil {
+		return nil, err
+	}
+
+	for _, f := range sorted {
+		if err := writeDocument(archive, FolderPath(f.FolderID), f); err != nil {
+			return nil, err
+		}
+		manifest.Counts.Folders++
+
+		err := note.EachNote(a, f.FolderID, func(n axon_types.Note) error {
+			detail, err := note.GetNoteDetail(a, f.FolderID, n.NoteID)
+			if err != nil {
+				return fmt.Errorf("could not export note %s - %w", n.NoteID, err)
+			}
+
+			if err := writeDocument(archive, NotePath(f.FolderID, n.NoteID), detail); err != nil {
+				return err
+			}
+			manifest.Counts.Notes++
+			manifest.Counts.Nodes += len(detail.Nodes)
+			manifest.Counts.Edges += len(detail.Edges)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not export folder %s - %w", f.FolderID, err)
+		}
+	}
+
+	if err := writeDocument(archive, MANIFEST_FILE, manifest); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, errors.New("could not export account - " + err.Error())
+	}
+
+	return &manifest, nil
+}
+
+func writeDocument(archive *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not export %s - %s", name, err.Error())
+	}
+	return writeFile(archive, name, append(data, '\n'))
+}
+
+func writeFile(archive *zip.Writer, name string, data []byte) error {
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("could not export %s - %s", name, err.Error())
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("could not export %s - %s", name, err.Error())
+	}
+	return nil
+}
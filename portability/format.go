@@ -0,0 +1,157 @@
+package portability
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// FORMAT names the archive format in its manifest, so other zip files are rejected
+const FORMAT = "axon-account"
+
+// Version of the archive layout and records written by Export. Raise it whenever a
+// change to the records would not read correctly as the previous version, and add the
+// step that upgrades the previous version to upgrades.
+const Version = 1
+
+// ErrInvalidArchive is returned for archives that cannot be imported as they are
+var ErrInvalidArchive = errors.New("invalid archive")
+
+const (
+	MANIFEST_FILE string = "manifest.json"
+	README_FILE   string = "README.md"
+	PROFILE_FILE  string = "profile.json"
+	FOLDERS_DIR   string = "folders"
+	FOLDER_FILE   string = "folder.json"
+	NOTES_DIR     string = "notes"
+)
+
+// Kinds of the JSON documents in an archive, passed to upgrade steps
+const (
+	KindProfile string = "profile"
+	KindFolder  string = "folder"
+	KindNote    string = "note"
+)
+
+// Manifest describes an archive. It is written last, so an archive cut short while
+// streaming has none and is rejected on import.
+type Manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	// Account the archive was exported from
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Counts Counts `json:"counts"`
+	// What each path in the archive holds
+	Contents map[string]string `json:"contents"`
+}
+
+type Counts struct {
+	Folders int `json:"folders"`
+	Notes   int `json:"notes"`
+	Nodes   int `json:"nodes"`
+	Edges   int `json:"edges"`
+}
+
+var contents = map[string]string{
+	MANIFEST_FILE: "format, version and counts of this archive",
+	README_FILE:   "this description",
+	PROFILE_FILE:  "the user profile",
+	path.Join(FOLDERS_DIR, "<folder_id>", FOLDER_FILE):                 "a folder",
+	path.Join(FOLDERS_DIR, "<folder_id>", NOTES_DIR, "<note_id>.json"): "a note of the folder, with its nodes and edges",
+}
+
+const readme = `# Axon account archive
+
+This archive holds every folder and note of one Axon account, as JSON:
+
+    manifest.json                          format, version and counts
+    profile.json                           the user profile
+    folders/<folder_id>/folder.json        a folder
+    folders/<folder_id>/notes/<id>.json    a note, with its nodes and edges
+
+The manifest version is the version of the layout and of the records. Axon imports
+archives of its own version and older ones, upgrading them as they are read.
+`
+
+// upgrades holds the step from each version to the next, the step from version v at
+// index v-1. A step changes a decoded document of the given kind in place.
+var upgrades = []func(kind string, document map[string]interface{}) error{}
+
+func FolderPath(folder_id string) string {
+	return path.Join(FOLDERS_DIR, folder_id, FOLDER_FILE)
+}
+
+func NotePath(folder_id string, note_id string) string {
+	return path.Join(FOLDERS_DIR, folder_id, NOTES_DIR, note_id+".json")
+}
+
+// parsePath returns the kind of document at a path and the folder it belongs to, or
+// "" for files that are not documents
+func parsePath(name string) (string, string) {
+	if name == PROFILE_FILE {
+		return KindProfile, ""
+	}
+
+	parts := strings.Split(name, "/")
+	switch {
+	case len(parts) == 3 && parts[0] == FOLDERS_DIR && parts[2] == FOLDER_FILE:
+		return KindFolder, parts[1]
+	case len(parts) == 4 && parts[0] == FOLDERS_DIR && parts[2] == NOTES_DIR && strings.HasSuffix(parts[3], ".json"):
+		return KindNote, parts[1]
+	}
+	return "", ""
+}
+
+// decode reads a document written by version into v, upgrading it to the current
+// version first
+func decode(data []byte, kind string, version int, v interface{}) error {
+	if version == Version {
+		return json.Unmarshal(data, v)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	if err := upgrade(upgrades, kind, document, version, Version); err != nil {
+		return err
+	}
+
+	upgraded, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+// upgrade runs the steps that take a document from version from to version to, in order
+func upgrade(steps []func(kind string, document map[string]interface{}) error, kind string, document map[string]interface{}, from int, to int) error {
+	for version := from; version < to; version++ {
+		if version < 1 || version-1 >= len(steps) {
+			return fmt.Errorf("no upgrade from version %d", version)
+		}
+		if err := steps[version-1](kind, document); err != nil {
+			return fmt.Errorf("could not upgrade from version %d - %s", version, err.Error())
+		}
+	}
+	return nil
+}
+
+func checkManifest(manifest Manifest) error {
+	if manifest.Format != FORMAT {
+		return fmt.Errorf("%w - the archive is not an %s archive", ErrInvalidArchive, FORMAT)
+	}
+	if manifest.Version < 1 {
+		return fmt.Errorf("%w - archive version %d is not valid", ErrInvalidArchive, manifest.Version)
+	}
+	if manifest.Version > Version {
+		return fmt.Errorf("%w - archive version %d is newer than version %d this server reads", ErrInvalidArchive, manifest.Version, Version)
+	}
+	return nil
+}
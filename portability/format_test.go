@@ -0,0 +1,200 @@
+package portability
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// archiveOf zips documents by path, encoding each one as Export does
+func archiveOf(t *testing.T, documents map[string]interface{}) *zip.Reader {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, document := range documents {
+		if err := writeDocument(writer, name, document); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+func manifestOf(version int) Manifest {
+	return Manifest{Format: FORMAT, Version: version, Email: "a@example.com"}
+}
+
+func TestCheck(t *testing.T) {
+	archive := archiveOf(t, map[string]interface{}{
+		MANIFEST_FILE:        manifestOf(Version),
+		PROFILE_FILE:         axon_types.User{Email: "a@example.com"},
+		FolderPath("f2"):     axon_types.Folder{FolderID: "f2"},
+		FolderPath("f1"):     axon_types.Folder{FolderID: "f1"},
+		NotePath("f1", "n2"): axon_types.NoteDetail{NoteID: "n2"},
+		NotePath("f1", "n1"): axon_types.NoteDetail{NoteID: "n1"},
+		"extra.txt":          "hand written",
+	})
+
+	manifest, folders, warnings, err := check(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Email != "a@example.com" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+
+	got := []string{}
+	for _, folder := range folders {
+		got = append(got, folder.file.Name)
+		for _, note := range folder.notes {
+			got = append(got, note.Name)
+		}
+	}
+	want := []string{FolderPath("f1"), NotePath("f1", "n1"), NotePath("f1", "n2"), FolderPath("f2")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("folders = %v, want %v", got, want)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "extra.txt") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestCheckRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		documents map[string]interface{}
+		problem   string
+	}{
+		{"no manifest", map[string]interface{}{
+			FolderPath("f1"): axon_types.Folder{FolderID: "f1"},
+		}, "has no manifest.json"},
+		{"other format", map[string]interface{}{
+			MANIFEST_FILE: Manifest{Format: "zip", Version: Version},
+		}, "is not an axon-account archive"},
+		{"version 0", map[string]interface{}{
+			MANIFEST_FILE: manifestOf(0),
+		}, "version 0 is not valid"},
+		{"newer version", map[string]interface{}{
+			MANIFEST_FILE: manifestOf(Version + 1),
+		}, "is newer than"},
+		{"folder id", map[string]interface{}{
+			MANIFEST_FILE:    manifestOf(Version),
+			FolderPath("f1"): axon_types.Folder{FolderID: "f2"},
+		}, `holds folder "f2"`},
+		{"note id", map[string]interface{}{
+			MANIFEST_FILE:        manifestOf(Version),
+			FolderPath("f1"):     axon_types.Folder{FolderID: "f1"},
+			NotePath("f1", "n1"): axon_types.NoteDetail{NoteID: "n2"},
+		}, `holds note "n2"`},
+		{"note twice", map[string]interface{}{
+			MANIFEST_FILE:        manifestOf(Version),
+			FolderPath("f1"):     axon_types.Folder{FolderID: "f1"},
+			FolderPath("f2"):     axon_types.Folder{FolderID: "f2"},
+			NotePath("f1", "n1"): axon_types.NoteDetail{NoteID: "n1"},
+			NotePath("f2", "n1"): axon_types.NoteDetail{NoteID: "n1"},
+		}, "is in the archive twice"},
+		{"notes without folder", map[string]interface{}{
+			MANIFEST_FILE:        manifestOf(Version),
+			NotePath("f1", "n1"): axon_types.NoteDetail{NoteID: "n1"},
+		}, "without folders/f1/folder.json"},
+		{"invalid document", map[string]interface{}{
+			MANIFEST_FILE:    manifestOf(Version),
+			FolderPath("f1"): []string{"not", "a", "folder"},
+		}, "could not read folders/f1/folder.json"},
+	}
+
+	for _, test := range tests {
+		_, _, _, err := check(archiveOf(t, test.documents))
+		if !errors.Is(err, ErrInvalidArchive) || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("%s: error = %v, want ErrInvalidArchive with %q", test.name, err, test.problem)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var folder axon_types.Folder
+	if err := decode([]byte(`{"folder_id": "f1", "folder_name": "Notes"}`), KindFolder, Version, &folder); err != nil {
+		t.Fatal(err)
+	}
+	if folder.FolderID != "f1" || folder.FolderName != "Notes" {
+		t.Errorf("folder = %+v", folder)
+	}
+
+	if err := decode([]byte(`{"folder_id": "f1"}`), KindFolder, 0, &folder); err == nil {
+		t.Errorf("decoded a document of version 0")
+	}
+	if err := decode([]byte(`{"folder_id":`), KindFolder, Version, &folder); err == nil {
+		t.Errorf("decoded invalid JSON")
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	// Version 1 renamed title to name, version 2 added a kind field to notes
+	steps := []func(kind string, document map[string]interface{}) error{
+		func(kind string, document map[string]interface{}) error {
+			document["name"] = document["title"]
+			delete(document, "title")
+			return nil
+		},
+		func(kind string, document map[string]interface{}) error {
+			if kind != KindNote {
+				return nil
+			}
+			if _, ok := document["name"]; !ok {
+				return errors.New("name is missing")
+			}
+			document["kind"] = "note"
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		kind     string
+		document map[string]interface{}
+		from     int
+		to       int
+		want     map[string]interface{}
+		problem  string
+	}{
+		{"whole chain", KindNote, map[string]interface{}{"title": "A"}, 1, 3, map[string]interface{}{"name": "A", "kind": "note"}, ""},
+		{"last step", KindNote, map[string]interface{}{"name": "A"}, 2, 3, map[string]interface{}{"name": "A", "kind": "note"}, ""},
+		{"kind", KindFolder, map[string]interface{}{"title": "A"}, 1, 3, map[string]interface{}{"name": "A"}, ""},
+		{"current", KindNote, map[string]interface{}{"name": "A"}, 3, 3, map[string]interface{}{"name": "A"}, ""},
+		{"failed step", KindNote, map[string]interface{}{}, 2, 3, nil, "could not upgrade from version 2 - name is missing"},
+		{"missing step", KindNote, map[string]interface{}{}, 1, 4, nil, "no upgrade from version 3"},
+		{"version 0", KindNote, map[string]interface{}{}, 0, 3, nil, "no upgrade from version 0"},
+	}
+
+	for _, test := range tests {
+		err := upgrade(steps, test.kind, test.document, test.from, test.to)
+		if test.problem != "" {
+			if err == nil || err.Error() != test.problem {
+				t.Errorf("%s: error = %v, want %q", test.name, err, test.problem)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.document, test.want) {
+			t.Errorf("%s: document = %v, want %v", test.name, test.document, test.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package portability
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Largest document read from an archive, so a small zip cannot expand without limit
+const maxDocumentSize = 64 << 20
+
+type ImportReport struct {
+	// Version of the archive, older versions are upgraded as they are read
+	Version int `json:"version"`
+	// Account the archive was exported from
+	SourceEmail string `json:"source_email"`
+	Counts      Counts `json:"counts"`
+	// New IDs of the folders and notes, by their IDs in the archive
+	Folders map[string]string `json:"folders"`
+	Notes   map[string]string `json:"notes"`
+	// Parts of the archive that were skipped
+	Warnings []string `json:"warnings"`
+}
+
+// archiveFolder is a folder document and the note documents stored under it
+type archiveFolder struct {
+	file  *zip.File
+	notes []*zip.File
+}
+
+// Import restores an archive into the session user's account, which must have no
+// folders yet. Every folder, note, node and edge is given a new ID, so an archive can
+// be imported alongside the account it came from. The archive is read through once to
+// check it before anything is written, and a failure part way removes the folders
+// already written, so the import can be retried.
+func Import(a *axon_types.AxonContext, session axon_types.Session, r io.ReaderAt, size int64) (*ImportReport, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrInvalidArchive, err.Error())
+	}
+
+	manifest, folders, warnings, err := check(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := (&axon_core.Folder{Session: session}).GetFolders(a)
+	if err != nil {
+		return nil, errors.New("could not import account - " + err.Error())
+	}
+	if len(*existing) > 0 {
+		return nil, fmt.Errorf("could not import account - archives are imported into accounts without folders - %w", axon_core.ErrConflict)
+	}
+
+	report := &ImportReport{
+		Version:     manifest.Version,
+		SourceEmail: manifest.Email,
+		Folders:     map[string]string{},
+		Notes:       map[string]string{},
+		Warnings:    warnings,
+	}
+
+	writer := axon_core.Import{Session: session}
+
+	if err := write(a, writer, folders, manifest.Version, report); err != nil {
+		return nil, rollback(a, session, report, err)
+	}
+
+	return report, nil
+}
+
+// write imports the folders and their notes, recording each new ID in the report as
+// soon as it is written
+func write(a *axon_types.AxonContext, writer axon_core.Import, folders []archiveFolder, version int, report *ImportReport) error {
+	for _, f := range folders {
+		var folder axon_types.Folder
+		if err := readDocument(f.file, KindFolder, version, &folder); err != nil {
+			return err
+		}
+
+		archivedId := folder.FolderID
+		folder.FolderID = uuid.New().String()
+		if err := writer.PutFolder(a, folder); err != nil {
+			return err
+		}
+		report.Folders[archivedId] = folder.FolderID
+		report.Counts.Folders++
+
+		for _, file := range f.notes {
+			var note axon_types.NoteDetail
+			if err := readDocument(file, KindNote, version, &note); err != nil {
+				return err
+			}
+
+			note, dropped := connected(note)
+			for _, edge := range dropped {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("edge %s of note %s connects a node that is not in the note and is skipped", edge.EdgeID, note.NoteID))
+			}
+
+			renumbered := axon_core.RenumberNote(note, folder.FolderID)
+			if err := writer.PutNote(a, renumbered); err != nil {
+				return err
+			}
+			report.Notes[note.NoteID] = renumbered.NoteID
+			report.Counts.Notes++
+			report.Counts.Nodes += len(renumbered.Nodes)
+			report.Counts.Edges += len(renumbered.Edges)
+		}
+	}
+
+	return nil
+}
+
+// rollback deletes the folders an import wrote before it failed, with their notes,
+// nodes and edges, and returns the cause
+func rollback(a *axon_types.AxonContext, session axon_types.Session, report *ImportReport, cause error) error {
+	folder := axon_core.Folder{Session: session}
+	for _, folder_id := range report.Folders {
+		if _, err := folder.DeleteFolder(a, folder_id); err != nil {
+			return fmt.Errorf("%w - and could not remove the folders already imported - %s", cause, err.Error())
+		}
+	}
+	return cause
+}
+
+// check reads the manifest and every document of an archive, and returns the folders
+// with their notes in path order. Files that are not part of the format are reported
+// and left out.
+func check(archive *zip.Reader) (*Manifest, []archiveFolder, []string, error) {
+	files := append([]*zip.File{}, archive.File...)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	var manifest *Manifest
+	for _, file := range files {
+		if file.Name == MANIFEST_FILE {
+			manifest = &Manifest{}
+			if err := readDocument(file, "", Version, manifest); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	if manifest == nil {
+		return nil, nil, nil, fmt.Errorf("%w - the archive has no %s, it may be incomplete", ErrInvalidArchive, MANIFEST_FILE)
+	}
+	if err := checkManifest(*manifest); err != nil {
+		return nil, nil, nil, err
+	}
+
+	warnings := []string{}
+	byId := map[string]*archiveFolder{}
+	noteIds := map[string]bool{}
+	order := []string{}
+
+	for _, file := range files {
+		kind, folder_id := parsePath(file.Name)
+		switch kind {
+		case KindProfile:
+			var user axon_types.User
+			if err := readDocument(file, kind, manifest.Version, &user); err != nil {
+				return nil, nil, nil, err
+			}
+
+		case KindFolder:
+			var folder axon_types.Folder
+			if err := readDocument(file, kind, manifest.Version, &folder); err != nil {
+				return nil, nil, nil, err
+			}
+			if folder.FolderID != folder_id {
+				return nil, nil, nil, fmt.Errorf("%w - %s holds folder %q", ErrInvalidArchive, file.Name, folder.FolderID)
+			}
+			if byId[folder_id] == nil {
+				byId[folder_id] = &archiveFolder{}
+				order = append(order, folder_id)
+			}
+			byId[folder_id].file = file
+
+		case KindNote:
+			var note axon_types.NoteDetail
+			if err := readDocument(file, kind, manifest.Version, &note); err != nil {
+				return nil, nil, nil, err
+			}
+			if note.NoteID == "" || note.NoteID != strings.TrimSuffix(path.Base(file.Name), ".json") {
+				return nil, nil, nil, fmt.Errorf("%w - %s holds note %q", ErrInvalidArchive, file.Name, note.NoteID)
+			}
+			if noteIds[note.NoteID] {
+				return nil, nil, nil, fmt.Errorf("%w - note %s is in the archive twice", ErrInvalidArchive, note.NoteID)
+			}
+			noteIds[note.NoteID] = true
+			if byId[folder_id] == nil {
+				byId[folder_id] = &archiveFolder{}
+				order = append(order, folder_id)
+			}
+			byId[folder_id].notes = append(byId[folder_id].notes, file)
+
+		default:
+			if file.Name != MANIFEST_FILE && file.Name != README_FILE && !strings.HasSuffix(file.Name, "/") {
+				warnings = append(warnings, fmt.Sprintf("%s is not part of the archive format and is skipped", file.Name))
+			}
+		}
+	}
+
+	folders := make([]archiveFolder, 0, len(order))
+	for _, folder_id := range order {
+		folder := byId[folder_id]
+		if folder.file == nil {
+			return nil, nil, nil, fmt.Errorf("%w - notes of folder %s are in the archive without %s", ErrInvalidArchive, folder_id, FolderPath(folder_id))
+		}
+		folders = append(folders, *folder)
+	}
+
+	return manifest, folders, warnings, nil
+}
+
+func readDocument(file *zip.File, kind string, version int, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w - could not open %s - %s", ErrInvalidArchive, file.Name, err.Error())
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxDocumentSize+1))
+	if err != nil {
+		return fmt.Errorf("%w - could not read %s - %s", ErrInvalidArchive, file.Name, err.Error())
+	}
+	if len(data) > maxDocumentSize {
+		return fmt.Errorf("%w - %s is larger than %d bytes", ErrInvalidArchive, file.Name, maxDocumentSize)
+	}
+
+	if err := decode(data, kind, version, v); err != nil {
+		return fmt.Errorf("%w - could not read %s - %s", ErrInvalidArchive, file.Name, err.Error())
+	}
+	return nil
+}
+
+// connected returns the note without its edges to nodes that are not in it, and the
+// edges left out
+func connected(note axon_types.NoteDetail) (axon_types.NoteDetail, []axon_types.Edge) {
+	nodes := make(map[string]bool, len(note.Nodes))
+	for _, node := range note.Nodes {
+		nodes[node.NodeID] = true
+	}
+
+	kept := make([]axon_types.Edge, 0, len(note.Edges))
+	dropped := []axon_types.Edge{}
+	for _, edge := range note.Edges {
+		if nodes[edge.SourceID] && nodes[edge.TargetID] {
+			kept = append(kept, edge)
+		} else {
+			dropped = append(dropped, edge)
+		}
+	}
+
+	note.Edges = kept
+	return note, dropped
+}
@@ -0,0 +1,251 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	axon_session "github.com/stephensanwo/axon-lib/session"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Router turns a []types.Route table into an http.Handler. Paths are matched by
+// segment, a segment written as {name} is a path parameter, e.g.
+// /folders/{folder_id}/notes/{note_id}. Private routes require an authenticated session.
+type Router struct {
+	axonContext    *axon_types.AxonContext
+	sessionManager axon_session.SessionManager
+	routes         []route
+
+	// Optional handlers for unmatched paths and methods, plain text errors are used otherwise
+	NotFound         http.Handler
+	MethodNotAllowed http.Handler
+
+	// Optional source of the base context, e.g. config.Watcher.AxonContext, so each
+	// request sees the current settings. The context passed to New is used otherwise.
+	Context func() *axon_types.AxonContext
+}
+
+type route struct {
+	axon_types.Route
+	segments []string
+	handler  axon_session.Handler
+}
+
+func New(a *axon_types.AxonContext, sessionManager axon_session.SessionManager, routes []axon_types.Route) (*Router, error) {
+	r := &Router{
+		axonContext:    a,
+		sessionManager: sessionManager,
+	}
+
+	for _, route := range routes {
+		if err := r.Handle(route); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Handle registers a route. Routes with the same method and path are rejected.
+func (r *Router) Handle(rt axon_types.Route) error {
+	if rt.Handler == nil {
+		return fmt.Errorf("route %s %s has no handler", rt.Method, rt.Path)
+	}
+
+	if rt.Method == "" {
+		return fmt.Errorf("route %s has no method", rt.Path)
+	}
+	rt.Method = strings.ToUpper(rt.Method)
+
+	if !strings.HasPrefix(rt.Path, "/") {
+		return fmt.Errorf("route %s %s must start with /", rt.Method, rt.Path)
+	}
+
+	segments := splitPath(rt.Path)
+	params := map[string]bool{}
+	for _, segment := range segments {
+		name, ok := paramName(segment)
+		if !ok {
+			continue
+		}
+		if name == "" || params[name] {
+			return fmt.Errorf("route %s %s has an empty or repeated path parameter", rt.Method, rt.Path)
+		}
+		params[name] = true
+	}
+
+	var handler axon_session.Handler
+	switch rt.Auth {
+	case axon_types.PrivateRoute:
+		handler = r.sessionManager.Authenticate(rt.Handler)
+	case axon_types.PublicRoute:
+		handler = rt.Handler
+	default:
+		return fmt.Errorf("route %s %s has unknown auth %q", rt.Method, rt.Path, rt.Auth)
+	}
+
+	for _, existing := range r.routes {
+		if existing.Method == rt.Method && samePattern(existing.segments, segments) {
+			return fmt.Errorf("route %s %s is already registered", rt.Method, rt.Path)
+		}
+	}
+
+	r.routes = append(r.routes, route{Route: rt, segments: segments, handler: handler})
+
+	// Routes with more static segments take precedence, /notes/shared before /notes/{note_id}
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return staticCount(r.routes[i].segments) > staticCount(r.routes[j].segments)
+	})
+
+	return nil
+}
+
+// Routes returns the registered route table
+func (r *Router) Routes() []axon_types.Route {
+	routes := make([]axon_types.Route, len(r.routes))
+	for i, route := range r.routes {
+		routes[i] = route.Route
+	}
+	return routes
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	segments := splitPath(req.URL.Path)
+
+	var allowed []string
+	for _, route := range r.routes {
+		params, ok := match(route.segments, segments)
+		if !ok {
+			continue
+		}
+
+		if route.Method != req.Method && !(req.Method == http.MethodHead && route.Method == http.MethodGet) {
+			allowed = append(allowed, route.Method)
+			// GET routes also answer HEAD
+			if route.Method == http.MethodGet {
+				allowed = append(allowed, http.MethodHead)
+			}
+			continue
+		}
+
+		// Each request gets its own AxonContext, carrying the path parameters
+		base := r.axonContext
+		if r.Context != nil {
+			base = r.Context()
+		}
+
+		requestCtx := *base
+		requestCtx.SessionId = ""
+		requestCtx.Context = context.WithValue(req.Context(), axon_types.PATH_PARAMS_CONTEXT_KEY, params)
+
+		route.handler(w, req.WithContext(requestCtx.Context), &requestCtx)
+		return
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(uniqueSorted(allowed), ", "))
+		if r.MethodNotAllowed != nil {
+			r.MethodNotAllowed.ServeHTTP(w, req)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(w, req)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// Param returns a path parameter of the current request, or "" if it is not set
+func Param(a *axon_types.AxonContext, name string) string {
+	return Params(a)[name]
+}
+
+// Params returns all path parameters of the current request
+func Params(a *axon_types.AxonContext) map[string]string {
+	if a == nil || a.Context == nil {
+		return nil
+	}
+	params, _ := a.Context.Value(axon_types.PATH_PARAMS_CONTEXT_KEY).(map[string]string)
+	return params
+}
+
+func match(pattern []string, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, segment := range pattern {
+		if name, ok := paramName(segment); ok {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[name] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+func paramName(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+func samePattern(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		_, aParam := paramName(a[i])
+		_, bParam := paramName(b[i])
+		if aParam != bParam || (!aParam && a[i] != b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func staticCount(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if _, ok := paramName(segment); !ok {
+			count++
+		}
+	}
+	return count
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	axon_session "github.com/stephensanwo/axon-lib/session"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// public returns a public route answering with its name and the path parameters
+func public(method string, path string, name string) axon_types.Route {
+	return axon_types.Route{
+		Path:   path,
+		Method: method,
+		Auth:   axon_types.PublicRoute,
+		Handler: func(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+			w.Write([]byte(name + " " + Param(a, "folder_id") + " " + Param(a, "note_id")))
+		},
+	}
+}
+
+func newRouter(t *testing.T) *Router {
+	t.Helper()
+
+	r, err := New(&axon_types.AxonContext{Context: context.Background()}, axon_session.SessionManager{}, []axon_types.Route{
+		public(http.MethodGet, "/folders/{folder_id}/notes/{note_id}", "note"),
+		public(http.MethodDelete, "/folders/{folder_id}/notes/{note_id}", "delete"),
+		public(http.MethodGet, "/folders/{folder_id}/notes/shared", "shared"),
+		public(http.MethodPost, "/folders", "create"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{http.MethodGet, "/folders/f1/notes/n1", http.StatusOK, "note f1 n1", ""},
+		{http.MethodGet, "/folders/f1/notes/n1/", http.StatusOK, "note f1 n1", ""},
+		{http.MethodDelete, "/folders/f1/notes/n1", http.StatusOK, "delete f1 n1", ""},
+		// A static segment wins over a parameter, whichever was registered first
+		{http.MethodGet, "/folders/f1/notes/shared", http.StatusOK, "shared f1 ", ""},
+		{http.MethodHead, "/folders/f1/notes/n1", http.StatusOK, "", ""},
+		{http.MethodGet, "/folders/f1/notes", http.StatusNotFound, "404 page not found\n", ""},
+		{http.MethodGet, "/folders//notes/n1", http.StatusNotFound, "404 page not found\n", ""},
+		{http.MethodPut, "/folders/f1/notes/n1", http.StatusMethodNotAllowed, "Method Not Allowed\n", "DELETE, GET, HEAD"},
+		{http.MethodGet, "/folders", http.StatusMethodNotAllowed, "Method Not Allowed\n", "POST"},
+	}
+
+	r := newRouter(t)
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		body := w.Body.String()
+		if test.method == http.MethodHead {
+			body = ""
+		}
+		if w.Code != test.status || body != test.body || w.Header().Get("Allow") != test.allow {
+			t.Errorf("%s %s = %d %q, Allow %q, want %d %q, Allow %q", test.method, test.path, w.Code, body, w.Header().Get("Allow"), test.status, test.body, test.allow)
+		}
+	}
+}
+
+func TestFallbackHandlers(t *testing.T) {
+	r := newRouter(t)
+	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	for path, status := range map[string]int{"/missing": http.StatusTeapot, "/folders": http.StatusConflict} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != status {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, status)
+		}
+	}
+}
+
+func TestHandleRejects(t *testing.T) {
+	r := newRouter(t)
+
+	for name, route := range map[string]axon_types.Route{
+		"duplicate":       public(http.MethodGet, "/folders/{id}/notes/{other}", "again"),
+		"relative":        public(http.MethodGet, "folders", "relative"),
+		"repeated param":  public(http.MethodGet, "/a/{id}/{id}", "repeated"),
+		"empty param":     public(http.MethodGet, "/a/{}", "empty"),
+		"no method":       public("", "/a", "none"),
+		"unknown auth":    {Path: "/a", Method: http.MethodGet, Auth: "team", Handler: public(http.MethodGet, "/a", "").Handler},
+		"missing handler": {Path: "/a", Method: http.MethodGet, Auth: axon_types.PublicRoute},
+	} {
+		if err := r.Handle(route); err == nil {
+			t.Errorf("%s: registered %s %s", name, route.Method, route.Path)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package search
+
+import (
+	"math"
+	"sort"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Weight of a match in each field. Names, titles and labels say what a document is
+// about, descriptions and content only mention things.
+var fieldWeights = map[string]float64{
+	axon_types.SearchFieldName:        4,
+	axon_types.SearchFieldLabel:       4,
+	axon_types.SearchFieldTitle:       4,
+	axon_types.SearchFieldDescription: 2,
+	axon_types.SearchFieldMarkdown:    1,
+}
+
+// Fields in the order they are looked at, which breaks ties between fields
+var fieldOrder = []string{
+	axon_types.SearchFieldName,
+	axon_types.SearchFieldTitle,
+	axon_types.SearchFieldLabel,
+	axon_types.SearchFieldDescription,
+	axon_types.SearchFieldMarkdown,
+}
+
+// Order of kinds in results with the same score
+var kindRank = map[string]int{
+	axon_types.SearchKindFolder: 0,
+	axon_types.SearchKindNote:   1,
+	axon_types.SearchKindNode:   2,
+}
+
+// Quality of each kind of match, a whole word counts most. Prefix and fuzzy matches
+// count up to prefixQuality and fuzzyQuality, less the further they are from the word.
+const (
+	exactQuality  = 1.0
+	prefixQuality = 0.7
+	fuzzyQuality  = 0.6
+)
+
+// hit is a match of a clause in a field
+type hit struct {
+	start   int
+	end     int
+	quality float64
+}
+
+// Match scores a document against a query. It returns false when the document does
+// not match every clause. The snippet is taken from the field with the best matches.
+func Match(document axon_types.SearchDocument, query Query) (axon_types.SearchResult, bool) {
+	fields := map[string][]token{}
+	for _, field := range fieldOrder {
+		if text := document.Fields[field]; text != "" {
+			fields[field] = tokenize(text)
+		}
+	}
+
+	score := 0.0
+	fieldScores := map[string]float64{}
+	fieldHits := map[string][]hit{}
+
+	for _, clause := range query.Clauses {
+		best := 0.0
+		for _, field := range fieldOrder {
+			hits := matchClause(clause, fields[field])
+			if len(hits) == 0 {
+				continue
+			}
+
+			clauseScore := fieldWeights[field] * bestQuality(hits) * float64(len(clause.Terms)) * (1 + math.Log(float64(len(hits))))
+			if clauseScore > best {
+				best = clauseScore
+			}
+			fieldScores[field] += clauseScore
+			fieldHits[field] = append(fieldHits[field], hits...)
+		}
+		if best == 0 {
+			return axon_types.SearchResult{}, false
+		}
+		score += best
+	}
+
+	field := ""
+	for _, f := range fieldOrder {
+		if fieldScores[f] > fieldScores[field] {
+			field = f
+		}
+	}
+
+	snippet, highlights := makeSnippet(document.Fields[field], fieldHits[field])
+
+	return axon_types.SearchResult{
+		Kind:       document.Kind,
+		FolderID:   document.FolderID,
+		NoteID:     document.NoteID,
+		NodeID:     document.NodeID,
+		Title:      Title(document),
+		Score:      math.Round(score*1000) / 1000,
+		Field:      field,
+		Snippet:    snippet,
+		Highlights: highlights,
+	}, true
+}
+
+// Title is the name of a folder or note, or the title of a node, or its label when it
+// has no title
+func Title(document axon_types.SearchDocument) string {
+	for _, field := range []string{axon_types.SearchFieldName, axon_types.SearchFieldTitle, axon_types.SearchFieldLabel} {
+		if text := document.Fields[field]; text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// Rank sorts results best first. Equal scores put folders before notes before nodes,
+// then sort by title, so the order is stable.
+func Rank(results []axon_types.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if kindRank[a.Kind] != kindRank[b.Kind] {
+			return kindRank[a.Kind] < kindRank[b.Kind]
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		if a.FolderID != b.FolderID {
+			return a.FolderID < b.FolderID
+		}
+		if a.NoteID != b.NoteID {
+			return a.NoteID < b.NoteID
+		}
+		return a.NodeID < b.NodeID
+	})
+}
+
+func matchClause(clause Clause, tokens []token) []hit {
+	hits := []hit{}
+
+	if clause.Kind == ClausePhrase {
+		for i := 0; i+len(clause.Terms) <= len(tokens); i++ {
+			matched := true
+			for j, term := range clause.Terms {
+				if tokens[i+j].term != term {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				hits = append(hits, hit{start: tokens[i].start, end: tokens[i+len(clause.Terms)-1].end, quality: exactQuality})
+			}
+		}
+		return hits
+	}
+
+	term := clause.Terms[0]
+	for _, t := range tokens {
+		quality := 0.0
+		switch {
+		case t.term == term:
+			quality = exactQuality
+		case clause.Kind == ClausePrefix && len(t.term) > len(term) && t.term[:len(term)] == term:
+			quality = prefixQuality * float64(len(term)) / float64(len(t.term))
+			quality = math.Max(quality, prefixQuality/2)
+		case clause.Kind == ClauseFuzzy && clause.Edits > 0:
+			if edits := distance(t.term, term, clause.Edits); edits <= clause.Edits {
+				quality = fuzzyQuality / float64(edits)
+			}
+		}
+		if quality > 0 {
+			hits = append(hits, hit{start: t.start, end: t.end, quality: quality})
+		}
+	}
+	return hits
+}
+
+func bestQuality(hits []hit) float64 {
+	best := 0.0
+	for _, h := range hits {
+		if h.quality > best {
+			best = h.quality
+		}
+	}
+	return best
+}
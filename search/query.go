@@ -0,0 +1,177 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidQuery is returned for queries that cannot be searched for
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Most clauses a query may have, each clause is matched against every document
+const maxClauses = 16
+
+// Most edits a fuzzy term may allow
+const maxEdits = 2
+
+// Kinds of clauses
+const (
+	ClauseTerm   = "term"
+	ClausePrefix = "prefix"
+	ClauseFuzzy  = "fuzzy"
+	ClausePhrase = "phrase"
+)
+
+// Query is a parsed search query. A document matches when it matches every clause.
+type Query struct {
+	Clauses []Clause
+}
+
+// Clause is a word, a word prefix, a word with a number of edits allowed, or a phrase
+// of words that must follow each other in the same field
+type Clause struct {
+	Kind  string
+	Terms []string
+	Edits int
+}
+
+// Parse reads a query. Words are matched whole, word* matches words starting with
+// word, word~ matches words a few typos away, word~1 or word~2 sets how many, and
+// "quoted words" match as a phrase. Words are split the way fields are, so a word
+// like e-mail is the phrase "e mail".
+func Parse(q string) (Query, error) {
+	query := Query{}
+
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		var clause Clause
+		var err error
+		if rest[0] == '"' {
+			clause, rest = parsePhrase(rest[1:])
+		} else {
+			clause, rest, err = parseWord(rest)
+			if err != nil {
+				return Query{}, err
+			}
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+
+		if len(clause.Terms) == 0 {
+			continue
+		}
+		query.Clauses = append(query.Clauses, clause)
+	}
+
+	if len(query.Clauses) == 0 {
+		return Query{}, fmt.Errorf("%w - the query has no words to search for", ErrInvalidQuery)
+	}
+	if len(query.Clauses) > maxClauses {
+		return Query{}, fmt.Errorf("%w - the query has more than %d words or phrases", ErrInvalidQuery, maxClauses)
+	}
+
+	return query, nil
+}
+
+// parsePhrase reads a phrase up to its closing quote, or the end of the query when the
+// quote is not closed
+func parsePhrase(q string) (Clause, string) {
+	text, rest := q, ""
+	if end := strings.IndexByte(q, '"'); end >= 0 {
+		text, rest = q[:end], q[end+1:]
+	}
+
+	words := terms(text)
+	if len(words) == 1 {
+		return Clause{Kind: ClauseTerm, Terms: words}, rest
+	}
+	return Clause{Kind: ClausePhrase, Terms: words}, rest
+}
+
+func parseWord(q string) (Clause, string, error) {
+	end := strings.IndexFunc(q, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '"'
+	})
+	if end < 0 {
+		end = len(q)
+	}
+	word, rest := q[:end], q[end:]
+
+	clause := Clause{Kind: ClauseTerm}
+	switch {
+	case strings.HasSuffix(word, "*"):
+		clause.Kind = ClausePrefix
+		word = strings.TrimRight(word, "*")
+
+	case strings.Contains(word, "~"):
+		at := strings.LastIndexByte(word, '~')
+		clause.Kind = ClauseFuzzy
+		clause.Edits = -1
+		if suffix := word[at+1:]; suffix != "" {
+			edits, err := strconv.Atoi(suffix)
+			if err != nil || edits < 0 || edits > maxEdits {
+				return Clause{}, "", fmt.Errorf("%w - %s must end in ~ or ~0 to ~%d", ErrInvalidQuery, word, maxEdits)
+			}
+			clause.Edits = edits
+		}
+		word = word[:at]
+	}
+
+	clause.Terms = terms(word)
+	if len(clause.Terms) > 1 {
+		// Words joined by punctuation match as a phrase, the * or ~ is dropped
+		return Clause{Kind: ClausePhrase, Terms: clause.Terms}, rest, nil
+	}
+	if clause.Kind == ClauseFuzzy && clause.Edits < 0 && len(clause.Terms) == 1 {
+		clause.Edits = autoEdits(clause.Terms[0])
+	}
+	return clause, rest, nil
+}
+
+// IndexTerm returns the word a document must contain to match the clause, for looking
+// it up in a term index, and whether documents with words starting with it match too.
+// A phrase is looked up by its longest word. Fuzzy words with edits are looked up with
+// FuzzyPrefixes instead.
+func (c Clause) IndexTerm() (string, bool, bool) {
+	switch c.Kind {
+	case ClauseTerm:
+		return c.Terms[0], false, true
+	case ClausePrefix:
+		return c.Terms[0], true, true
+	case ClauseFuzzy:
+		return c.Terms[0], false, c.Edits == 0
+	case ClausePhrase:
+		longest := c.Terms[0]
+		for _, term := range c.Terms[1:] {
+			if len(term) > len(longest) {
+				longest = term
+			}
+		}
+		return longest, false, true
+	}
+	return "", false, false
+}
+
+// FuzzyPrefixes returns the prefixes of the indexed words a fuzzy clause with edits is
+// looked up under: the first letter of its word, and the second, which covers a swap
+// of the first two letters and an extra letter in front. A typo in the first letter
+// of a word is not found.
+func (c Clause) FuzzyPrefixes() []string {
+	if c.Kind != ClauseFuzzy || c.Edits == 0 {
+		return nil
+	}
+
+	letters := []rune(c.Terms[0])
+	prefixes := []string{string(letters[0])}
+	if len(letters) > 1 && letters[1] != letters[0] {
+		prefixes = append(prefixes, string(letters[1]))
+	}
+	return prefixes
+}
+
+// MatchesWord reports whether an indexed word is within the edits of a fuzzy clause
+func (c Clause) MatchesWord(word string) bool {
+	return c.Kind == ClauseFuzzy && distance(word, c.Terms[0], c.Edits) <= c.Edits
+}
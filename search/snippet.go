@@ -0,0 +1,133 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// Longest snippet in bytes, before the ellipses, and how much text comes before the
+// first match in it
+const (
+	snippetLength  = 160
+	snippetContext = 40
+)
+
+const ellipsis = "…"
+
+// makeSnippet cuts the part of text around its first hits, with runs of whitespace
+// made single spaces, and returns it with the ranges of the hits in it
+func makeSnippet(text string, hits []hit) (string, []axon_types.SearchHighlight) {
+	spans := mergeHits(hits)
+
+	start, end := 0, len(text)
+	if len(text) > snippetLength {
+		if len(spans) > 0 {
+			start = wordStart(text, spans[0].start-snippetContext)
+		}
+		end = wordEnd(text, start, start+snippetLength)
+		if end-start < snippetLength/2 {
+			// The text ends soon after the hit, show more before it
+			start = wordStart(text, end-snippetLength)
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+
+	// offsets maps byte offsets of text to offsets in the snippet
+	offsets := make(map[int]int, end-start+1)
+	space := false
+	for i, r := range text[start:end] {
+		offsets[start+i] = b.Len()
+		if unicode.IsSpace(r) {
+			if !space && b.Len() > 0 && !strings.HasSuffix(b.String(), ellipsis) {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	snippet := strings.TrimRight(b.String(), " ")
+	offsets[end] = len(snippet)
+	if end < len(text) {
+		snippet += ellipsis
+	}
+
+	highlights := []axon_types.SearchHighlight{}
+	for _, span := range spans {
+		if span.start < start || span.end > end {
+			continue
+		}
+		highlights = append(highlights, axon_types.SearchHighlight{Start: offsets[span.start], End: offsets[span.end]})
+	}
+
+	return snippet, highlights
+}
+
+// mergeHits sorts hits and joins the ones that overlap
+func mergeHits(hits []hit) []hit {
+	sorted := append([]hit{}, hits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start < sorted[j].start
+	})
+
+	merged := []hit{}
+	for _, h := range sorted {
+		if last := len(merged) - 1; last >= 0 && h.start <= merged[last].end {
+			if h.end > merged[last].end {
+				merged[last].end = h.end
+			}
+			continue
+		}
+		merged = append(merged, h)
+	}
+	return merged
+}
+
+// wordStart moves offset back to the start of the word it is in, or 0
+func wordStart(text string, offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+	for offset > 0 && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	for offset > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:offset])
+		if unicode.IsSpace(r) {
+			break
+		}
+		offset -= size
+	}
+	return offset
+}
+
+// wordEnd moves offset back to the end of the last whole word after from and before
+// offset, or to the end of text
+func wordEnd(text string, from int, offset int) int {
+	if offset >= len(text) {
+		return len(text)
+	}
+	for offset > 0 && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	limit := offset
+	for offset > from {
+		r, _ := utf8.DecodeRuneInString(text[offset:])
+		if unicode.IsSpace(r) {
+			return offset
+		}
+		_, size := utf8.DecodeLastRuneInString(text[:offset])
+		offset -= size
+	}
+	// A single word longer than the snippet is cut
+	return limit
+}
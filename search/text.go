@@ -0,0 +1,129 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// token is a word of a field, in lower case, with its byte range in the field text
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// tokenize splits text into words, runs of letters and digits. Everything else,
+// markdown syntax included, separates words.
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// terms returns the words of text in lower case
+func terms(text string) []string {
+	tokens := tokenize(text)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.term
+	}
+	return words
+}
+
+// DocumentTerms returns the distinct words of the fields of a document, sorted
+func DocumentTerms(document axon_types.SearchDocument) []string {
+	seen := map[string]bool{}
+	for _, text := range document.Fields {
+		for _, term := range terms(text) {
+			seen[term] = true
+		}
+	}
+
+	words := make([]string, 0, len(seen))
+	for term := range seen {
+		words = append(words, term)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// autoEdits is the number of edits a fuzzy term allows when the query does not say:
+// none for very short words, where one edit makes a different word, two for long ones
+func autoEdits(term string) int {
+	switch length := utf8.RuneCountInString(term); {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance is the number of insertions, deletions, substitutions and transpositions
+// of adjacent letters between a and b, or limit+1 once it is over limit
+func distance(a string, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	// Three rows are enough for transpositions
+	previous2 := make([]int, len(rb)+1)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		smallest := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			best := previous[j] + 1
+			if current[j-1]+1 < best {
+				best = current[j-1] + 1
+			}
+			if previous[j-1]+cost < best {
+				best = previous[j-1] + cost
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && previous2[j-2]+1 < best {
+				best = previous2[j-2] + 1
+			}
+			current[j] = best
+			if best < smallest {
+				smallest = best
+			}
+		}
+		if smallest > limit {
+			return limit + 1
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+
+	if previous[len(rb)] > limit {
+		return limit + 1
+	}
+	return previous[len(rb)]
+}
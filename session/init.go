@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	axon_coredb "github.com/stephensanwo/axon-lib/coredb"
+	axon_keyring "github.com/stephensanwo/axon-lib/keyring"
 	axon_types "github.com/stephensanwo/axon-lib/types"
 
 	"crypto/rand"
@@ -33,13 +34,32 @@ func (s SessionManager) CreateSession(w http.ResponseWriter, a *axon_types.AxonC
 	cookie := http.Cookie{Name: s.CookieName, Value: s.SessionId, Path: "/", HttpOnly: true, Expires: expiration}
 	http.SetCookie(w, &cookie)
 
+	// Seal the OAuth token before it is written to the cache
+	keyRing, err := axon_keyring.New(a.Settings.SecuritySettings)
+	if err != nil {
+		log.Panicln("Error creating user session - " + err.Error())
+	}
+
+	sealedSession, err := keyRing.SealSession(*sessionData)
+	if err != nil {
+		log.Panicln("Error creating user session - " + err.Error())
+	}
+
 	// Cache Session Data
-	err = db.CacheData(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", s.SessionId), s.SessionId, sessionData, 12 * 60 * 60)
+	err = db.CacheData(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("SESSION#%s", s.SessionId), s.SessionId, sealedSession, axon_types.SESSION_TTL)
 
 	if err != nil {
 		log.Panicln("Error saving session in cache")
 	}
 
+	// Index the session under its user, so account deletion can revoke it
+	index := axon_types.UserSession{SessionId: s.SessionId, DateCreated: time.Now()}
+	err = db.CacheData(axon_types.AXON_USER_SESSION_TABLE, fmt.Sprintf("USER_SESSION#%s", sessionData.SessionData.User.Email), s.SessionId, index, axon_types.SESSION_TTL)
+
+	if err != nil {
+		log.Panicln("Error saving session index in cache")
+	}
+
 }
 
 func NewSessionId() string {
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+type Handler func(http.ResponseWriter, *http.Request, *axon_types.AxonContext)
+
+// Authenticate wraps a handler so it only runs for an authenticated caller. Callers
+// authenticate with the session cookie, or with an `Authorization: Bearer` personal
+// access token. The resolved session is stored on a per-request copy of the AxonContext.
+func (s SessionManager) Authenticate(handler Handler) Handler {
+	return func(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) {
+		session, ok := s.resolveSession(w, r, a)
+		if !ok {
+			return
+		}
+
+		requestCtx := *a
+		requestCtx.SessionId = session.SessionId
+		requestCtx.Context = context.WithValue(requestContext(a, r), axon_types.SESSION_CONTEXT_KEY, *session)
+
+		handler(w, r, &requestCtx)
+	}
+}
+
+// GetSession returns the session stored on the AxonContext by Authenticate
+func GetSession(a *axon_types.AxonContext) (axon_types.Session, bool) {
+	if a == nil || a.Context == nil {
+		return axon_types.Session{}, false
+	}
+	session, ok := a.Context.Value(axon_types.SESSION_CONTEXT_KEY).(axon_types.Session)
+	return session, ok
+}
+
+func (s SessionManager) resolveSession(w http.ResponseWriter, r *http.Request, a *axon_types.AxonContext) (*axon_types.Session, bool) {
+
+	// Bearer tokens take precedence over the cookie
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+			http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+			return nil, false
+		}
+
+		session, err := axon_core.AuthenticateToken(a, strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+			return nil, false
+		}
+
+		if !axon_core.HasScope(*session, requiredScope(r.Method)) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+			http.Error(w, "token does not have the required scope", http.StatusForbidden)
+			return nil, false
+		}
+
+		return session, true
+	}
+
+	cookieName := s.CookieName
+	if cookieName == "" {
+		cookieName = axon_types.AUTH_SESSION
+	}
+
+	cookie, err := r.Cookie(cookieName)
+	if err != nil || cookie.Value == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	sessionCtx := *a
+	sessionCtx.SessionId = cookie.Value
+
+	user := axon_core.User{}
+	session, err := user.GetAuthenticatedUserData(&sessionCtx)
+	if err != nil || session.SessionId == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return &session, true
+}
+
+// Safe methods only need read access, everything else mutates data
+func requiredScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return axon_types.TokenScopeRead
+	}
+	return axon_types.TokenScopeWrite
+}
+
+func requestContext(a *axon_types.AxonContext, r *http.Request) context.Context {
+	if a.Context != nil {
+		return a.Context
+	}
+	return r.Context()
+}
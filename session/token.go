@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	axon_core "github.com/stephensanwo/axon-lib/core"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+	"golang.org/x/oauth2"
+)
+
+var ErrSessionExpired = errors.New("session has expired, sign in again")
+
+// NewSessionData builds the session for a signed in user, keeping the full OAuth token
+// so it can be refreshed later.
+func (s SessionManager) NewSessionData(user *axon_types.User, token *oauth2.Token) *axon_types.Session {
+	return &axon_types.Session{
+		SessionId: s.SessionId,
+		SessionData: axon_types.UserCache{
+			User:  *user,
+			Token: token,
+		},
+	}
+}
+
+type sessionTokenSource struct {
+	a       *axon_types.AxonContext
+	session axon_types.Session
+	source  oauth2.TokenSource
+	mu      sync.Mutex
+	current *oauth2.Token
+}
+
+// TokenSource returns an oauth2.TokenSource for the provider token of a session. Tokens
+// are refreshed with the AxonContext OAuth config when they expire and the refreshed
+// token is written back to the session store. When the provider rejects the refresh the
+// session is deleted and ErrSessionExpired is returned, other refresh errors are returned
+// as they are.
+func TokenSource(a *axon_types.AxonContext, session axon_types.Session) oauth2.TokenSource {
+	ctx := a.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return &sessionTokenSource{
+		a:       a,
+		session: session,
+		source:  a.Oauth.TokenSource(ctx, session.SessionData.Token),
+		current: session.SessionData.Token,
+	}
+}
+
+func (s *sessionTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return nil, ErrSessionExpired
+	}
+
+	token, err := s.source.Token()
+	if err != nil && revoked(err) {
+		s.invalidate(err)
+		return nil, ErrSessionExpired
+	}
+	if err != nil {
+		// The provider may be unreachable, the session stays valid for the next request
+		return nil, errors.New("could not refresh session token - " + err.Error())
+	}
+
+	if token.AccessToken != s.current.AccessToken {
+		// Providers may omit the refresh token on refresh, keep the one we have
+		if token.RefreshToken == "" {
+			token.RefreshToken = s.current.RefreshToken
+		}
+
+		s.session.SessionData.Token = token
+		user := axon_core.User{}
+		if err := user.UpdateSession(s.a, s.session); err != nil {
+			log.Warnln("Error saving refreshed token - " + err.Error())
+		}
+	}
+
+	s.current = token
+	return token, nil
+}
+
+// revoked reports whether a refresh failed because the provider no longer accepts the
+// grant, as opposed to a network failure, a provider outage or a cancelled request
+func revoked(err error) bool {
+	var retrieve *oauth2.RetrieveError
+	if !errors.As(err, &retrieve) {
+		return false
+	}
+
+	// GitHub answers 200 with bad_refresh_token when the refresh token is revoked or expired
+	switch retrieve.ErrorCode {
+	case "invalid_grant", "bad_refresh_token":
+		return true
+	}
+
+	return retrieve.Response != nil && (retrieve.Response.StatusCode == http.StatusBadRequest || retrieve.Response.StatusCode == http.StatusUnauthorized)
+}
+
+func (s *sessionTokenSource) invalidate(cause error) {
+	log.Infoln("Invalidating session after token refresh failed - " + cause.Error())
+
+	s.current = nil
+
+	// Token sessions have no provider token to refresh, and nothing to invalidate
+	if s.session.Scopes != nil {
+		return
+	}
+
+	user := axon_core.User{}
+	if err := user.DeleteSession(s.a, s.session.SessionId); err != nil {
+		log.Warnln("Error deleting session - " + err.Error())
+	}
+}
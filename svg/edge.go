@@ -0,0 +1,148 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+// route is the path an edge is drawn along, with the point its label is centred on
+type route struct {
+	edge   axon_types.Edge
+	path   string
+	labelX float64
+	labelY float64
+	// Corners of the area the path may reach, to fit it in the view box
+	minX, minY, maxX, maxY float64
+}
+
+// routeEdges draws each edge as a line between the outlines of its nodes. Edges that
+// join the same two nodes are spread out as curves so they do not cover each other,
+// and an edge from a node to itself is a loop on its top right corner. Edges to nodes
+// that are not in the note are left out.
+func routeEdges(edges []axon_types.Edge, boxes map[string]box) []route {
+	pairs := map[string]int{}
+	for _, edge := range edges {
+		if _, ok := boxes[edge.SourceID]; !ok {
+			continue
+		}
+		if _, ok := boxes[edge.TargetID]; !ok {
+			continue
+		}
+		pairs[pairKey(edge)]++
+	}
+
+	routes := []route{}
+	seen := map[string]int{}
+	for _, edge := range edges {
+		source, sourceOk := boxes[edge.SourceID]
+		target, targetOk := boxes[edge.TargetID]
+		if !sourceOk || !targetOk {
+			continue
+		}
+
+		key := pairKey(edge)
+		index, count := seen[key], pairs[key]
+		seen[key]++
+
+		if edge.SourceID == edge.TargetID {
+			routes = append(routes, loop(edge, source, index))
+			continue
+		}
+
+		// The side edges are spread to is taken from the pair, not the edge, so an edge
+		// and its reverse curve apart
+		first, second := source, target
+		if edge.SourceID > edge.TargetID {
+			first, second = target, source
+		}
+		fx, fy := first.centre()
+		sx, sy := second.centre()
+		dx, dy := sx-fx, sy-fy
+		length := math.Hypot(dx, dy)
+
+		offset := (float64(index) - float64(count-1)/2) * edgeSpacing
+		if offset == 0 || length == 0 {
+			x1, y1 := target.centre()
+			x0, y0 := source.clip(x1, y1)
+			x1, y1 = target.clip(source.centre())
+			routes = append(routes, route{
+				edge:   edge,
+				path:   fmt.Sprintf("M%s,%s L%s,%s", num(x0), num(y0), num(x1), num(y1)),
+				labelX: (x0 + x1) / 2,
+				labelY: (y0 + y1) / 2,
+				minX:   math.Min(x0, x1), minY: math.Min(y0, y1), maxX: math.Max(x0, x1), maxY: math.Max(y0, y1),
+			})
+			continue
+		}
+
+		// A quadratic curve passes half way to its control point, which is set twice
+		// the offset away from the middle of the line
+		nx, ny := -dy/length, dx/length
+		cx, cy := (fx+sx)/2+nx*offset*2, (fy+sy)/2+ny*offset*2
+		x0, y0 := source.clip(cx, cy)
+		x1, y1 := target.clip(cx, cy)
+		routes = append(routes, route{
+			edge:   edge,
+			path:   fmt.Sprintf("M%s,%s Q%s,%s %s,%s", num(x0), num(y0), num(cx), num(cy), num(x1), num(y1)),
+			labelX: 0.25*x0 + 0.5*cx + 0.25*x1,
+			labelY: 0.25*y0 + 0.5*cy + 0.25*y1,
+			minX:   math.Min(x0, math.Min(cx, x1)), minY: math.Min(y0, math.Min(cy, y1)),
+			maxX: math.Max(x0, math.Max(cx, x1)), maxY: math.Max(y0, math.Max(cy, y1)),
+		})
+	}
+	return routes
+}
+
+// loop is an edge from a node to itself, leaving its top and coming back to its right
+// side. Further loops on the same node are drawn larger.
+func loop(edge axon_types.Edge, node box, index int) route {
+	size := loopSize * float64(index+1)
+	x0, y0 := node.x+node.width*3/4, node.y
+	x1, y1 := node.x+node.width, node.y+node.height/4
+	c0x, c0y := x0, y0-size
+	c1x, c1y := x1+size, y1
+
+	return route{
+		edge:   edge,
+		path:   fmt.Sprintf("M%s,%s C%s,%s %s,%s %s,%s", num(x0), num(y0), num(c0x), num(c0y), num(c1x), num(c1y), num(x1), num(y1)),
+		labelX: x1 + size/2,
+		labelY: y0 - size/2,
+		minX:   x0, minY: c0y, maxX: c1x, maxY: y1,
+	}
+}
+
+func pairKey(edge axon_types.Edge) string {
+	if edge.SourceID < edge.TargetID {
+		return edge.SourceID + "\x00" + edge.TargetID
+	}
+	return edge.TargetID + "\x00" + edge.SourceID
+}
+
+// writeEdge draws an edge with an arrowhead at its target and its label on a white
+// background, growing view to fit them. Animated edges are dashed.
+func writeEdge(b *strings.Builder, r route, view *area) {
+	view.add(r.minX, r.minY, r.maxX-r.minX, r.maxY-r.minY)
+
+	dash := ""
+	if r.edge.Animated {
+		dash = ` stroke-dasharray="6 4"`
+	}
+
+	fmt.Fprintf(b, "<g id=\"%s\">\n", escape("edge-"+r.edge.EdgeID))
+	fmt.Fprintf(b, `<path d="%s" fill="none" stroke="%s" stroke-width="1.5"%s marker-end="url(#axon-arrow)"/>`+"\n", r.path, edgeColor, dash)
+
+	if label := strings.Join(strings.Fields(r.edge.Label), " "); label != "" {
+		width := textWidth(label, edgeLabelSize) + 8
+		height := edgeLabelSize*lineHeight + 4
+		x, y := r.labelX-width/2, r.labelY-height/2
+		view.add(x, y, width, height)
+
+		fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s" rx="3" fill="#ffffff"/>`+"\n", num(x), num(y), num(width), num(height))
+		fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%s" fill="%s" text-anchor="middle">%s</text>`+"\n",
+			num(r.labelX), num(baseline(y+2, edgeLabelSize)), num(edgeLabelSize), edgeColor, escape(label))
+	}
+	b.WriteString("</g>\n")
+}
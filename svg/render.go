@@ -0,0 +1,286 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	axon_layout "github.com/stephensanwo/axon-lib/layout"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+const (
+	// Space around the drawing, inside the view box
+	padding = 40
+
+	titleSize       = 14.0
+	descriptionSize = 12.0
+	edgeLabelSize   = 12.0
+	lineHeight      = 1.3
+	// Space between the outline of a node and its text
+	textInset = 10.0
+
+	// Distance between edges joining the same two nodes, which are drawn as curves
+	edgeSpacing = 30.0
+	// Size of the loop drawn for an edge from a node to itself
+	loopSize = 40.0
+)
+
+// Colours used where the node styles set none
+const (
+	defaultFill        = "#ffffff"
+	defaultBorder      = "#94a3b8"
+	defaultText        = "#0f172a"
+	defaultDescription = "#475569"
+	defaultGroupFill   = "#f8fafc"
+	edgeColor          = "#64748b"
+)
+
+// Render draws a note as a standalone SVG image. Nodes are drawn at their positions
+// with the colours of their styles, group nodes behind their members, and edges as
+// arrows between the node outlines. The view box fits the nodes, and the same note
+// always renders to the same bytes.
+func Render(note axon_types.NoteDetail) (string, error) {
+	boxes := nodeBoxes(note.Nodes)
+
+	nodes := append([]axon_types.Node{}, note.Nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		aGroup, bGroup := isGroup(a), isGroup(b)
+		if aGroup != bGroup {
+			return aGroup
+		}
+		if aGroup {
+			aArea, bArea := boxes[a.NodeID].width*boxes[a.NodeID].height, boxes[b.NodeID].width*boxes[b.NodeID].height
+			if aArea != bArea {
+				return aArea > bArea
+			}
+		}
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		if a.Position.X != b.Position.X {
+			return a.Position.X < b.Position.X
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	edges := append([]axon_types.Edge{}, note.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.SourceID != b.SourceID {
+			return a.SourceID < b.SourceID
+		}
+		if a.TargetID != b.TargetID {
+			return a.TargetID < b.TargetID
+		}
+		return a.EdgeID < b.EdgeID
+	})
+
+	view := area{empty: true}
+	for _, node := range nodes {
+		b := boxes[node.NodeID]
+		view.add(b.x, b.y, b.width, b.height)
+	}
+
+	var groupsOut, edgesOut, nodesOut strings.Builder
+	for _, node := range nodes {
+		if isGroup(node) {
+			writeGroup(&groupsOut, node, boxes[node.NodeID])
+		} else {
+			writeNode(&nodesOut, node, boxes[node.NodeID])
+		}
+	}
+
+	for _, route := range routeEdges(edges, boxes) {
+		writeEdge(&edgesOut, route, &view)
+	}
+
+	if view.empty {
+		view.add(0, 0, 0, 0)
+	}
+	x, y := view.minX-padding, view.minY-padding
+	width, height := view.maxX-view.minX+2*padding, view.maxY-view.minY+2*padding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		num(width), num(height), num(x), num(y), num(width), num(height))
+	if title := noteTitle(note); title != "" {
+		fmt.Fprintf(&b, "<title>%s</title>\n", escape(title))
+	}
+	b.WriteString("<defs>\n")
+	fmt.Fprintf(&b, `<marker id="axon-arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker>`+"\n", edgeColor)
+	b.WriteString("</defs>\n")
+	fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" fill="#ffffff"/>`+"\n", num(x), num(y), num(width), num(height))
+
+	b.WriteString("<g class=\"groups\">\n" + groupsOut.String() + "</g>\n")
+	b.WriteString("<g class=\"edges\">\n" + edgesOut.String() + "</g>\n")
+	b.WriteString("<g class=\"nodes\">\n" + nodesOut.String() + "</g>\n")
+	b.WriteString("</svg>\n")
+
+	return b.String(), nil
+}
+
+func isGroup(node axon_types.Node) bool {
+	return node.Data.NodeCategory == axon_types.NODE_CATEGORY_GROUP
+}
+
+// nodeBoxes is the area of each node. Nodes take the size in their background styles,
+// or the default node size. Groups without a size are drawn around their members.
+func nodeBoxes(nodes []axon_types.Node) map[string]box {
+	boxes := make(map[string]box, len(nodes))
+	members := map[string][]axon_types.Node{}
+	for _, node := range nodes {
+		if node.ParentID != "" && node.ParentID != node.NodeID {
+			members[node.ParentID] = append(members[node.ParentID], node)
+		}
+	}
+
+	measuring := map[string]bool{}
+	var measure func(node axon_types.Node) box
+	measure = func(node axon_types.Node) box {
+		if b, ok := boxes[node.NodeID]; ok {
+			return b
+		}
+
+		b := box{x: float64(node.Position.X), y: float64(node.Position.Y), width: axon_layout.NodeWidth, height: axon_layout.NodeHeight, shape: shapeOf(node.Data.NodeCategory)}
+		width, hasWidth := axon_types.StyleNumber(node.Styles.BackgroundStyles, axon_types.STYLE_WIDTH)
+		height, hasHeight := axon_types.StyleNumber(node.Styles.BackgroundStyles, axon_types.STYLE_HEIGHT)
+		if hasWidth && width > 0 {
+			b.width = width
+		}
+		if hasHeight && height > 0 {
+			b.height = height
+		}
+
+		if isGroup(node) {
+			b.shape = shapeRectangle
+			// Groups whose parents form a cycle keep the default size
+			if !hasWidth && !measuring[node.NodeID] {
+				measuring[node.NodeID] = true
+				inner := area{empty: true}
+				for _, member := range members[node.NodeID] {
+					m := measure(member)
+					inner.add(m.x, m.y, m.width, m.height)
+				}
+				if !inner.empty {
+					b.x = inner.minX - axon_layout.GroupPadding
+					b.y = inner.minY - axon_layout.GroupPadding - axon_layout.GroupHeader
+					b.width = inner.maxX - inner.minX + 2*axon_layout.GroupPadding
+					b.height = inner.maxY - inner.minY + 2*axon_layout.GroupPadding + axon_layout.GroupHeader
+				}
+			}
+		}
+
+		boxes[node.NodeID] = b
+		return b
+	}
+
+	for _, node := range nodes {
+		measure(node)
+	}
+	return boxes
+}
+
+func writeGroup(b *strings.Builder, node axon_types.Node, bounds box) {
+	fill := styleColor(node.Styles.BackgroundStyles, axon_types.STYLE_BACKGROUND_COLOR, defaultGroupFill)
+	border := styleColor(node.Styles.BackgroundStyles, axon_types.STYLE_BORDER_COLOR, defaultBorder)
+
+	fmt.Fprintf(b, "<g id=\"%s\">\n", escape("node-"+node.NodeID))
+	fmt.Fprintf(b, "%s\n", bounds.outline(fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="1" stroke-dasharray="6 4"`, escape(fill), escape(border))))
+
+	width := bounds.width - 2*textInset
+	if lines := truncate(wrap(nodeText(node), width, titleSize), 1, width, titleSize); len(lines) > 0 {
+		color := styleColor(node.Styles.LabelStyles, axon_types.STYLE_COLOR, defaultText)
+		fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%s" font-weight="bold" fill="%s">%s</text>`+"\n",
+			num(bounds.x+textInset), num(baseline(bounds.y+textInset, titleSize)), num(titleSize), escape(color), escape(lines[0]))
+	}
+	b.WriteString("</g>\n")
+}
+
+// writeNode draws the outline of a node with its title and, when there is room, its
+// description, wrapped to the width of the node and centred
+func writeNode(b *strings.Builder, node axon_types.Node, bounds box) {
+	fill := styleColor(node.Styles.BackgroundStyles, axon_types.STYLE_BACKGROUND_COLOR, defaultFill)
+	border := styleColor(node.Styles.BackgroundStyles, axon_types.STYLE_BORDER_COLOR, defaultBorder)
+
+	fmt.Fprintf(b, "<g id=\"%s\">\n", escape("node-"+node.NodeID))
+	fmt.Fprintf(b, "%s\n", bounds.outline(fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="1.5"`, escape(fill), escape(border))))
+
+	// Text inside a diamond or an ellipse has to fit the narrower middle of the shape
+	width, height := bounds.width-2*textInset, bounds.height-2*textInset
+	if bounds.shape == shapeDiamond || bounds.shape == shapeEllipse {
+		width, height = bounds.width/math.Sqrt2-2*textInset, bounds.height/math.Sqrt2-2*textInset
+	}
+
+	titleLines := wrap(nodeText(node), width, titleSize)
+	descriptionLines := wrap(node.Data.Description, width, descriptionSize)
+
+	available := height
+	titleLines = truncate(titleLines, int(available/(titleSize*lineHeight)), width, titleSize)
+	available -= float64(len(titleLines)) * titleSize * lineHeight
+	descriptionLines = truncate(descriptionLines, int(available/(descriptionSize*lineHeight)), width, descriptionSize)
+
+	total := float64(len(titleLines))*titleSize*lineHeight + float64(len(descriptionLines))*descriptionSize*lineHeight
+	cx, cy := bounds.centre()
+	// Top of the first line, with the block of lines centred on the node
+	y := cy - total/2
+
+	titleColor := styleColor(node.Styles.LabelStyles, axon_types.STYLE_COLOR, defaultText)
+	for _, line := range titleLines {
+		fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%s" font-weight="bold" fill="%s" text-anchor="middle">%s</text>`+"\n",
+			num(cx), num(baseline(y, titleSize)), num(titleSize), escape(titleColor), escape(line))
+		y += titleSize * lineHeight
+	}
+
+	descriptionColor := styleColor(node.Styles.DescriptionStyles, axon_types.STYLE_COLOR, defaultDescription)
+	for _, line := range descriptionLines {
+		fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%s" fill="%s" text-anchor="middle">%s</text>`+"\n",
+			num(cx), num(baseline(y, descriptionSize)), num(descriptionSize), escape(descriptionColor), escape(line))
+		y += descriptionSize * lineHeight
+	}
+
+	b.WriteString("</g>\n")
+}
+
+func styleColor(styles map[string]interface{}, key string, fallback string) string {
+	if color := axon_types.StyleString(styles, key); color != "" {
+		return color
+	}
+	return fallback
+}
+
+func nodeText(node axon_types.Node) string {
+	for _, text := range []string{node.Data.Title, node.Data.Label} {
+		if strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+func noteTitle(note axon_types.NoteDetail) string {
+	if note.NoteName != "" {
+		return note.NoteName
+	}
+	return note.NoteID
+}
+
+// area is the smallest rectangle around everything added to it
+type area struct {
+	empty                  bool
+	minX, minY, maxX, maxY float64
+}
+
+func (a *area) add(x, y, width, height float64) {
+	if a.empty {
+		a.minX, a.minY, a.maxX, a.maxY = x, y, x+width, y+height
+		a.empty = false
+		return
+	}
+	a.minX = math.Min(a.minX, x)
+	a.minY = math.Min(a.minY, y)
+	a.maxX = math.Max(a.maxX, x+width)
+	a.maxY = math.Max(a.maxY, y+height)
+}
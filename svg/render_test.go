@@ -0,0 +1,166 @@
+package svg
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	axon_layout "github.com/stephensanwo/axon-lib/layout"
+	axon_types "github.com/stephensanwo/axon-lib/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the snapshots in testdata")
+
+func node(id string, category string, title string, x int, y int) axon_types.Node {
+	return axon_types.Node{
+		NodeID:   id,
+		Data:     axon_types.NodeData{Title: title, NodeCategory: category},
+		Position: axon_types.Position{X: x, Y: y},
+	}
+}
+
+func TestRenderSnapshot(t *testing.T) {
+	styled := node("styled", "process", "Styled <node> & more", 0, 0)
+	styled.Data.Description = "A description long enough to wrap over more than one line of the node"
+	styled.Styles = axon_types.NodeStyles{
+		BackgroundStyles:  map[string]interface{}{axon_types.STYLE_BACKGROUND_COLOR: "#fef3c7", axon_types.STYLE_BORDER_COLOR: "#d97706", axon_types.STYLE_WIDTH: 240.0, axon_types.STYLE_HEIGHT: 120.0},
+		LabelStyles:       map[string]interface{}{axon_types.STYLE_COLOR: "#92400e"},
+		DescriptionStyles: map[string]interface{}{axon_types.STYLE_COLOR: "#b45309"},
+	}
+
+	member := func(id string, category string, parent string, x int, y int) axon_types.Node {
+		n := node(id, category, "Member "+id, x, y)
+		n.ParentID = parent
+		return n
+	}
+
+	tests := []struct {
+		name string
+		note axon_types.NoteDetail
+	}{
+		{name: "empty", note: axon_types.NoteDetail{}},
+		{
+			// Node styles, escaped text and the shapes of a few categories
+			name: "styles",
+			note: axon_types.NoteDetail{
+				NoteName: "Styles & shapes",
+				Nodes: []axon_types.Node{
+					styled,
+					node("decision", "decision", "Decision", 300, 0),
+					node("database", "database", "Database", 0, 200),
+					node("circle", "circle", "Circle", 300, 200),
+				},
+			},
+		},
+		{
+			// A group sized around its members, a nested group, and a group with a size
+			name: "groups",
+			note: axon_types.NoteDetail{
+				NoteName: "Groups",
+				Nodes: []axon_types.Node{
+					node("outer", axon_types.NODE_CATEGORY_GROUP, "Outer", 0, 0),
+					member("a", "process", "outer", 40, 80),
+					member("inner", axon_types.NODE_CATEGORY_GROUP, "outer", 0, 0),
+					member("b", "process", "inner", 300, 80),
+					member("c", "process", "inner", 300, 200),
+					{NodeID: "fixed", Data: axon_types.NodeData{Title: "Fixed", NodeCategory: axon_types.NODE_CATEGORY_GROUP}, Position: axon_types.Position{X: 700, Y: 0},
+						Styles: axon_types.NodeStyles{BackgroundStyles: map[string]interface{}{axon_types.STYLE_WIDTH: 200.0, axon_types.STYLE_HEIGHT: 150.0}}},
+				},
+				Edges: []axon_types.Edge{{EdgeID: "e1", SourceID: "a", TargetID: "b"}},
+			},
+		},
+		{
+			// Labels, dashed edges, edges sharing two nodes, a loop and an edge to a
+			// node outside the note
+			name: "edges",
+			note: axon_types.NoteDetail{
+				NoteName: "Edges",
+				Nodes: []axon_types.Node{
+					node("a", "start", "Start", 0, 0),
+					node("b", "decision", "Valid?", 300, 0),
+					node("c", "process", "Save", 300, 200),
+				},
+				Edges: []axon_types.Edge{
+					{EdgeID: "e1", SourceID: "a", TargetID: "b", Label: "submit"},
+					{EdgeID: "e2", SourceID: "a", TargetID: "b", Label: "retry <once>", Animated: true},
+					{EdgeID: "e3", SourceID: "b", TargetID: "a"},
+					{EdgeID: "e4", SourceID: "b", TargetID: "c", Label: "yes"},
+					{EdgeID: "e5", SourceID: "c", TargetID: "c", Label: "again"},
+					{EdgeID: "e6", SourceID: "c", TargetID: "missing"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Render(test.note)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			snapshot := filepath.Join("testdata", test.name+".svg")
+			if *update {
+				if err := os.WriteFile(snapshot, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(snapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Render() differs from %s:\n%s\nwant:\n%s", snapshot, got, want)
+			}
+
+			// The same note renders to the same bytes
+			again, err := Render(test.note)
+			if err != nil || again != got {
+				t.Errorf("a second Render() differs")
+			}
+		})
+	}
+}
+
+var viewBox = regexp.MustCompile(`viewBox="([^"]*)"`)
+
+func TestRenderViewBox(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []axon_types.Node
+		want  string
+	}{
+		{"empty", nil, fmt.Sprintf("%d %d %d %d", -padding, -padding, 2*padding, 2*padding)},
+		{
+			"one node",
+			[]axon_types.Node{node("a", "process", "A", 100, 50)},
+			fmt.Sprintf("%d %d %s %s", 100-padding, 50-padding, num(axon_layout.NodeWidth+2*padding), num(axon_layout.NodeHeight+2*padding)),
+		},
+		{
+			"spread",
+			[]axon_types.Node{node("a", "process", "A", -200, -100), node("b", "process", "B", 300, 400)},
+			fmt.Sprintf("%d %d %s %s", -200-padding, -100-padding, num(500+axon_layout.NodeWidth+2*padding), num(500+axon_layout.NodeHeight+2*padding)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Render(axon_types.NoteDetail{Nodes: test.nodes})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			match := viewBox.FindStringSubmatch(got)
+			if match == nil {
+				t.Fatalf("no viewBox in %s", got)
+			}
+			if match[1] != test.want {
+				t.Errorf("viewBox = %q, want %q", match[1], test.want)
+			}
+		})
+	}
+}
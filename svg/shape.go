@@ -0,0 +1,97 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	shapeRectangle = "rectangle"
+	shapeRounded   = "rounded"
+	shapeEllipse   = "ellipse"
+	shapeDiamond   = "diamond"
+)
+
+// categoryShapes maps node categories to the shapes drawn for them. Categories not
+// listed are drawn as rectangles.
+var categoryShapes = map[string]string{
+	"rounded":   shapeRounded,
+	"start":     shapeEllipse,
+	"end":       shapeEllipse,
+	"terminal":  shapeEllipse,
+	"circle":    shapeEllipse,
+	"event":     shapeEllipse,
+	"stop":      shapeEllipse,
+	"decision":  shapeDiamond,
+	"condition": shapeDiamond,
+}
+
+func shapeOf(category string) string {
+	if shape, ok := categoryShapes[strings.ToLower(category)]; ok {
+		return shape
+	}
+	return shapeRectangle
+}
+
+// box is the area a node is drawn in, with its top left corner at x, y
+type box struct {
+	x      float64
+	y      float64
+	width  float64
+	height float64
+	shape  string
+}
+
+func (b box) centre() (float64, float64) {
+	return b.x + b.width/2, b.y + b.height/2
+}
+
+// outline is the SVG element drawing the shape of the box, with attrs added to it
+func (b box) outline(attrs string) string {
+	cx, cy := b.centre()
+	switch b.shape {
+	case shapeEllipse:
+		return fmt.Sprintf(`<ellipse cx="%s" cy="%s" rx="%s" ry="%s" %s/>`, num(cx), num(cy), num(b.width/2), num(b.height/2), attrs)
+	case shapeDiamond:
+		return fmt.Sprintf(`<polygon points="%s,%s %s,%s %s,%s %s,%s" %s/>`,
+			num(cx), num(b.y), num(b.x+b.width), num(cy), num(cx), num(b.y+b.height), num(b.x), num(cy), attrs)
+	case shapeRounded:
+		return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" rx="12" %s/>`, num(b.x), num(b.y), num(b.width), num(b.height), attrs)
+	default:
+		return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" rx="4" %s/>`, num(b.x), num(b.y), num(b.width), num(b.height), attrs)
+	}
+}
+
+// clip returns where a line from the centre of the box towards x, y leaves its
+// outline, so edges end at the border instead of under the node
+func (b box) clip(x float64, y float64) (float64, float64) {
+	cx, cy := b.centre()
+	dx, dy := x-cx, y-cy
+	if dx == 0 && dy == 0 {
+		return cx, cy
+	}
+
+	hw, hh := b.width/2, b.height/2
+	var t float64
+	switch b.shape {
+	case shapeEllipse:
+		t = 1 / math.Sqrt((dx/hw)*(dx/hw)+(dy/hh)*(dy/hh))
+	case shapeDiamond:
+		t = 1 / (math.Abs(dx)/hw + math.Abs(dy)/hh)
+	default:
+		t = math.Inf(1)
+		if dx != 0 {
+			t = hw / math.Abs(dx)
+		}
+		if dy != 0 && hh/math.Abs(dy) < t {
+			t = hh / math.Abs(dy)
+		}
+	}
+
+	// The point is inside the box, the line does not leave it
+	if t > 1 {
+		return x, y
+	}
+	return cx + dx*t, cy + dy*t
+}
@@ -0,0 +1,99 @@
+package svg
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Average glyph width as a share of the font size. Text is measured without fonts, so
+// wrapping stays the same wherever the SVG is rendered.
+const glyphWidth = 0.6
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
+
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// num formats a coordinate with at most two decimals
+func num(f float64) string {
+	rounded := math.Round(f*100) / 100
+	if rounded == 0 {
+		// Avoids writing -0
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// baseline is where to write a line of text whose line box starts at top
+func baseline(top float64, size float64) float64 {
+	return top + size*(lineHeight-1)/2 + size*0.8
+}
+
+func textWidth(text string, size float64) float64 {
+	return float64(utf8.RuneCountInString(text)) * size * glyphWidth
+}
+
+// wrap breaks text into lines that fit width, at spaces where it can and inside long
+// words where it cannot
+func wrap(text string, width float64, size float64) []string {
+	limit := int(width / (size * glyphWidth))
+	if limit < 1 {
+		limit = 1
+	}
+
+	lines := []string{}
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for utf8.RuneCountInString(word) > limit {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:limit]))
+				word = string(runes[limit:])
+			}
+
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= limit:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// truncate keeps the first count lines, ending the last one with an ellipsis when
+// lines were dropped
+func truncate(lines []string, count int, width float64, size float64) []string {
+	if len(lines) <= count {
+		return lines
+	}
+	if count <= 0 {
+		return []string{}
+	}
+
+	lines = append([]string{}, lines[:count]...)
+	last := []rune(lines[count-1])
+	limit := int(width/(size*glyphWidth)) - 1
+	if limit < 0 {
+		limit = 0
+	}
+	if len(last) > limit {
+		last = last[:limit]
+	}
+	lines[count-1] = strings.TrimSpace(string(last)) + "…"
+	return lines
+}
@@ -6,6 +6,11 @@ import (
 	oauthTypes "golang.org/x/oauth2"
 )
 
+const (
+	SESSION_CONTEXT_KEY     AxonContextKey = "axon_session"
+	PATH_PARAMS_CONTEXT_KEY AxonContextKey = "axon_path_params"
+)
+
 type AxonContext struct {
 	Context            context.Context
 	Settings           Settings           `json:"settings"`
@@ -16,6 +16,9 @@ type FolderList struct {
 	DateCreated time.Time          `json:"date_created"`
 	LastEdited  time.Time          `json:"last_edited"`
 	Notes       []Note             `json:"notes"`
+	// Set for folders shared with the user, with the highest permission granted
+	OwnerEmail  string             `json:"owner_email,omitempty"`
+	Permission  string             `json:"permission,omitempty"`
 }
 
 type NoteDetail struct {
@@ -24,6 +27,7 @@ type NoteDetail struct {
 	NoteID      string `json:"note_id"`
 	NoteName        string             `json:"note_name"`
 	Description string             `json:"description"`
+	Tags        []string           `json:"tags,omitempty"`
 	DateCreated time.Time          `json:"date_created"`
 	LastEdited  time.Time          `json:"last_edited"`
 	Nodes       []Node             `json:"nodes"`
@@ -44,6 +48,7 @@ type Note struct {
 	NoteID      string `json:"note_id"`
 	NoteName        string             `json:"note_name"`
 	Description string             `json:"description"`
+	Tags        []string           `json:"tags,omitempty"`
 	DateCreated time.Time          `json:"date_created"`
 	LastEdited  time.Time          `json:"last_edited"`
 }
@@ -57,9 +62,17 @@ type Node struct {
 	Position   Position           `json:"position"`
 	Content    NodeContent        `json:"node_content"`
 	Styles     NodeStyles         `json:"node_styles"`
+	// Node ID of the group node this node is drawn inside, positions stay absolute
+	ParentID   string             `json:"parent_id,omitempty"`
+	Tags       []string           `json:"tags,omitempty"`
+	// Fields of imported formats with no equivalent, keyed by format, written back on export
+	Extra      map[string]map[string]interface{} `json:"extra,omitempty"`
 	LastEdited time.Time          `json:"last_edited"`
 }
 
+// Category of a node that groups other nodes, its members set ParentID to its ID
+const NODE_CATEGORY_GROUP string = "group"
+
 type NodeData struct {
 	Label        string `json:"label"`
 	Title        string `json:"title"`
@@ -92,5 +105,7 @@ type Edge struct {
 	Animated   bool               `json:"animated"`
 	Label      string             `json:"label"`
 	EdgeType   string             `json:"edge_type"`
+	// Fields of imported formats with no equivalent, keyed by format, written back on export
+	Extra      map[string]map[string]interface{} `json:"extra,omitempty"`
 	LastEdited time.Time          `json:"last_edited"`
 }
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+const (
+	DeletionInProgress = "in_progress"
+	DeletionCompleted  = "completed"
+)
+
+// DeletionReceipt records the deletion of an account, and is kept once the account is
+// gone. It holds no personal data, the account is identified by its user ID and the
+// SHA-256 hash of its email.
+type DeletionReceipt struct {
+	DeletionId  string     `json:"deletion_id"`
+	UserId      string     `json:"user_id"`
+	EmailHash   string     `json:"email_hash"`
+	Status      string     `json:"status"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// Steps finished, in the order they ran. An interrupted deletion resumes after them
+	Steps []string `json:"steps"`
+	// Records removed, by kind
+	Removed map[string]int `json:"removed"`
+	// Times the deletion was run, more than once when it was resumed
+	Runs int `json:"runs"`
+}
@@ -0,0 +1,31 @@
+package types
+
+import "time"
+
+const (
+	LINK_PREFIX string = "axon_link_"
+)
+
+// PublicLink is the stored record of a public read-only link to a note. Only the
+// SHA-256 hash of the link token and the bcrypt hash of the password are persisted.
+type PublicLink struct {
+	LinkId       string     `json:"link_id"`
+	UserId       string     `json:"user_id"`
+	Email        string     `json:"email"`
+	FolderID     string     `json:"folder_id"`
+	NoteID       string     `json:"note_id"`
+	TokenHash    string     `json:"token_hash"`
+	PasswordHash string     `json:"password_hash,omitempty"`
+	DateCreated  time.Time  `json:"date_created"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// PublicLinkList is the owner's view of a link, without the hashes
+type PublicLinkList struct {
+	LinkId      string     `json:"link_id"`
+	FolderID    string     `json:"folder_id"`
+	NoteID      string     `json:"note_id"`
+	HasPassword bool       `json:"has_password"`
+	DateCreated time.Time  `json:"date_created"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
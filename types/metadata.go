@@ -8,4 +8,6 @@ const (
 type Metadata struct {
 	Environment string `yaml:"environment"`
 	Version     string `yaml:"version"`
+	// logrus level, e.g. debug, info or warn. Unset leaves the level unchanged.
+	LogLevel string `yaml:"log_level"`
 }
@@ -12,4 +12,13 @@ type Route struct {
 	Auth    string                                                 `json:"auth"`
 	Handler func(http.ResponseWriter, *http.Request, *AxonContext) `json:"handler"`
 	Method  string                                                 `json:"method"`
+
+	// Optional documentation, used to generate the OpenAPI specification. Request and
+	// Response are zero values of the JSON body types, Status the success status code
+	// and Errors the error status codes the route is known to return.
+	Summary  string      `json:"summary,omitempty"`
+	Request  interface{} `json:"-"`
+	Response interface{} `json:"-"`
+	Status   int         `json:"status,omitempty"`
+	Errors   []int       `json:"errors,omitempty"`
 }
@@ -0,0 +1,81 @@
+package types
+
+import "time"
+
+// Kinds of search documents
+const (
+	SearchKindFolder = "folder"
+	SearchKindNote   = "note"
+	SearchKindNode   = "node"
+)
+
+// Fields of search documents, matches in earlier fields rank higher
+const (
+	SearchFieldName        = "name"
+	SearchFieldLabel       = "label"
+	SearchFieldTitle       = "title"
+	SearchFieldDescription = "description"
+	SearchFieldMarkdown    = "markdown"
+)
+
+// SearchDocument is the searchable text of a folder, note or node, stored in the
+// SEARCH#<email> partition of the owner. Its sort key is the folder ID, the folder
+// and note IDs, or the folder, note and node IDs joined by #, so the documents of a
+// folder or note share a prefix.
+type SearchDocument struct {
+	Kind     string `json:"kind"`
+	FolderID string `json:"folder_id"`
+	NoteID   string `json:"note_id,omitempty"`
+	NodeID   string `json:"node_id,omitempty"`
+	// Text of each field, by field name
+	Fields map[string]string `json:"fields"`
+	// Words of the fields, each indexed by a SearchPosting
+	Terms      []string  `json:"terms,omitempty"`
+	LastEdited time.Time `json:"last_edited"`
+}
+
+// SearchPosting indexes a document under one of its words, in the SEARCHTERM#<email>
+// partition. Its sort key is the word and the document sort key joined by #, so the
+// documents with a word, or with a word starting with a prefix, are read together.
+type SearchPosting struct {
+	Term     string `json:"term"`
+	Document string `json:"document"`
+}
+
+type SearchResults struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+	// Documents that matched, which can be more than the results returned
+	Total int `json:"total"`
+	// Set when the query has more candidates than one search reads, Total then only
+	// counts the matches among those read
+	Truncated bool `json:"truncated"`
+}
+
+// SearchResult is a matching folder, note or node with the IDs to open it
+type SearchResult struct {
+	Kind     string `json:"kind"`
+	FolderID string `json:"folder_id"`
+	NoteID   string `json:"note_id,omitempty"`
+	NodeID   string `json:"node_id,omitempty"`
+	// Name, title or label of the document
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+	// Field the snippet is taken from
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+	// Byte ranges of the snippet that matched the query
+	Highlights []SearchHighlight `json:"highlights"`
+}
+
+type SearchHighlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchIndexReport counts the folders, notes and nodes read when an index is rebuilt
+type SearchIndexReport struct {
+	Folders int `json:"folders"`
+	Notes   int `json:"notes"`
+	Nodes   int `json:"nodes"`
+}
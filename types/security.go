@@ -0,0 +1,17 @@
+package types
+
+type SecuritySettings struct {
+	// Key used to wrap new data keys, must be present in Keys
+	ActiveKeyId string `yaml:"active_key_id"`
+	// Key ID to base64 encoded 256 bit AES key. Retired keys stay here until
+	// every record sealed with them has been rewrapped.
+	Keys map[string]string `yaml:"keys"`
+}
+
+// SealedField is a value encrypted with envelope encryption. The value is sealed with
+// a random data key, and the data key is sealed with the key ring key KeyId.
+type SealedField struct {
+	KeyId      string `json:"key_id"`
+	WrappedKey string `json:"wrapped_key"`
+	Ciphertext string `json:"ciphertext"`
+}
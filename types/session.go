@@ -1,10 +1,22 @@
 package types
 
+import "time"
+
 const (
 	AUTH_SESSION string = "axon_auth_session"
+	SESSION_TTL  int64  = 12 * 60 * 60
 )
 
 type Session struct {
 	SessionId   string
 	SessionData UserCache
+	// Scopes is only set for sessions authenticated with a personal access token
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// UserSession lists a session under its user, so all of a user's sessions can be
+// found and revoked. It expires with the session.
+type UserSession struct {
+	SessionId   string    `json:"session_id"`
+	DateCreated time.Time `json:"date_created"`
 }
@@ -1,11 +1,14 @@
 package types
 
 type Settings struct {
-	Metadata      Metadata      `yaml:"metadata"`
-	HttpSettings  HttpSettings  `yaml:"http"`
-	OauthSettings OauthSettings `yaml:"oauth_settings"`
-	CoreSettings  struct {
+	Metadata         Metadata         `yaml:"metadata"`
+	HttpSettings     HttpSettings     `yaml:"http"`
+	OauthSettings    OauthSettings    `yaml:"oauth_settings"`
+	SecuritySettings SecuritySettings `yaml:"security"`
+	CoreSettings     struct {
 		GithubArchiveRepo string `yaml:"github_archive_repo"`
+		// Repository synced with notes in both directions, the archive repository when empty
+		GithubSyncRepo string `yaml:"github_sync_repo"`
 	} `yaml:"core_settings"`
 	AxonClient struct {
 		AuthRedirectUrl string `yaml:"auth_redirect_url"`
@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+const (
+	PermissionViewer    = "viewer"
+	PermissionCommenter = "commenter"
+	PermissionEditor    = "editor"
+	PermissionOwner     = "owner"
+
+	ShareResourceFolder = "folder"
+	ShareResourceNote   = "note"
+
+	ShareActionGranted = "granted"
+	ShareActionChanged = "changed"
+	ShareActionRevoked = "revoked"
+)
+
+type Share struct {
+	ShareId        string    `json:"share_id"`
+	OwnerId        string    `json:"owner_id"`
+	OwnerEmail     string    `json:"owner_email"`
+	RecipientEmail string    `json:"recipient_email"`
+	ResourceType   string    `json:"resource_type"`
+	FolderID       string    `json:"folder_id"`
+	NoteID         string    `json:"note_id,omitempty"`
+	Permission     string    `json:"permission"`
+	DateCreated    time.Time `json:"date_created"`
+	LastEdited     time.Time `json:"last_edited"`
+}
+
+// ShareAudit records every grant, permission change and revocation made on a user's items
+type ShareAudit struct {
+	AuditId            string    `json:"audit_id"`
+	ShareId            string    `json:"share_id"`
+	ActorEmail         string    `json:"actor_email"`
+	Action             string    `json:"action"`
+	RecipientEmail     string    `json:"recipient_email"`
+	ResourceType       string    `json:"resource_type"`
+	FolderID           string    `json:"folder_id"`
+	NoteID             string    `json:"note_id,omitempty"`
+	Permission         string    `json:"permission,omitempty"`
+	PreviousPermission string    `json:"previous_permission,omitempty"`
+	DateCreated        time.Time `json:"date_created"`
+}
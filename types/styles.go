@@ -0,0 +1,29 @@
+package types
+
+// Keys of the NodeStyles maps, named after the CSS properties the editor applies
+const (
+	STYLE_BACKGROUND_COLOR string = "backgroundColor"
+	STYLE_BORDER_COLOR     string = "borderColor"
+	STYLE_COLOR            string = "color"
+	// Size of a group node, in background styles
+	STYLE_WIDTH  string = "width"
+	STYLE_HEIGHT string = "height"
+)
+
+// StyleString returns a style value when it is a string, and "" otherwise
+func StyleString(styles map[string]interface{}, key string) string {
+	value, _ := styles[key].(string)
+	return value
+}
+
+// StyleNumber returns a numeric style value, which is an int when set in Go and a
+// float64 when read back from JSON or DynamoDB
+func StyleNumber(styles map[string]interface{}, key string) (float64, bool) {
+	switch value := styles[key].(type) {
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// SyncState records the last successful sync of a user's notes with a repository.
+// It is the common base both sides are compared against to tell who changed what.
+type SyncState struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// Directory of the user's files in the repository
+	Directory string `json:"directory"`
+	// Commit written or confirmed by the last sync
+	CommitSHA string `json:"commit_sha"`
+	// Folder IDs present on both sides after the last sync
+	Folders    []string                 `json:"folders"`
+	Notes      map[string]NoteSyncState `json:"notes"`
+	LastSynced time.Time                `json:"last_synced"`
+	// Set on the first save, needed to list the states of a user
+	DateCreated time.Time `json:"date_created"`
+}
+
+// NoteSyncState is a note as it was after the last sync. BlobSHA is the git object ID
+// of the note file, which is also the hash of the note as it was in axon.
+type NoteSyncState struct {
+	FolderID   string    `json:"folder_id"`
+	NoteID     string    `json:"note_id"`
+	BlobSHA    string    `json:"blob_sha"`
+	LastEdited time.Time `json:"last_edited"`
+}
@@ -0,0 +1,31 @@
+package types
+
+import "time"
+
+// Kinds of tagged items
+const (
+	TaggedKindNote = "note"
+	TaggedKindNode = "node"
+)
+
+// Tag is an entry of a user's tag catalogue, stored in the TAG#<email> partition
+// under its name, with the number of notes and nodes that have it
+type Tag struct {
+	Name        string    `json:"name"`
+	Notes       int       `json:"notes"`
+	Nodes       int       `json:"nodes"`
+	DateCreated time.Time `json:"date_created"`
+	LastEdited  time.Time `json:"last_edited"`
+}
+
+// TaggedItem indexes a note or node under a tag, in the TAGGED#<email>#<tag>
+// partition. Its sort key is the kind followed by the folder, note and node IDs joined
+// by #, so the notes and the nodes of a tag are read separately.
+type TaggedItem struct {
+	Tag         string    `json:"tag"`
+	Kind        string    `json:"kind"`
+	FolderID    string    `json:"folder_id"`
+	NoteID      string    `json:"note_id"`
+	NodeID      string    `json:"node_id,omitempty"`
+	DateCreated time.Time `json:"date_created"`
+}
@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+const (
+	TOKEN_PREFIX string = "axon_pat_"
+
+	TokenScopeRead  = "read"
+	TokenScopeWrite = "write"
+	TokenScopeAdmin = "admin"
+)
+
+// PersonalAccessToken is the stored record of a token. Only the SHA-256 hash
+// of the secret is persisted, the plaintext is returned once on creation.
+type PersonalAccessToken struct {
+	TokenId     string     `json:"token_id"`
+	UserId      string     `json:"user_id"`
+	Email       string     `json:"email"`
+	TokenName   string     `json:"token_name"`
+	TokenHash   string     `json:"token_hash"`
+	Scopes      []string   `json:"scopes"`
+	DateCreated time.Time  `json:"date_created"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsed    *time.Time `json:"last_used,omitempty"`
+}
+
+// TokenList is the public view of a token, without the hash
+type TokenList struct {
+	TokenId     string     `json:"token_id"`
+	TokenName   string     `json:"token_name"`
+	Scopes      []string   `json:"scopes"`
+	DateCreated time.Time  `json:"date_created"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsed    *time.Time `json:"last_used,omitempty"`
+}
@@ -1,5 +1,7 @@
 package types
 
+import "golang.org/x/oauth2"
+
 type User struct {
 	UserId    string `json:"user_id"`
 	Email     string             `json:"email"`
@@ -10,6 +12,12 @@ type User struct {
 }
 
 type UserCache struct {
-	User        User   `json:"user"`
-	AccessToken string `json:"access_token"`
+	User        User          `json:"user"`
+	// Token is the provider OAuth token, with its refresh token and expiry. It is
+	// never written to the database or encoded, only its sealed form is stored
+	Token       *oauth2.Token `json:"-" dynamodbav:"-"`
+	SealedToken *SealedField  `json:"-" dynamodbav:"sealed_token,omitempty"`
+	// AccessToken is the plaintext token of sessions stored before tokens were sealed.
+	// It is moved into Token and sealed when the session is read
+	AccessToken string        `json:"-" dynamodbav:"access_token,omitempty"`
 }